@@ -1,5 +1,5 @@
 module github.com/rhansen/go-kairos
 
-go 1.20
+go 1.22
 
 require golang.org/x/sync v0.1.0
@@ -0,0 +1,118 @@
+package kairos
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// WithAuditLog makes the clock keep the last n operations on its timers in a ring buffer,
+// returned by [Diagnostics.AuditLog], so that when a timeout misbehaves, the sequence of starts,
+// stops, and expirations that preceded it can be inspected after the fact.  Unlike a [Recorder],
+// the log takes bounded memory, so it can stay on in production.  WithAuditLog panics if n is not
+// positive.
+func WithAuditLog(n int) ClockOption {
+	if n <= 0 {
+		panic("kairos: non-positive size for WithAuditLog")
+	}
+	return func(cfg *clockConfig) { cfg.auditSize = n }
+}
+
+// An AuditEntry is a timer operation in the log of [WithAuditLog].
+type AuditEntry struct {
+	Op    Op
+	Timer uint64 // Number of the timer, unique in the process.
+	Label string // Label of the timer at the time of the operation.
+	// Time is the clock's time when the operation happened.
+	Time time.Time
+	// Deadline is the deadline of the timer, for OpReset and OpFire entries.
+	Deadline time.Time
+	// Duration is the duration passed to Reset, for OpReset entries.
+	Duration time.Duration
+	// Active is the value returned by Reset or Stop, for OpReset and OpStop entries.
+	Active bool
+}
+
+// An auditLog is a ring buffer of the last operations of a clock.
+type auditLog struct {
+	entries []AuditEntry
+	next    int  // Index of the slot of the next entry.
+	full    bool // Whether every slot holds an entry.
+}
+
+// setAudit applies the audit log option of cfg to clk.
+func (clk *clock) setAudit(cfg clockConfig) {
+	if cfg.auditSize > 0 {
+		clk.audit = &auditLog{entries: make([]AuditEntry, cfg.auditSize)}
+	}
+}
+
+// add logs an operation on t, overwriting the oldest entry if the log is full.
+func (l *auditLog) add(op Op, t *Timer, now time.Time, d time.Duration, active bool) {
+	e := AuditEntry{Op: op, Timer: t.id, Label: t.label, Time: now}
+	switch op {
+	case OpReset:
+		e.Deadline, e.Duration, e.Active = t.when, d, active
+	case OpStop:
+		e.Active = active
+	case OpFire:
+		e.Deadline = t.when
+	}
+	l.entries[l.next] = e
+	l.next++
+	if l.next == len(l.entries) {
+		l.next, l.full = 0, true
+	}
+}
+
+// AuditLog returns the last timer operations, oldest first, or nil without WithAuditLog.
+func (clk *clock) AuditLog() []AuditEntry {
+	clk.mutex.Lock()
+	defer clk.mutex.Unlock()
+	l := clk.audit
+	if l == nil {
+		return nil
+	}
+	if !l.full {
+		return append([]AuditEntry(nil), l.entries[:l.next]...)
+	}
+	return append(append([]AuditEntry(nil), l.entries[l.next:]...), l.entries[:l.next]...)
+}
+
+// AuditLog returns the logs of the shards merged in time order.
+func (sc *shardedClock) AuditLog() []AuditEntry {
+	var entries []AuditEntry
+	for _, shard := range sc.shards {
+		entries = append(entries, shard.AuditLog()...)
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Time.Before(entries[j].Time) })
+	return entries
+}
+
+// WriteAuditLog writes a human-readable description of the entries of an audit log to w, one per
+// line.
+func WriteAuditLog(w io.Writer, entries []AuditEntry) error {
+	var b strings.Builder
+	for _, e := range entries {
+		fmt.Fprintf(&b, "%v timer %d", e.Time.Format(time.RFC3339Nano), e.Timer)
+		if e.Label != "" {
+			fmt.Fprintf(&b, " %q", e.Label)
+		}
+		switch e.Op {
+		case OpReset:
+			fmt.Fprintf(&b, ": reset(%v) until %v, active %v", e.Duration,
+				e.Deadline.Format(time.RFC3339Nano), e.Active)
+		case OpStop:
+			fmt.Fprintf(&b, ": stop, active %v", e.Active)
+		case OpFire:
+			fmt.Fprintf(&b, ": fire, due %v", e.Deadline.Format(time.RFC3339Nano))
+		default:
+			fmt.Fprintf(&b, ": %s", e.Op)
+		}
+		b.WriteString("\n")
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,86 @@
+package kairos
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAuditLog(t *testing.T) {
+	fc := NewFakeClock(fakeStart, WithAuditLog(3))
+	defer fc.Close()
+	if got := fc.AuditLog(); len(got) != 0 {
+		t.Errorf("got log %+v of a new clock, want empty", got)
+	}
+	timer := fc.NewTimer(time.Second)
+	timer.SetLabel("idle")
+	timer.Stop()
+	id := timer.id
+	t1 := fakeStart.Add(time.Second)
+	want := []AuditEntry{
+		{Op: OpReset, Timer: id, Time: fakeStart, Deadline: t1, Duration: time.Second},
+		{Op: OpStop, Timer: id, Label: "idle", Time: fakeStart, Active: true},
+	}
+	if got := fc.AuditLog(); !slices.Equal(got, want) {
+		t.Errorf("got log %+v, want %+v", got, want)
+	}
+
+	// The log keeps the last 3 entries.
+	timer.Reset(time.Second)
+	fc.Advance(time.Second)
+	timer.Stop()
+	want = []AuditEntry{
+		{Op: OpReset, Timer: id, Label: "idle", Time: fakeStart, Deadline: t1, Duration: time.Second},
+		{Op: OpFire, Timer: id, Label: "idle", Time: t1, Deadline: t1},
+		{Op: OpStop, Timer: id, Label: "idle", Time: t1},
+	}
+	got := fc.AuditLog()
+	if !slices.Equal(got, want) {
+		t.Errorf("got log %+v, want %+v", got, want)
+	}
+
+	var b strings.Builder
+	if err := WriteAuditLog(&b, got); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`2000-01-01T00:00:00Z timer %d "idle": reset(1s) until 2000-01-01T00:00:01Z, active false`,
+		`2000-01-01T00:00:01Z timer %d "idle": fire, due 2000-01-01T00:00:01Z`,
+		`2000-01-01T00:00:01Z timer %d "idle": stop, active false`,
+	} {
+		if want := strings.ReplaceAll(want, "%d", strconv.FormatUint(id, 10)); !strings.Contains(b.String(), want+"\n") {
+			t.Errorf("got log:\n%s\nwant a line %s", b.String(), want)
+		}
+	}
+
+	if got := NewFakeClock(fakeStart).AuditLog(); got != nil {
+		t.Errorf("got log %+v without WithAuditLog, want nil", got)
+	}
+}
+
+func TestShardedAuditLog(t *testing.T) {
+	c := NewClock(WithShards(2), WithAuditLog(10))
+	defer c.Close()
+	var ids []uint64
+	for i := 0; i < 4; i++ {
+		timer := c.NewTimer(time.Hour)
+		timer.Stop()
+		ids = append(ids, timer.impl().id)
+	}
+	log := diagnostics(c).AuditLog()
+	if len(log) != 8 {
+		t.Fatalf("got %d entries, want 8", len(log))
+	}
+	for i := 1; i < len(log); i++ {
+		if log[i].Time.Before(log[i-1].Time) {
+			t.Errorf("entry %d at %v is before entry %d at %v", i, log[i].Time, i-1, log[i-1].Time)
+		}
+	}
+	for _, id := range ids {
+		if n := slices.IndexFunc(log, func(e AuditEntry) bool { return e.Timer == id }); n < 0 {
+			t.Errorf("timer %d not in the log", id)
+		}
+	}
+}
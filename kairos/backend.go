@@ -0,0 +1,81 @@
+package kairos
+
+import (
+	"fmt"
+	"time"
+)
+
+// A Backend is the engine that keeps track of the armed timers of a clock created by [NewClock] or
+// [NewClockFromFunc].  Each suits a different workload:
+//
+//   - [HeapBackend], the default, fires every timer at its exact deadline, in deadline order.
+//     Starting or stopping a timer takes time logarithmic in the number of armed timers.
+//   - [WheelBackend] rounds deadlines up to a tick and fires the timers of a tick together, in no
+//     particular order.  Starting or stopping a timer takes constant time, which suits millions
+//     of timers, such as connection timeouts, that need not fire precisely.
+//   - [RuntimeBackend] delegates each timer to a runtime timer, like [WithRuntimeTimers].
+type Backend struct {
+	name     string
+	newQueue func() timerQueue // Nil for runtime timers.
+}
+
+// String returns the name of the backend.
+func (b Backend) String() string { return b.name }
+
+// HeapBackend returns the [Backend] that keeps the armed timers in a heap ordered by deadline.
+func HeapBackend() Backend {
+	return Backend{name: "heap", newQueue: func() timerQueue { return &timerHeap{} }}
+}
+
+// WheelBackend returns the [Backend] that keeps the armed timers in a timing wheel whose slots span
+// tick.  A timer fires at the end of the slot of its deadline, so up to tick late.  WheelBackend
+// panics if tick is not positive.
+func WheelBackend(tick time.Duration) Backend {
+	if tick <= 0 {
+		panic("kairos: non-positive tick for WheelBackend")
+	}
+	return Backend{
+		name:     fmt.Sprintf("wheel(%v)", tick),
+		newQueue: func() timerQueue { return newTimerWheel(tick) },
+	}
+}
+
+// RuntimeBackend returns the [Backend] that delegates each timer to a standard library runtime
+// timer.  It is equivalent to [WithRuntimeTimers].
+func RuntimeBackend() Backend {
+	return Backend{name: "runtime"}
+}
+
+// WithBackend makes a clock created by [NewClock] or [NewClockFromFunc] keep track of its armed
+// timers with b.  A [FakeClock] always uses [HeapBackend].
+func WithBackend(b Backend) ClockOption {
+	return func(cfg *clockConfig) {
+		cfg.runtime = b.newQueue == nil
+		cfg.newQueue = b.newQueue
+	}
+}
+
+// A timerQueue holds the armed timers of a clock in the order in which they are due.  The methods
+// are called with the clock's mutex held.
+type timerQueue interface {
+	Len() int
+	// Insert adds t, which must not be in the queue, with its current deadline.
+	Insert(t *Timer)
+	// Remove removes t and reports whether it was in the queue.
+	Remove(t *Timer) bool
+	// Has reports whether t is in the queue.
+	Has(t *Timer) bool
+	// Peek returns the timer that is due first, or nil if the queue is empty.
+	Peek() *Timer
+	// Due returns the time at which t, which must be in the queue, is due.  It is never before the
+	// deadline of t.
+	Due(t *Timer) time.Time
+	// Counts returns the counts of the timers in the queue by kind.
+	Counts() timerCounts
+	// Size returns the number of timers the queue can hold before it must grow, and an estimate of
+	// the memory it holds, excluding the timers.
+	Size() (capacity int, bytes int64)
+	// AppendTo appends the timers in the queue to dst, in no particular order, and returns the
+	// extended slice.
+	AppendTo(dst []*Timer) []*Timer
+}
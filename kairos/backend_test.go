@@ -0,0 +1,100 @@
+package kairos
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+var backends = []Backend{HeapBackend(), WheelBackend(time.Millisecond), RuntimeBackend()}
+
+func TestBackends(t *testing.T) {
+	for _, b := range backends {
+		t.Run(b.String(), func(t *testing.T) {
+			c := NewClock(WithBackend(b))
+			defer c.Close()
+			start := time.Now()
+			var timers []*Timer
+			for i := 0; i < 100; i++ {
+				timers = append(timers, c.NewTimer(time.Duration(rand.Intn(20))*time.Millisecond))
+			}
+			stopped := c.NewTimer(10 * time.Millisecond)
+			postponed := c.NewTimer(time.Millisecond)
+			postponed.Reset(30 * time.Millisecond)
+			timers = append(timers, postponed)
+			if !stopped.Stop() {
+				t.Errorf("Stop: was active is false")
+			}
+			for i, timer := range timers {
+				got := waitFired(t, timer)
+				if d := got.Sub(start); d < timer.when.Sub(start) || d >= timer.when.Sub(start)+margin {
+					t.Errorf("timer %d fired after %v, want %v", i, d, timer.when.Sub(start))
+				}
+			}
+			if _, ok := recv(stopped.C); ok {
+				t.Errorf("stopped timer fired")
+			}
+			if got := diagnostics(c).Pending(); got != 0 {
+				t.Errorf("got %d pending timers, want 0", got)
+			}
+		})
+	}
+}
+
+func TestTimerWheel(t *testing.T) {
+	const tick = 10 * time.Millisecond
+	w := newTimerWheel(tick)
+	var timers []*Timer
+	for i := 0; i < 1000; i++ {
+		timer := &Timer{when: fakeStart.Add(time.Duration(rand.Int63n(int64(time.Second))))}
+		timers = append(timers, timer)
+		w.Insert(timer)
+	}
+	for _, timer := range timers[:500] {
+		if !w.Remove(timer) {
+			t.Fatalf("Remove of an inserted timer: got false, want true")
+		}
+	}
+	if w.Remove(timers[0]) || w.Has(timers[0]) {
+		t.Errorf("Remove or Has of a removed timer: got true, want false")
+	}
+	if got := w.Len(); got != 500 {
+		t.Errorf("got length %d, want 500", got)
+	}
+	var prev time.Time
+	for timer := w.Peek(); timer != nil; timer = w.Peek() {
+		due := w.Due(timer)
+		if due.Before(prev) || due.Before(timer.when) || due.Sub(timer.when) >= tick || due.Truncate(tick) != due {
+			t.Fatalf("timer with deadline %v is due at %v after %v, want the next multiple of %v",
+				timer.when, due, prev, tick)
+		}
+		prev = due
+		w.Remove(timer)
+	}
+	if got := w.Len(); got != 0 {
+		t.Errorf("got length %d after removing every timer, want 0", got)
+	}
+}
+
+func BenchmarkBackends(b *testing.B) {
+	for _, backend := range append(backends, WheelBackend(time.Second)) {
+		for _, n := range []int{1e3, 1e5} {
+			b.Run(fmt.Sprintf("%v/pre-filled %v", backend, n), func(b *testing.B) {
+				c := NewClock(WithBackend(backend))
+				defer c.Close()
+				timers := make([]*Timer, n)
+				for i := range timers {
+					timers[i] = c.NewTimer(time.Hour + time.Duration(rand.Int63n(int64(time.Hour))))
+				}
+				b.ReportAllocs()
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					timer := timers[i%n]
+					timer.Stop()
+					timer.Reset(time.Hour + time.Duration(i%n)*time.Millisecond)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,61 @@
+// Package benbjohnsonadapter lets a [benbjohnson/clock] clock drive kairos timers, so that code
+// already tested with [clock.Mock] can switch to kairos timers without changing its tests.
+//
+// The package only goes from clock to kairos.  A [clock.Clock] backed by kairos, with its Timer,
+// Ticker, and AfterFunc methods mapped to kairos timers, cannot be implemented outside package
+// clock: those methods return the concrete types [*clock.Timer] and [*clock.Ticker], whose fields
+// are unexported and whose methods only work on values created by package clock itself.  Code that
+// wants kairos timers underneath should accept a [kairos.Clock] instead, and its tests can still
+// drive it with a clock.Mock through [ToKairos].
+//
+// [benbjohnson/clock]: https://github.com/benbjohnson/clock
+package benbjohnsonadapter
+
+import (
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/rhansen/go-kairos/kairos"
+)
+
+// ToKairos returns a [kairos.Clock] whose time is read from c and whose timer routine sleeps using
+// timers created by c, so that a [clock.Mock] can drive kairos timers.  opts configure the returned
+// clock; [kairos.WithSleeper] is overridden.
+func ToKairos(c clock.Clock, opts ...kairos.ClockOption) kairos.Clock {
+	opts = append(opts[:len(opts):len(opts)], kairos.WithSleeper(&sleeper{c: c}))
+	return kairos.NewClockFromFunc(c.Now, opts...)
+}
+
+// sleeper is a [kairos.Sleeper] backed by a [clock.Timer].  It is only used by the timer routine of
+// a single clock, so it needs no locking.
+type sleeper struct {
+	c clock.Clock
+	t *clock.Timer // Created on first use.
+}
+
+func (s *sleeper) C() <-chan time.Time {
+	if s.t == nil {
+		return nil
+	}
+	return s.t.C
+}
+
+func (s *sleeper) Reset(d time.Duration) {
+	if s.t == nil {
+		s.t = s.c.Timer(d)
+		return
+	}
+	s.Stop()
+	s.t.Reset(d)
+}
+
+func (s *sleeper) Stop() {
+	if s.t == nil {
+		return
+	}
+	s.t.Stop()
+	select {
+	case <-s.t.C:
+	default:
+	}
+}
@@ -0,0 +1,53 @@
+package benbjohnsonadapter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+)
+
+// An armedMock is a mock clock that reports the timers created on it.
+type armedMock struct {
+	*clock.Mock
+	armed chan time.Duration
+}
+
+func (m *armedMock) Timer(d time.Duration) *clock.Timer {
+	t := m.Mock.Timer(d)
+	m.armed <- d
+	return t
+}
+
+func TestToKairos(t *testing.T) {
+	start := time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+	mock := &armedMock{Mock: clock.NewMock(), armed: make(chan time.Duration, 1)}
+	mock.Set(start)
+	c := ToKairos(mock)
+	t.Cleanup(func() { c.Close() })
+	timer := c.NewTimer(time.Minute)
+	// Wait for the timer routine to go to sleep on the mock clock.
+	select {
+	case d := <-mock.armed:
+		if d != time.Minute {
+			t.Errorf("got the timer routine sleeping for %v, want %v", d, time.Minute)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("timer routine did not sleep on the mock clock")
+	}
+	mock.Add(59 * time.Second)
+	select {
+	case <-timer.C:
+		t.Fatalf("timer fired early")
+	case <-time.After(10 * time.Millisecond):
+	}
+	mock.Add(time.Second)
+	select {
+	case got := <-timer.C:
+		if want := start.Add(time.Minute); !got.Equal(want) {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("timer did not fire")
+	}
+}
@@ -0,0 +1,10 @@
+module github.com/rhansen/go-kairos/kairos/benbjohnsonadapter
+
+go 1.22
+
+require (
+	github.com/benbjohnson/clock v1.3.5
+	github.com/rhansen/go-kairos v0.0.0-00010101000000-000000000000
+)
+
+replace github.com/rhansen/go-kairos => ../..
@@ -0,0 +1,76 @@
+package kairos
+
+import (
+	"context"
+	"fmt"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// clockBoottime is CLOCK_BOOTTIME, which the syscall package does not define.
+const clockBoottime = 7
+
+// A BoottimeClock is a [Clock] whose time advances with Linux's CLOCK_BOOTTIME, which keeps
+// counting while the system is suspended, unlike the monotonic clock that the standard library and
+// [NewClock] measure durations with.  Time spent suspended therefore counts toward the expiry of
+// its timers: a timer started for an hour on a laptop that sleeps for two hours fires when it
+// resumes, rather than an hour after that.  This suits TTLs, leases and other timeouts that are
+// about elapsed real time.
+//
+// The clock's time starts at the wall time at which it was created and then follows
+// CLOCK_BOOTTIME, so it drifts from the system's wall clock when the latter is set.
+type BoottimeClock struct {
+	*clock
+	sleeper *TimerfdSleeper
+}
+
+// NewBoottimeClock returns a [BoottimeClock], whose timer routine sleeps on a CLOCK_BOOTTIME
+// timerfd.  [WithSleeper], [WithShards] and [WithRuntimeTimers] have no effect on it.  It returns
+// an error if the kernel does not support CLOCK_BOOTTIME timerfds, which require Linux 3.15.
+func NewBoottimeClock(opts ...ClockOption) (*BoottimeClock, error) {
+	if _, err := boottime(); err != nil {
+		return nil, err
+	}
+	s, err := newTimerfdSleeper(clockBoottime)
+	if err != nil {
+		return nil, err
+	}
+	// Round(0) strips the monotonic clock reading, so that the times are compared by their wall
+	// readings, which follow CLOCK_BOOTTIME.
+	d, _ := boottime()
+	base := time.Now().Round(0).Add(-d)
+	now := func() time.Time {
+		d, _ := boottime()
+		return base.Add(d)
+	}
+	cfg := newClockConfig(opts)
+	cfg.sleeper = s
+	cfg.runtime = false
+	return &BoottimeClock{clock: newClockWith(now, cfg), sleeper: s}, nil
+}
+
+// Close is equivalent to Shutdown with a context that is never done.
+func (bc *BoottimeClock) Close() error {
+	return bc.Shutdown(context.Background())
+}
+
+// Shutdown closes the clock like [Clock.Shutdown], then releases its timerfd once its timer
+// routine has exited.
+func (bc *BoottimeClock) Shutdown(ctx context.Context) error {
+	if err := bc.clock.Shutdown(ctx); err != nil {
+		return err
+	}
+	return bc.sleeper.Close()
+}
+
+// boottime returns the time elapsed on CLOCK_BOOTTIME, since the system booted.
+func boottime() (time.Duration, error) {
+	var ts syscall.Timespec
+	_, _, errno := syscall.Syscall(syscall.SYS_CLOCK_GETTIME, clockBoottime,
+		uintptr(unsafe.Pointer(&ts)), 0)
+	if errno != 0 {
+		return 0, fmt.Errorf("kairos: clock_gettime(CLOCK_BOOTTIME): %w", errno)
+	}
+	return time.Duration(ts.Nano()), nil
+}
@@ -0,0 +1,29 @@
+package kairos
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBoottimeClock(t *testing.T) {
+	c, err := NewBoottimeClock()
+	if err != nil {
+		t.Skipf("CLOCK_BOOTTIME not supported: %v", err)
+	}
+	var _ Clock = c
+	if d := time.Since(c.Now()); d < -margin || d > margin {
+		t.Errorf("clock is %v behind the wall clock, want about 0", d)
+	}
+	start := c.Now()
+	timer := c.NewTimer(20 * time.Millisecond)
+	waitFired(t, timer)
+	if got := c.Since(start); got < 20*time.Millisecond || got >= 20*time.Millisecond+margin {
+		t.Errorf("timer fired after %v, want 20ms", got)
+	}
+	if err := c.Close(); err != nil {
+		t.Errorf("Close: got error %v, want nil", err)
+	}
+	if err := c.Close(); err != ErrClosed {
+		t.Errorf("second Close: got error %v, want %v", err, ErrClosed)
+	}
+}
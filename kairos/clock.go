@@ -1,50 +1,745 @@
 package kairos
 
 import (
+	"context"
+	"errors"
+	"log/slog"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
-var realClock = newClock()
+// A Clock is a source of time and a factory for timers.  The real clock used by the package-level
+// functions and [FakeClock] both implement Clock, so code that accepts a Clock can be tested
+// without waiting for real time to pass.  Its methods mirror the functions of package time, so
+// application code that depends on an injected Clock never needs package time for behavior.
+type Clock interface {
+	// Now returns the current time according to the clock.
+	Now() time.Time
+	// Since returns the time elapsed since t according to the clock.
+	Since(t time.Time) time.Duration
+	// Until returns the duration until t according to the clock.
+	Until(t time.Time) time.Duration
+	// Sleep pauses the current goroutine for at least the duration d according to the clock.
+	Sleep(d time.Duration)
+	// After waits for the duration to elapse and then sends the current time on the returned
+	// channel.
+	After(d time.Duration) <-chan time.Time
+	// AfterFunc waits for the duration to elapse and then calls f in its own goroutine.  It returns
+	// a [Timer] that can be used to cancel the call using its Stop method.
+	AfterFunc(d time.Duration, f func()) *Timer
+	// AfterFuncContext is like AfterFunc, but passes f a context that is cancelled when the call
+	// is stopped.  See the package-level [AfterFuncContext].
+	AfterFuncContext(d time.Duration, f func(ctx context.Context)) *FuncTimer
+	// NewTimer creates a new [Timer] and starts it with duration d.
+	NewTimer(d time.Duration) *Timer
+	// NewTimerCtx creates a new [Timer] like NewTimer, but stops it once ctx is done.  See the
+	// package-level [NewTimerCtx].
+	NewTimerCtx(ctx context.Context, d time.Duration) *Timer
+	// ContextWithTimeout is like [context.WithTimeout], but the deadline is d after the clock's
+	// current time, and the clock enforces it, so a FakeClock controls when the context times out.
+	// The context's Deadline method returns the clock's deadline.  When the deadline is reached,
+	// Err returns [context.DeadlineExceeded] and [context.Cause] returns [ErrTimerFired].
+	ContextWithTimeout(parent context.Context, d time.Duration) (context.Context, context.CancelFunc)
+	// NewTimerAt creates a new [Timer] that fires when the clock reaches t, or right away if t is
+	// not after the current time.
+	NewTimerAt(t time.Time) *Timer
+	// NewStoppedTimer creates a new stopped [Timer].  Call [Timer.Reset] to start it.
+	NewStoppedTimer() *Timer
+	// InitTimer initializes the zero Timer t in place as a stopped timer of the clock that calls f
+	// in its own goroutine when it fires.  See the package-level [InitTimer].  It panics if t was
+	// already initialized or f is nil.
+	InitTimer(t *Timer, f func())
+	// AcquireTimer is like NewTimer, but reuses a timer released with [ReleaseTimer] if possible.
+	AcquireTimer(d time.Duration) *Timer
+	// NewTicker returns a new [Ticker] that ticks every d.  It panics if d is not positive.
+	NewTicker(d time.Duration, opts ...TickerOption) *Ticker
+	// NewFuncTicker returns a new [Ticker] whose intervals are computed by next.  See the
+	// package-level [NewFuncTicker].
+	NewFuncTicker(next func(prev time.Time) time.Duration, opts ...TickerOption) *Ticker
+	// TickFunc returns a new [Ticker] that calls f on each tick.  See the package-level
+	// [TickFunc].
+	TickFunc(d time.Duration, f func(t time.Time), opts ...TickerOption) *Ticker
+	// Close is equivalent to Shutdown with a context that is never done.
+	Close() error
+	// Shutdown stops the clock's background goroutine and disposes of pending timers according to
+	// the clock's [ClosePolicy], then waits until the goroutine and any running AfterFunc callbacks
+	// have exited or ctx is done.  The missed ticks that tickers with [DeliverMissedTicks] still
+	// hold are dropped.  Timers belonging to a closed clock can no longer be started: Reset leaves
+	// them stopped and returns false.  Shutdown returns [ErrClosed] if the clock was already
+	// closed.
+	Shutdown(ctx context.Context) error
+}
+
+// Diagnostics gives access to the state and the statistics of a clock's timers, and control of its
+// timer routine.  The clocks of this package implement it in addition to [Clock], which is kept to
+// the operations on time and timers so that other implementations and test doubles are easy to
+// write.  Code that accepts a Clock reaches the diagnostics with a type assertion:
+//
+//	if d, ok := c.(kairos.Diagnostics); ok {
+//		log.Printf("%d timers armed", d.Pending())
+//	}
+type Diagnostics interface {
+	// Pending returns the number of timers that are armed (started but not yet fired or stopped).
+	Pending() int
+	// MemStats returns statistics about the memory held by the armed timers.  See [MemStats].
+	MemStats() MemStats
+	// Stats returns the numbers of operations on the clock's timers.  See [Stats].
+	Stats() Stats
+	// PopExpired removes the timers whose deadlines are at or before now, up to max of them if max
+	// is positive, and returns them in deadline order instead of sending on their channels, so
+	// that an event loop can process expirations in batches.  See [WithManualExpiry].
+	PopExpired(now time.Time, max int) []*Timer
+	// StartRunner restarts the timer routine stopped by StopRunner.  See [RunnerHealth].
+	StartRunner() error
+	// StopRunner stops the clock's timer routine, without closing the clock, and waits until it
+	// has exited or ctx is done.  See [RunnerHealth].
+	StopRunner(ctx context.Context) error
+	// Health reports the state of the clock's timer routine.  See [RunnerHealth].
+	Health() RunnerHealth
+	// Healthy returns nil if the clock works as expected, for readiness probes, and otherwise an
+	// error joining the problems found: [ErrRunnerStopped] if the timer routine is stopped or the
+	// clock closed, [ErrRunnerStalled] if the routine is stuck, [ErrTimersOverdue] if the earliest
+	// timer is overdue by more than the limit of [WithMaxHealthyLag], and [ErrCallbackPanics] if
+	// callbacks panicked since the previous call.
+	Healthy() error
+	// DumpTimers returns a description of the armed timers, in deadline order, for debugging.  See
+	// [TimerInfo] and [WriteTimers].
+	DumpTimers() []TimerInfo
+	// LabelStats returns the numbers and delays of the expirations of the clock's timers by label.
+	// See [WithLabelStats].
+	LabelStats() map[string]LatencyHistogram
+	// AuditLog returns the last timer operations of the clock, oldest first.  See [WithAuditLog].
+	AuditLog() []AuditEntry
+	// AddListener makes the clock report every subsequent timer operation to l.  See
+	// [ClockListener].
+	AddListener(l ClockListener)
+	// FireLatency returns the histogram of the delays with which the clock fired its timers.  See
+	// [WithFireLatency].
+	FireLatency() LatencyHistogram
+}
+
+var (
+	_ Diagnostics = (*clock)(nil)
+	_ Diagnostics = (*shardedClock)(nil)
+)
+
+// ErrClosed is returned when closing a [Clock] that is already closed.
+var ErrClosed = errors.New("kairos: clock closed")
+
+var realClock = newClockWith(time.Now, clockConfig{idle: time.Second})
 
 type clock struct {
+	now    func() time.Time
+	kick   func() // Called without mutex held when the earliest deadline might have moved earlier.
+	armed  func() // If non-nil, called without mutex held after a timer is added to the heap.
+	policy ClosePolicy
+	quitC  chan struct{} // Closed to stop the timer routine.
+	doneC  chan struct{} // Closed when the timer routine has exited; protected by mutex.
+	stopC  chan struct{} // Closed by StopRunner to stop the timer routine; protected by mutex.
+	funcs  funcGroup     // Running AfterFunc callbacks and ticker forwarders.
+	rec    *Recorder     // If non-nil, records timer operations.
+	serial bool          // Run TickFunc callbacks synchronously; see WithDeterministicDispatch.
+	// Tickers whose backlog a goroutine delivers, for Shutdown to stop them; protected by mutex.
+	forwarders map[*Ticker]struct{}
+	// Postpone timers in place instead of moving them in the heap; see startTimer.
+	postpone bool
+	slack    time.Duration // How late timers may fire; see WithSlack.
+	res      time.Duration // Granularity of the timer routine's wakeups; see WithResolution.
+	// Number of timers fired per wakeup, or 0 for no limit, and the pause between batches; see
+	// WithMaxBatch.
+	maxBatch   int
+	batchPause time.Duration
+	// Creates the queues of the priority levels, if a timer gets a priority; see prioritizeLocked.
+	newQueue func() timerQueue
+
+	mutex   sync.Mutex // protects:
+	seq     uint64     // Sequence number of the most recently started timer.
+	ties    TieOrder   // Order of timers with equal deadlines; see nextSeqLocked.
+	timers  timerQueue
+	rtimers map[*Timer]struct{} // Armed timers, if delegated to runtime timers instead of the heap.
+	rcounts timerCounts         // Counts of the timers in rtimers.
+	closed  bool
+	calls   []func()    // TickFunc callbacks waiting to be run synchronously, if serial.
+	limit   *timerLimit // If non-nil, limits the number of armed timers; see WithTimerLimit.
+	// If non-nil, limits the number of running callbacks; see WithMaxConcurrentCallbacks.
+	callbacks *callbackLimit
+	// Whether callbacks run with pprof labels; see WithProfilerLabels.
+	pprofLabels bool
+	// If non-nil, reports the callbacks that run for too long; see WithCallbackBudget.
+	budget *callbackBudget
+
+	pool sync.Pool // Timers released by ReleaseTimer.
+	// If non-nil, the timers and tickers dropped while armed are reaped; see WithLeakDetection.
+	leaks *leakDetection
+	obs   Observer   // If non-nil, notified of the timer operations; see WithObserver.
+	ops   opCounters // Counts of the timer operations; see Diagnostics.Stats.
+	// If non-nil, the delays of the expirations; see WithFireLatency.  Protected by mutex.
+	latency *LatencyHistogram
+	// If non-nil, the delays of the expirations by timer label; see WithLabelStats.  Protected by
+	// mutex.
+	labelStats map[string]*LatencyHistogram
+
+	lateFire *lateFire    // If non-nil, called for the timers that fire late; see WithOnLateFire.
+	sites    bool         // Whether the creation sites of timers are recorded; see WithCreationSites.
+	log      *slog.Logger // If non-nil, logs the timer operations; see WithLogger.
+	stale    *staleTimers // If non-nil, reports the stale timers; see WithStaleTimers.
+	// If non-nil, reports the suspicious interleavings; see WithRaceDiagnostics.
+	races func(msg string)
+	// If non-nil, samples the durations of the timers; see WithDurationSampling.  Protected by
+	// mutex.
+	samples *durationSamples
+	// If non-nil, the last timer operations; see WithAuditLog.  Protected by mutex.
+	audit *auditLog
+	// The state of the checks of Healthy.
+	health *healthCheck
+	// If non-nil, watches the timer routine; see WithWatchdog.
+	watchdog *watchdog
+	// The listeners added by AddListener, replaced as a whole when one is added.
+	listeners atomic.Pointer[[]ClockListener]
+
+	// The timer routine, if the clock has one, runs only while timers are armed; see
+	// startRoutineLocked.
+	sleeper     Sleeper
 	rescheduleC chan struct{}
-	mutex       sync.Mutex // protects:
-	timers      *timerHeap
+	idle        time.Duration // How long the routine waits without armed timers before it exits.
+	highRes     bool          // Whether to raise the system timer resolution; see WithHighResolution.
+	running     bool          // protected by mutex
+	held        bool          // protected by mutex; true if the routine was stopped by StopRunner.
+	// If non-nil, the routine detects changes of the wall time; see WithClockChangeDetection.
+	detect *changeDetection
+	stepC  chan struct{} // Receives when the wall time stepped; nil unless detect is set.
+}
+
+// A ClosePolicy determines what happens to a clock's pending timers when it is closed.
+type ClosePolicy int
+
+const (
+	// CancelOnClose stops pending timers without firing them.
+	CancelOnClose ClosePolicy = iota
+	// FireOnClose fires pending timers immediately, in deadline order, as if they had expired.
+	FireOnClose
+)
+
+// A TieOrder determines the order in which a clock fires timers with equal deadlines.
+type TieOrder int
+
+const (
+	// FIFOTies fires timers with equal deadlines in the order they were started, so that a state
+	// machine that arms a timeout, then a fallback for the same instant, sees the timeout first.
+	// Resetting a timer starts it again, so it then fires after the timers started before it.
+	FIFOTies TieOrder = iota
+	// LIFOTies fires timers with equal deadlines in the reverse of the order they were started.
+	LIFOTies
+	// UnorderedTies leaves the order of timers with equal deadlines unspecified, for code that does
+	// not depend on it: the clock fires them in whatever order is cheapest for its backend.
+	UnorderedTies
+)
+
+// WithTieOrder sets the order in which the clock fires timers with equal deadlines.  The default
+// is [FIFOTies].  Within a batch, values are sent on the channels of the timers in that order and
+// the AfterFunc callbacks are started in that order, but they run concurrently unless the clock
+// was created with [WithMaxConcurrentCallbacks](1) or [WithDeterministicDispatch].  The order is
+// not guaranteed with [WheelBackend], which fires the timers of a tick in no particular order,
+// with [WithRuntimeTimers], whose timers fire on their own, or between the shards of
+// [WithShards].
+func WithTieOrder(o TieOrder) ClockOption {
+	return func(cfg *clockConfig) { cfg.ties = o }
+}
+
+// A ClockOption configures a clock.
+type ClockOption func(*clockConfig)
+
+type clockConfig struct {
+	sleeper  Sleeper
+	policy   ClosePolicy
+	autoIdle time.Duration
+	runtime  bool
+	serial   bool
+	rec      *Recorder
+	settle   time.Duration
+	shards   int
+	sharded  bool
+	slack    time.Duration
+	res      time.Duration
+	manual   bool
+	idle     time.Duration
+	highRes  bool
+	detect   *changeDetection
+	ties     TieOrder
+	newQueue func() timerQueue
+
+	limit       int
+	limitPolicy LimitPolicy
+
+	maxBatch     int
+	batchPause   time.Duration
+	maxCallbacks int
+	pprofLabels  bool
+	budget       *callbackBudget
+
+	strict       time.Duration
+	strictReport func(msg string)
+
+	leaks      *leakDetection
+	obs        Observer
+	expvar     string
+	latency    bool
+	lateFire   *lateFire
+	sites      bool
+	log        *slog.Logger
+	stale      *staleTimers
+	races      func(msg string)
+	labelStats bool
+	watchdog   *watchdog
+	sampleSize int
+	auditSize  int
+	maxLag     time.Duration
+}
+
+func newClockConfig(opts []ClockOption) clockConfig {
+	var cfg clockConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
 }
 
-func newClock() *clock {
-	clk := &clock{rescheduleC: make(chan struct{}, 1), timers: &timerHeap{}}
-	go clk.timerRoutine()
+// WithSleeper makes the clock's timer routine sleep using s instead of real time.  s must not be
+// shared with another clock.
+func WithSleeper(s Sleeper) ClockOption {
+	return func(cfg *clockConfig) { cfg.sleeper = s }
+}
+
+// WithClosePolicy sets what happens to the clock's pending timers when it is closed.  The default
+// is [CancelOnClose].
+func WithClosePolicy(p ClosePolicy) ClockOption {
+	return func(cfg *clockConfig) { cfg.policy = p }
+}
+
+// WithSlack lets the timers of a clock created by [NewClock] or [NewClockFromFunc] fire up to d
+// after their deadlines, like the timer slack of Linux, so that timers expiring within d of each
+// other are fired by a single wakeup of the timer routine.  This trades bounded lateness for fewer
+// wakeups, which saves CPU time and battery when many timers expire at nearby times.  The timer
+// routine sleeps until d after the earliest deadline, then fires every timer that has expired.  A
+// timer never fires before its deadline.  The option has no effect with [WithRuntimeTimers].
+func WithSlack(d time.Duration) ClockOption {
+	return func(cfg *clockConfig) { cfg.slack = max(d, 0) }
+}
+
+// WithResolution makes the timer routine of a clock created by [NewClock] or [NewClockFromFunc]
+// wake up only at multiples of r, such as every 10 milliseconds or every second, and fire the
+// timers that expired since the previous wakeup together.  Timers fire up to r late, but a clock
+// with thousands of timers, such as connection idle timeouts, wakes up at most once per r however
+// their deadlines are spread.  Combined with [WithSlack], the timer routine wakes up at the first
+// multiple of r at least the slack after the earliest deadline.  A non-positive r means the
+// default: full resolution, except under js/wasm and wasip1, whose hosts deliver timer events with
+// millisecond granularity at best, where a clock sleeping in real time wakes up at multiples of a
+// millisecond.  The option has no effect with [WithRuntimeTimers].
+func WithResolution(r time.Duration) ClockOption {
+	return func(cfg *clockConfig) { cfg.res = max(r, 0) }
+}
+
+// WithIdleShutdown makes the timer routine of a clock created by [NewClock] or [NewClockFromFunc]
+// exit once no timer has been armed for d.  The routine is started again when a timer is started.
+// The timer routine of a clock is only started when its first timer is started, so a clock that
+// is never used runs no goroutine; but by default, the routine runs until the clock is closed
+// once started.  The clock used by the package-level functions exits its routine after one second
+// without armed timers.  A non-positive d means the default.
+func WithIdleShutdown(d time.Duration) ClockOption {
+	return func(cfg *clockConfig) { cfg.idle = max(d, 0) }
+}
+
+// WithHighResolution makes the timer routine of a clock created by [NewClock] or
+// [NewClockFromFunc] request the finest system timer resolution while it runs, for clocks whose
+// timers must fire within a millisecond of their deadlines.  On Windows, whose timers tick every
+// 15.6 milliseconds by default, the routine raises the resolution to one millisecond with
+// timeBeginPeriod, which affects the whole system and increases its power consumption, and
+// restores it when the routine exits; combine the option with [WithIdleShutdown] so that the
+// resolution is raised only while timers are armed.  Other platforms have high-resolution timers
+// already, and the option has no effect there, nor with [WithRuntimeTimers].
+func WithHighResolution() ClockOption {
+	return func(cfg *clockConfig) { cfg.highRes = true }
+}
+
+// WithManualExpiry makes a clock created by [NewClock] or [NewClockFromFunc] run no timer routine:
+// its timers expire only when [Diagnostics.PopExpired] is called, typically by an event loop that
+// calls it on each iteration and handles the returned timers, identified by pointer or by label, in
+// batches.  PopExpired returns the timers created with a channel, such as by NewTimer, without
+// sending on their channels; it delivers the expirations of AfterFunc timers and tickers found on
+// the way as usual, without returning them.  Sleep and After never return on such a clock, since
+// their timers are returned to the caller of PopExpired instead.
+func WithManualExpiry() ClockOption {
+	return func(cfg *clockConfig) { cfg.manual = true }
+}
+
+// NewClock returns a new real-time [Clock].  Each clock has its own timer heap and timer routine,
+// isolated from the clock used by the package-level functions and from every other clock, so
+// several clocks can coexist, for example one per simulated node in a cluster test.
+//
+// The timer routine wakes up at the earliest deadline, or later with [WithSlack], [WithResolution],
+// or [WheelBackend], reads the time, and fires every timer that has expired by then as one batch,
+// in deadline order, with timers of equal deadlines in the order they were started, or as set by
+// [WithTieOrder].  All the timers of a batch receive the same time.  The batch is dispatched as
+// follows: values are sent on the channels of timers and tickers, and the calls of [TickFunc]
+// tickers are started, while the clock's lock is held; then the AfterFunc callbacks of the batch
+// are started, each in its own goroutine, in the same order.  Timers that expire while a batch is
+// dispatched are fired by the next batch.  With [WithRuntimeTimers], each timer fires on its own
+// instead.
+func NewClock(opts ...ClockOption) Clock {
+	return NewClockFromFunc(time.Now, opts...)
+}
+
+// NewClockFromFunc returns a [Clock] that reads the current time from now, for time sources such as
+// replayed traces or external time services.  Timers are managed exactly as for the real clock.  By
+// default the clock's timer routine sleeps in real time and re-reads now when it wakes; use
+// [WithSleeper] if now does not advance at the rate of real time.
+func NewClockFromFunc(now func() time.Time, opts ...ClockOption) Clock {
+	cfg := newClockConfig(opts)
+	var c statsSource
+	if cfg.sharded && cfg.sleeper == nil && !cfg.runtime {
+		c = newShardedClock(now, cfg)
+	} else {
+		c = newClockWith(now, cfg)
+	}
+	if cfg.expvar != "" {
+		publishExpvar(cfg.expvar, c)
+	}
+	return c
+}
+
+// newClockWith returns a clock that reads the time from now and whose timer routine sleeps using
+// cfg.sleeper, or in real time if nil.
+func newClockWith(now func() time.Time, cfg clockConfig) *clock {
+	if cfg.runtime {
+		return newRuntimeClock(now, cfg)
+	}
+	if cfg.newQueue == nil {
+		cfg.newQueue = func() timerQueue { return &timerHeap{} }
+	}
+	timers := cfg.newQueue()
+	// Postponing timers in place only pays off with a heap.  A timer postponed to the same deadline
+	// would keep its place among the timers of that deadline, which only FIFO and unordered ties
+	// allow.
+	_, postpone := timers.(*timerHeap)
+	postpone = postpone && cfg.ties != LIFOTies
+	if cfg.manual {
+		clk := &clock{
+			now:      now,
+			kick:     func() {},
+			policy:   cfg.policy,
+			rec:      cfg.rec,
+			timers:   timers,
+			postpone: postpone,
+			ties:     cfg.ties,
+			newQueue: cfg.newQueue,
+		}
+		clk.setLimit(cfg)
+		clk.setDispatch(cfg)
+		clk.obs = cfg.obs
+		clk.sites = cfg.sites
+		clk.log = cfg.log
+		clk.setStale(cfg, true)
+		clk.races = cfg.races
+		clk.setLatency(cfg)
+		clk.setSamples(cfg)
+		clk.setAudit(cfg)
+		clk.health = newHealthCheck(cfg)
+		return clk
+	}
+	if cfg.sleeper == nil {
+		cfg.sleeper = newRealSleeper()
+		if cfg.res == 0 {
+			cfg.res = defaultResolution
+		}
+	}
+	rescheduleC := make(chan struct{}, 1)
+	clk := &clock{
+		now: now,
+		kick: func() {
+			// Do not block if there is already a pending reschedule request.
+			select {
+			case rescheduleC <- struct{}{}:
+			default:
+			}
+		},
+		policy:   cfg.policy,
+		rec:      cfg.rec,
+		quitC:    make(chan struct{}),
+		timers:   timers,
+		ties:     cfg.ties,
+		postpone: postpone,
+		newQueue: cfg.newQueue,
+		slack:    cfg.slack,
+		res:      cfg.res,
+
+		sleeper:     cfg.sleeper,
+		rescheduleC: rescheduleC,
+		idle:        cfg.idle,
+		highRes:     cfg.highRes,
+		detect:      cfg.detect,
+	}
+	if cfg.detect != nil {
+		clk.stepC = make(chan struct{}, 1)
+	}
+	clk.setLimit(cfg)
+	clk.setDispatch(cfg)
+	clk.leaks = cfg.leaks
+	clk.obs = cfg.obs
+	clk.sites = cfg.sites
+	clk.log = cfg.log
+	clk.setStale(cfg, true)
+	clk.races = cfg.races
+	clk.setLatency(cfg)
+	clk.setSamples(cfg)
+	clk.setAudit(cfg)
+	clk.health = newHealthCheck(cfg)
+	clk.setWatchdog(cfg)
 	return clk
 }
 
+// startRoutineLocked starts the timer routine if the clock has one and it is not running.  The
+// mutex must be held.
+func (clk *clock) startRoutineLocked() {
+	if clk.running || clk.sleeper == nil || clk.held {
+		return
+	}
+	clk.running = true
+	prevDoneC := clk.doneC
+	clk.stopC = make(chan struct{})
+	clk.doneC = make(chan struct{})
+	go clk.timerRoutine(prevDoneC, clk.stopC, clk.doneC)
+}
+
+// A Sleeper wakes a clock's timer routine after a delay.  The timer routine calls Reset with the
+// time remaining until the earliest deadline, measured by the clock's own time source, and waits
+// for a value on C.  Spurious or late wakeups are harmless: the timer routine re-reads the time
+// when it wakes and goes back to sleep if no timer has expired.
+type Sleeper interface {
+	// C returns the channel on which wakeups are delivered.
+	C() <-chan time.Time
+	// Reset discards any pending wakeup and arranges for a wakeup after d.
+	Reset(d time.Duration)
+	// Stop cancels any pending wakeup.
+	Stop()
+}
+
+// NewClockSleeper returns a [Sleeper] that sleeps using timers created by c.
+func NewClockSleeper(c Clock) Sleeper {
+	return timerSleeper{c.NewStoppedTimer()}
+}
+
+// realSleeper is a Sleeper backed by a standard library timer.
+type realSleeper struct{ t *time.Timer }
+
+func newRealSleeper() realSleeper {
+	t := time.NewTimer(0)
+	<-t.C
+	return realSleeper{t: t}
+}
+
+func (s realSleeper) C() <-chan time.Time { return s.t.C }
+
+func (s realSleeper) Reset(d time.Duration) {
+	s.Stop()
+	s.t.Reset(d)
+}
+
+func (s realSleeper) Stop() {
+	// If not yet received a value from t.C, the timer must be stopped and—if Stop reports that the
+	// timer expired before being stopped—the channel explicitly drained.  The value might have
+	// already been received, so do not block.
+	if !s.t.Stop() {
+		select {
+		case <-s.t.C:
+		default:
+		}
+	}
+}
+
+// timerSleeper is a Sleeper backed by a [Timer] from another clock.
+type timerSleeper struct{ t *Timer }
+
+func (s timerSleeper) C() <-chan time.Time   { return s.t.C }
+func (s timerSleeper) Reset(d time.Duration) { s.t.Reset(d) }
+func (s timerSleeper) Stop()                 { s.t.Stop() }
+
+// Now returns the current time according to the clock.
+func (clk *clock) Now() time.Time {
+	return clk.now()
+}
+
+// Pending returns the number of timers that are armed (started but not yet fired or stopped).
+func (clk *clock) Pending() int {
+	clk.mutex.Lock()
+	defer clk.mutex.Unlock()
+	if clk.rtimers != nil {
+		return len(clk.rtimers)
+	}
+	return clk.timers.Len()
+}
+
+// Since returns the time elapsed since t according to the clock.
+func (clk *clock) Since(t time.Time) time.Duration {
+	return clk.now().Sub(t)
+}
+
+// Until returns the duration until t according to the clock.
+func (clk *clock) Until(t time.Time) time.Duration {
+	return t.Sub(clk.now())
+}
+
+// Sleep pauses the current goroutine for at least the duration d according to the clock.  A
+// negative or zero duration causes Sleep to return immediately.
+func (clk *clock) Sleep(d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	<-clk.newTimer(d).C
+}
+
+// After waits for the duration to elapse and then sends the current time on the returned channel.
+func (clk *clock) After(d time.Duration) <-chan time.Time {
+	return clk.newTimer(d).C
+}
+
+// AfterFunc waits for the duration to elapse and then calls f in its own goroutine.  It returns a
+// [Timer] that can be used to cancel the call using its Stop method.
+func (clk *clock) AfterFunc(d time.Duration, f func()) *Timer {
+	t := &Timer{clk: clk, f: f}
+	clk.created(t)
+	clk.resetTimer(t, d)
+	return t
+}
+
 // NewTimer creates a new [Timer] and starts it with duration d.
 func (clk *clock) NewTimer(d time.Duration) *Timer {
-	t := clk.NewStoppedTimer()
+	return clk.trackTimer(clk.newTimer(d))
+}
+
+// newTimer creates a timer and starts it with duration d.  Unlike NewTimer, it never returns a
+// handle tracked by WithLeakDetection, for the timers that are dropped by design, such as After's.
+func (clk *clock) newTimer(d time.Duration) *Timer {
+	t := clk.newStoppedTimer()
 	clk.resetTimer(t, d)
 	return t
 }
 
+// NewTimerAt creates a new [Timer] that fires when the clock reaches t.  It is like
+// NewTimer(clk.Until(t)), except that the deadline is exactly t, however long the call takes.
+func (clk *clock) NewTimerAt(t time.Time) *Timer {
+	timer := clk.newStoppedTimer()
+	clk.startTimerAt(timer, t)
+	return clk.trackTimer(timer)
+}
+
+// InitTimer initializes the zero Timer t as a stopped timer that calls f when it fires.
+func (clk *clock) InitTimer(t *Timer, f func()) {
+	if t.clk != nil || t.node != nil {
+		panic("kairos: InitTimer called on an initialized Timer")
+	}
+	if f == nil {
+		panic("kairos: InitTimer called with a nil function")
+	}
+	t.clk, t.f = clk, f
+	clk.created(t)
+}
+
+// AcquireTimer is like NewTimer, but reuses a timer released with [ReleaseTimer] if possible.
+func (clk *clock) AcquireTimer(d time.Duration) *Timer {
+	t, _ := clk.pool.Get().(*Timer)
+	if t == nil {
+		t = clk.NewStoppedTimer()
+	}
+	clk.resetTimer(t.impl(), d)
+	return t
+}
+
 // NewStoppedTimer creates a new stopped [Timer].  Call [Timer.Reset] to start it.
 func (clk *clock) NewStoppedTimer() *Timer {
+	return clk.trackTimer(clk.newStoppedTimer())
+}
+
+// newStoppedTimer is like NewStoppedTimer, but never returns a tracked handle.
+func (clk *clock) newStoppedTimer() *Timer {
 	c := make(chan time.Time, 1)
-	return &Timer{C: c, c: c}
+	t := &Timer{C: c, c: c, clk: clk}
+	clk.created(t)
+	return t
 }
 
 // Delete timer t from the heap.
 // It returns true if t was removed, false if t wasn't even there.
 // Do not need to update the timer routine: if it wakes up early, no big deal.
 func (clk *clock) delTimer(t *Timer) bool {
+	if clk.rtimers != nil {
+		return clk.delRuntimeTimer(t)
+	}
+	var now time.Time
+	if clk.stopTimed() {
+		now = clk.now()
+	}
 	clk.mutex.Lock()
 	defer clk.mutex.Unlock()
-	return clk.timers.Remove(t)
+	if clk.races != nil {
+		clk.checkRaceLocked(OpStop, t, now, clk.timers.Has(t))
+	}
+	b := clk.timers.Remove(t)
+	if b {
+		clk.disarmedLocked()
+	}
+	clk.record(OpStop, t, now, 0, b)
+	return b
+}
+
+// startTimerAt starts the timer with deadline when.
+func (clk *clock) startTimerAt(t *Timer, when time.Time) (bool, error) {
+	if clk.detect != nil {
+		when = when.Round(0) // Follow the wall clock; see WithClockChangeDetection.
+	}
+	return clk.startTimer(t, when.Sub(clk.now()), when)
 }
 
 // Reset the timer to the new timeout duration.
 // This clears the channel.
-func (clk *clock) resetTimer(t *Timer, d time.Duration) (b bool) {
+func (clk *clock) resetTimer(t *Timer, d time.Duration) (bool, error) {
+	return clk.startTimer(t, d, time.Time{})
+}
+
+// startTimer is like resetTimer, but if when is not zero, the timer is armed with deadline when
+// instead, and a ticker keeps its schedule.  It returns an error if the timer could not be started
+// because the clock is closed or its limit of armed timers is reached.
+func (clk *clock) startTimer(t *Timer, d time.Duration, when time.Time) (b bool, err error) {
+	if clk.rtimers != nil {
+		return clk.startRuntimeTimer(t, d, when)
+	}
+	now := clk.now()
+	restart := when.IsZero()
+	if restart {
+		when = t.deadline(now, d)
+	}
 	clk.mutex.Lock()
+	// Idle timeouts are typically postponed on every event, so postponing an armed timer, other than
+	// a ticker, only updates its deadline.  The timer stays in the heap at its earlier deadline, and
+	// the timer routine moves it when that deadline is reached.
+	if clk.postpone && t.tk == nil && clk.timers.Has(t) && !when.Before(t.when) {
+		if clk.races != nil {
+			clk.checkRaceLocked(OpReset, t, now, true)
+		}
+		select {
+		case <-t.C:
+		default:
+		}
+		t.when = when
+		t.seq = clk.nextSeqLocked()
+		clk.record(OpReset, t, now, d, true)
+		clk.mutex.Unlock()
+		return true, nil
+	}
+	if clk.races != nil {
+		clk.checkRaceLocked(OpReset, t, now, clk.timers.Has(t))
+	}
 	b = clk.timers.Remove(t)
 	// The channel must be drained while the mutex is locked, otherwise a notification generated by a
 	// concurrent t.Reset(0) call might be erroneously consumed.
@@ -52,72 +747,352 @@ func (clk *clock) resetTimer(t *Timer, d time.Duration) (b bool) {
 	case <-t.C:
 	default:
 	}
-	t.when = time.Now().Add(d)
+	t.drainFiringsLocked()
+	if t.tk != nil {
+		t.tk.drainLocked()
+	}
+	if clk.closed {
+		clk.mutex.Unlock()
+		return b, ErrClosed
+	}
+	if !b {
+		if retry, err := clk.reserveLocked(); err != nil || retry {
+			clk.mutex.Unlock()
+			if err != nil {
+				return false, err
+			}
+			if restart {
+				when = time.Time{}
+			}
+			return clk.startTimer(t, d, when)
+		}
+	}
+	t.when = when
+	if t.tk != nil && restart {
+		t.tk.anchor, t.tk.n, t.tk.tickSeq = now, 1, 1
+	}
+	t.seq = clk.nextSeqLocked()
 	clk.timers.Insert(t)
+	clk.startRoutineLocked()
+	clk.record(OpReset, t, now, d, b)
 	// Reschedule if this is the next timer in the heap.
-	if clk.timers.Peek() == t {
-		// Do not block if there is already a pending reschedule request.
+	next := clk.timers.Peek() == t
+	clk.mutex.Unlock()
+	if clk.armed != nil {
+		clk.armed()
+	}
+	if next {
+		clk.kick()
+	}
+	return
+}
+
+// Close is equivalent to Shutdown with a context that is never done.
+func (clk *clock) Close() error {
+	return clk.Shutdown(context.Background())
+}
+
+// Shutdown stops the clock's background goroutine and disposes of pending timers according to the
+// clock's [ClosePolicy], then waits until the goroutine has exited or ctx is done.  Shutdown
+// returns [ErrClosed] if the clock was already closed.
+func (clk *clock) Shutdown(ctx context.Context) error {
+	now := clk.now()
+	clk.mutex.Lock()
+	if clk.closed {
+		clk.mutex.Unlock()
+		return ErrClosed
+	}
+	clk.closed = true
+	clk.running = false // The routine exits, or has already.
+	if clk.limit != nil {
+		clk.limit.freed.Broadcast()
+	}
+	var pending []*Timer
+	if clk.rtimers != nil {
+		pending = clk.removeRuntimeTimersLocked()
+	}
+	for t := clk.timers.Peek(); t != nil; t = clk.timers.Peek() {
+		clk.timers.Remove(t)
+		pending = append(pending, t)
+	}
+	if _, heap := clk.timers.(*timerHeap); !heap || clk.postpone {
+		// Postponed timers came out of the heap at their earlier deadlines, and other backends do
+		// not order the timers within a tick.
+		sort.SliceStable(pending, func(i, j int) bool {
+			a, b := pending[i], pending[j]
+			return a.when.Before(b.when) || (a.when.Equal(b.when) && a.seq < b.seq)
+		})
+	}
+	if clk.policy == FireOnClose {
+		for _, t := range pending {
+			clk.fireLocked(t, now)
+		}
+	}
+	// Ticks that the receivers have not taken are dropped.
+	for tk := range clk.forwarders {
+		tk.discardLocked()
+	}
+	doneC := clk.doneC
+	clk.mutex.Unlock()
+	clk.runCalls()
+	if clk.quitC != nil {
+		close(clk.quitC)
+	}
+	if clk.stale != nil && clk.stale.quitC != nil {
+		close(clk.stale.quitC)
+	}
+	if doneC != nil {
 		select {
-		case clk.rescheduleC <- struct{}{}:
+		case <-doneC:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	funcsDone := make(chan struct{})
+	go func() {
+		clk.funcs.Wait()
+		close(funcsDone)
+	}()
+	select {
+	case <-funcsDone:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// fireLocked delivers the expiration of timer t, which must already have been removed from the
+// heap, and restarts it if it backs a Ticker.  The mutex must be held.
+func (clk *clock) fireLocked(t *Timer, now time.Time) {
+	clk.record(OpFire, t, now, 0, true)
+	if t.tk != nil {
+		if !t.tk.tickLocked(now, t.when) {
+			clk.rearmLocked(t, now)
+		}
+		return
+	}
+	if t.f != nil {
+		clk.goLocked(clk.callbackLocked(t, t.f, t.f))
+		return
+	}
+	if t.fc != nil {
+		select {
+		case t.fc <- Firing{Scheduled: t.when, Fired: now}:
 		default:
 		}
+		return
+	}
+	select {
+	case t.c <- now:
+	default:
+	}
+}
+
+// runCalls runs the TickFunc callbacks queued in serial mode, in order.
+func (clk *clock) runCalls() {
+	clk.mutex.Lock()
+	defer clk.mutex.Unlock()
+	clk.runCallsLocked()
+}
+
+// runCallsLocked is like runCalls.  The mutex must be held; it is temporarily released to run the
+// callbacks.
+func (clk *clock) runCallsLocked() {
+	for len(clk.calls) > 0 {
+		f := clk.calls[0]
+		clk.calls = clk.calls[1:]
+		clk.mutex.Unlock()
+		f()
+		clk.mutex.Lock()
+	}
+}
+
+// PopExpired removes the timers whose deadlines are at or before now, up to max of them if max is
+// positive, and returns those created with a channel in deadline order without sending on their
+// channels.  The expirations of AfterFunc timers and tickers are delivered as usual; they do not
+// count towards max.
+func (clk *clock) PopExpired(now time.Time, max int) []*Timer {
+	var expired []*Timer
+	clk.mutex.Lock()
+	for max <= 0 || len(expired) < max {
+		var t *Timer
+		if clk.rtimers != nil {
+			t = clk.popRuntimeTimerLocked(now)
+		} else if t = clk.popExpiredLocked(now); t != nil {
+			clk.disarmedLocked()
+		}
+		if t == nil {
+			break
+		}
+		if t.f != nil || t.tk != nil {
+			clk.fireLocked(t, now)
+			continue
+		}
+		clk.record(OpFire, t, now, 0, true)
+		expired = append(expired, t)
 	}
 	clk.mutex.Unlock()
-	return
+	clk.runCalls()
+	return expired
 }
 
-func (clk *clock) timerRoutine() {
-	var now time.Time
+// nextSeqLocked returns the sequence number of a timer being started, by which it is ordered among
+// the timers with the same deadline: the start order, its reverse with LIFOTies, or the same
+// number for every timer with UnorderedTies.  The mutex must be held.
+func (clk *clock) nextSeqLocked() uint64 {
+	clk.seq++
+	switch clk.ties {
+	case LIFOTies:
+		return ^clk.seq
+	case UnorderedTies:
+		return 0
+	}
+	return clk.seq
+}
 
-	sleepTimer := time.NewTimer(0)
-	<-sleepTimer.C
-	sleepTimerActive := false
+// peekLocked returns the timer with the earliest deadline, or nil if none is armed, after moving
+// the timers that were postponed in place to their new places.  The mutex must be held.
+func (clk *clock) peekLocked() *Timer {
+	return peekQueue(clk.timers)
+}
 
-Loop:
+// peekQueue is like peekLocked for the timers of q, a queue of the clock or one of its priority
+// levels.
+func peekQueue(q timerQueue) *Timer {
 	for {
-		select {
-		case <-sleepTimer.C:
+		t := q.Peek()
+		if t == nil || t.when.Equal(t.key) && t.seq == t.keySeq {
+			return t
+		}
+		q.Remove(t)
+		q.Insert(t)
+	}
+}
 
-		case <-clk.rescheduleC:
-			// If not yet received a value from sleepTimer.C, the timer must be
-			// stopped and—if Stop reports that the timer expired before being
-			// stopped—the channel explicitly drained.
-			if !sleepTimer.Stop() && sleepTimerActive {
-				<-sleepTimer.C
-			}
+// wakeup returns the time at which the timer routine wakes up to fire a timer with deadline when.
+func (clk *clock) wakeup(when time.Time) time.Time {
+	when = when.Add(clk.slack)
+	if clk.res > 0 {
+		// Truncate drops the monotonic clock reading, so round when up by adding to it.
+		if rem := when.Sub(when.Truncate(clk.res)); rem > 0 {
+			when = when.Add(clk.res - rem)
 		}
-		sleepTimerActive = false
+	}
+	return when
+}
 
-	Reschedule:
-		now = time.Now()
+// timerRoutine fires the expired timers until the clock is closed, until StopRunner closes stopC,
+// or, with an idle period, until no timer has been armed for that long.  It closes doneC when it
+// exits.
+// It first waits for prevDoneC, if not nil, to be closed by the previous run of the routine, so
+// that two runs never share the sleeper.
+func (clk *clock) timerRoutine(prevDoneC <-chan struct{}, stopC, doneC chan struct{}) {
+	if prevDoneC != nil {
+		<-prevDoneC
+	}
+	var batch []func() // AfterFunc callbacks of the current wakeup, reused across wakeups.
+	idling := false    // Whether the routine sleeps for the idle period.
+	sleeper := clk.sleeper
+	defer close(doneC)
+	if clk.highRes {
+		defer beginHighResolution()()
+	}
+	if clk.detect != nil {
+		stopC, detectDoneC := make(chan struct{}), make(chan struct{})
+		go clk.detectChanges(stopC, detectDoneC)
+		defer func() {
+			close(stopC)
+			<-detectDoneC
+		}()
+	}
 
-		clk.mutex.Lock()
-		if clk.timers.Len() == 0 {
-			clk.mutex.Unlock()
-			continue Loop
-		}
+	for {
+		clk.watchdog.idle()
+		select {
+		case <-sleeper.C():
+			if idling {
+				clk.mutex.Lock()
+				if clk.timers.Len() == 0 {
+					clk.running = false
+					clk.mutex.Unlock()
+					return
+				}
+				clk.mutex.Unlock()
+			}
 
-		t := clk.timers.Peek()
-		delta := t.when.Sub(now)
+		case <-clk.rescheduleC:
+			sleeper.Stop()
 
-		// Sleep if not expired.
-		if delta > 0 {
+		case <-clk.stepC:
+			// The deadlines of timers armed for wall-clock times moved relative to the others.
+			sleeper.Stop()
+			clk.mutex.Lock()
+			clk.requeueLocked()
 			clk.mutex.Unlock()
-			sleepTimer.Reset(delta)
-			sleepTimerActive = true
-			continue Loop
-		}
 
-		// Timer expired.
-		select {
-		case t.c <- now:
-		default:
+		case <-clk.quitC:
+			sleeper.Stop()
+			return
+
+		case <-stopC:
+			sleeper.Stop()
+			return
 		}
-		clk.timers.Remove(t)
+		idling = false
+		clk.watchdog.busy()
+		clk.ops.wakeups.Add(1)
 
+		// Fire every timer that expired by now as one batch.
+		now := clk.now()
+		var next time.Time // Deadline of the earliest timer left, if any.
+		fired := 0
+		clk.mutex.Lock()
+		for {
+			var t *Timer
+			if clk.maxBatch == 0 || fired < clk.maxBatch {
+				t = clk.popExpiredLocked(now)
+			}
+			if t == nil {
+				if t = clk.peekLocked(); t == nil {
+					break
+				}
+				if due := clk.timers.Due(t); due.After(now) {
+					next = clk.wakeup(due)
+				} else {
+					// Hold back the rest of a mass expiry; see WithMaxBatch.
+					next = now.Add(clk.batchPause)
+				}
+				break
+			}
+			clk.disarmedLocked()
+			fired++
+			clk.ops.routineFired.Add(1)
+			if t.f != nil {
+				// Start the callbacks after releasing the mutex, which they might need.
+				clk.record(OpFire, t, now, 0, true)
+				batch = append(batch, clk.callbackLocked(t, t.f, t.f))
+				continue
+			}
+			clk.fireLocked(t, now)
+		}
 		clk.mutex.Unlock()
 
-		// Reschedule immediately.
-		goto Reschedule
+		clk.goFuncs(batch)
+		clear(batch)
+		batch = batch[:0]
+
+		// Sleep until the next deadline.  Timers that expired while the batch was dispatched are
+		// fired by the next batch, right away.
+		if next.IsZero() {
+			if clk.idle > 0 {
+				sleeper.Reset(clk.idle)
+				idling = true
+			}
+			continue
+		}
+		if fired > 0 {
+			now = clk.now()
+		}
+		sleeper.Reset(next.Sub(now))
 	}
 }
@@ -0,0 +1,435 @@
+package kairos
+
+import (
+	"context"
+	"reflect"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// traceSource is a time source that replays a fixed sequence of instants, one per call to advance.
+type traceSource struct {
+	mu    sync.Mutex
+	trace []time.Time
+}
+
+func (ts *traceSource) now() time.Time {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	return ts.trace[0]
+}
+
+func (ts *traceSource) advance() {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	ts.trace = ts.trace[1:]
+}
+
+func TestNewClockFromFunc(t *testing.T) {
+	ts := &traceSource{trace: []time.Time{fakeStart, fakeStart.Add(time.Second), fakeStart.Add(time.Minute)}}
+	// The trace only advances when told to, so drive the timer routine with a fake sleeper.
+	fc := NewFakeClock(fakeStart)
+	c := NewClockFromFunc(ts.now, WithSleeper(NewClockSleeper(fc)))
+	timer := c.NewTimer(30 * time.Second)
+	ts.advance()
+	fc.Advance(30 * time.Second)
+	time.Sleep(10 * time.Millisecond)
+	if _, ok := recv(timer.C); ok {
+		t.Fatalf("timer fired before the time source reached its deadline")
+	}
+	ts.advance()
+	fc.Advance(30 * time.Second)
+	if got, want := waitFired(t, timer), fakeStart.Add(time.Minute); !got.Equal(want) {
+		t.Errorf("got fire time %v, want %v", got, want)
+	}
+}
+
+func TestNewClockFromFuncRealSleeper(t *testing.T) {
+	c := NewClockFromFunc(time.Now)
+	const want = 100 * time.Millisecond
+	start := time.Now()
+	waitFired(t, c.NewTimer(want))
+	if got := time.Since(start); got < want || got >= want+margin {
+		t.Errorf("timer fired at wrong time; got duration %v, want %v", got, want)
+	}
+}
+
+func TestClockBatchFiring(t *testing.T) {
+	fc := NewFakeClock(fakeStart)
+	var reads atomic.Int64
+	now := func() time.Time {
+		reads.Add(1)
+		return fc.Now()
+	}
+	c := NewClockFromFunc(now, WithSleeper(NewClockSleeper(fc)))
+	defer c.Close()
+	const n = 1000
+	timers := make([]*Timer, n)
+	for i := range timers {
+		timers[i] = c.NewTimer(time.Second)
+	}
+	var called sync.WaitGroup
+	called.Add(n)
+	for i := 0; i < n; i++ {
+		c.AfterFunc(time.Second, called.Done)
+	}
+	later := c.NewTimer(2 * time.Second)
+	before := reads.Load()
+	fc.Advance(time.Second)
+	for i, timer := range timers {
+		if got, want := waitFired(t, timer), fakeStart.Add(time.Second); !got.Equal(want) {
+			t.Fatalf("timer %d: got fire time %v, want %v", i, got, want)
+		}
+	}
+	called.Wait()
+	// One read on wakeup, and possibly already one to compute the time until the next deadline.
+	if got := reads.Load() - before; got > 2 {
+		t.Errorf("got %d time reads to fire %d timers, want at most 2", got, 2*n)
+	}
+	if got := diagnostics(c).Pending(); got != 1 {
+		t.Errorf("got %d pending timers, want 1", got)
+	}
+	fc.Advance(time.Second)
+	waitFired(t, later)
+}
+
+func TestTieOrder(t *testing.T) {
+	for _, tc := range []struct {
+		desc string
+		opts []ClockOption
+		want []int // Order in which the timers fire, or nil if unspecified.
+	}{
+		{"default", nil, []int{1, 2, 3, 0}},
+		{"FIFO", []ClockOption{WithTieOrder(FIFOTies)}, []int{1, 2, 3, 0}},
+		{"LIFO", []ClockOption{WithTieOrder(LIFOTies)}, []int{0, 3, 2, 1}},
+		{"unordered", []ClockOption{WithTieOrder(UnorderedTies)}, nil},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			want := tc.want
+			if want == nil {
+				want = []int{0, 1, 2, 3}
+			}
+			check := func(desc string, got []int) {
+				t.Helper()
+				if tc.want == nil {
+					slices.Sort(got)
+				}
+				if !slices.Equal(got, want) {
+					t.Errorf("%s: timers fired in order %v, want %v", desc, got, want)
+				}
+			}
+
+			// The callbacks of a fake clock with deterministic dispatch run in the order the timers
+			// fire.  Its time stands still, so the timers get the same deadline.
+			fc := NewFakeClock(fakeStart, append(tc.opts, WithDeterministicDispatch())...)
+			defer fc.Close()
+			var got []int
+			timers := make([]*Timer, 4)
+			for i := range timers {
+				timers[i] = fc.AfterFunc(time.Second, func() { got = append(got, i) })
+			}
+			timers[0].Reset(time.Second) // Starts timer 0 again, after the others.
+			fc.Advance(time.Second)
+			check("fake clock", got)
+
+			// PopExpired returns the timers in the order they fire.
+			c := NewClockFromFunc(func() time.Time { return fakeStart }, append(tc.opts, WithManualExpiry())...)
+			defer c.Close()
+			index := map[*Timer]int{}
+			for i := range timers {
+				timers[i] = c.NewTimer(time.Second)
+				index[timers[i]] = i
+			}
+			timers[0].Reset(time.Second)
+			got = nil
+			for _, timer := range diagnostics(c).PopExpired(fakeStart.Add(time.Second), 0) {
+				got = append(got, index[timer])
+			}
+			check("PopExpired", got)
+		})
+	}
+}
+
+func TestClockSlack(t *testing.T) {
+	fc := NewFakeClock(fakeStart)
+	c := NewClockFromFunc(fc.Now, WithSleeper(NewClockSleeper(fc)), WithSlack(10*time.Millisecond))
+	defer c.Close()
+	timers := []*Timer{
+		c.NewTimer(100 * time.Millisecond),
+		c.NewTimer(105 * time.Millisecond),
+		c.NewTimer(120 * time.Millisecond),
+	}
+	for _, step := range []struct {
+		advance time.Duration
+		fired   []int // Indexes of the timers that fire.
+	}{
+		{100 * time.Millisecond, nil},
+		{10 * time.Millisecond, []int{0, 1}},
+		{15 * time.Millisecond, nil},
+		{5 * time.Millisecond, []int{2}},
+	} {
+		fc.BlockUntil(1) // Wait for the timer routine to go to sleep.
+		fc.Advance(step.advance)
+		for _, i := range step.fired {
+			if got, want := waitFired(t, timers[i]), fc.Now(); !got.Equal(want) {
+				t.Errorf("timer %d: got fire time %v, want %v", i, got, want)
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		for i, timer := range timers {
+			if _, ok := recv(timer.C); ok {
+				t.Errorf("at %v: timer %d fired, want it to wait", fc.Since(fakeStart), i)
+			}
+		}
+	}
+}
+
+func TestClockResolution(t *testing.T) {
+	fc := NewFakeClock(fakeStart.Add(20 * time.Millisecond))
+	c := NewClockFromFunc(fc.Now, WithSleeper(NewClockSleeper(fc)), WithResolution(100*time.Millisecond))
+	defer c.Close()
+	timers := []*Timer{
+		c.NewTimer(10 * time.Millisecond),
+		c.NewTimer(70 * time.Millisecond),
+		c.NewTimer(130 * time.Millisecond),
+	}
+	for _, step := range []struct {
+		advance time.Duration
+		fired   []int // Indexes of the timers that fire.
+	}{
+		{70 * time.Millisecond, nil},
+		{10 * time.Millisecond, []int{0, 1}},
+		{99 * time.Millisecond, nil},
+		{1 * time.Millisecond, []int{2}},
+	} {
+		fc.BlockUntil(1) // Wait for the timer routine to go to sleep.
+		fc.Advance(step.advance)
+		for _, i := range step.fired {
+			if got, want := waitFired(t, timers[i]), fc.Now(); !got.Equal(want) {
+				t.Errorf("timer %d: got fire time %v, want %v", i, got, want)
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		for i, timer := range timers {
+			if _, ok := recv(timer.C); ok {
+				t.Errorf("at %v: timer %d fired, want it to wait", fc.Since(fakeStart), i)
+			}
+		}
+	}
+}
+
+func TestPopExpired(t *testing.T) {
+	now := func() time.Time { return fakeStart }
+	for _, tc := range []struct {
+		desc string
+		c    Clock
+	}{
+		{"manual", NewClockFromFunc(now, WithManualExpiry())},
+		{"manual sharded", NewClockFromFunc(now, WithManualExpiry(), WithShards(3))},
+		{"runtime timers", NewClockFromFunc(now, WithRuntimeTimers())},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			defer tc.c.Close()
+			a := tc.c.NewTimer(time.Hour)
+			b := tc.c.NewTimer(2 * time.Hour)
+			c := tc.c.NewTimer(3 * time.Hour)
+			called := make(chan struct{})
+			tc.c.AfterFunc(90*time.Minute, func() { close(called) })
+			for _, step := range []struct {
+				now  time.Duration
+				max  int
+				want []*Timer
+			}{
+				{30 * time.Minute, 0, nil},
+				{2 * time.Hour, 1, []*Timer{a}},
+				{2 * time.Hour, 0, []*Timer{b}},
+				{2 * time.Hour, 0, nil},
+			} {
+				got := diagnostics(tc.c).PopExpired(fakeStart.Add(step.now), step.max)
+				if !reflect.DeepEqual(got, step.want) {
+					t.Errorf("PopExpired(%v, %d): got %v, want %v", step.now, step.max, got, step.want)
+				}
+			}
+			select {
+			case <-called:
+			case <-time.After(time.Second):
+				t.Errorf("AfterFunc callback was not called")
+			}
+			for i, timer := range []*Timer{a, b} {
+				if _, ok := recv(timer.C); ok {
+					t.Errorf("popped timer %d sent on its channel", i)
+				}
+				if timer.Stop() {
+					t.Errorf("popped timer %d: Stop: was active is true", i)
+				}
+			}
+			if got := diagnostics(tc.c).Pending(); got != 1 {
+				t.Errorf("got %d pending timers, want 1", got)
+			}
+			if !c.Stop() {
+				t.Errorf("Stop of unexpired timer: was active is false")
+			}
+		})
+	}
+}
+
+func TestNewClockIsolation(t *testing.T) {
+	clocks := []Clock{NewClock(), NewClock(), realClock}
+	var timers []*Timer
+	for _, c := range clocks {
+		timers = append(timers, c.NewTimer(time.Hour))
+	}
+	for i, timer := range timers {
+		if timer.clk == timers[(i+1)%len(timers)].clk {
+			t.Errorf("timers %d and %d share a clock", i, (i+1)%len(timers))
+		}
+		if got := timer.clk.timers.Len(); got < 1 {
+			t.Errorf("clock %d: got %d pending timers, want at least 1", i, got)
+		}
+	}
+	// Firing a timer on one clock must not disturb the others.
+	start := time.Now()
+	timers[0].Reset(0)
+	waitFired(t, timers[0])
+	for i, timer := range timers[1:] {
+		if _, ok := recv(timer.C); ok {
+			t.Errorf("timer %d fired", i+1)
+		}
+		if !timer.Stop() {
+			t.Errorf("timer %d: was active is false", i+1)
+		}
+	}
+	if got := time.Since(start); got >= margin {
+		t.Errorf("timer fired at wrong time; got duration %v, want 0", got)
+	}
+}
+
+func TestClockClose(t *testing.T) {
+	for _, tc := range []struct {
+		desc   string
+		policy ClosePolicy
+		fire   bool
+	}{
+		{"cancel", CancelOnClose, false},
+		{"fire", FireOnClose, true},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			for _, c := range []Clock{NewClock(WithClosePolicy(tc.policy)), NewFakeClock(fakeStart, WithClosePolicy(tc.policy))} {
+				timer := c.NewTimer(time.Hour)
+				if err := c.Close(); err != nil {
+					t.Fatalf("%T: Close: got error %v, want nil", c, err)
+				}
+				if _, ok := recv(timer.C); ok != tc.fire {
+					t.Errorf("%T: pending timer fired = %v, want %v", c, ok, tc.fire)
+				}
+				if timer.Stop() {
+					t.Errorf("%T: Stop after Close: was active is true", c)
+				}
+				if timer.Reset(0) {
+					t.Errorf("%T: Reset after Close: was active is true", c)
+				}
+				time.Sleep(10 * time.Millisecond)
+				if _, ok := recv(timer.C); ok {
+					t.Errorf("%T: timer reset after Close fired", c)
+				}
+				if err := c.Shutdown(context.Background()); err != ErrClosed {
+					t.Errorf("%T: second Shutdown: got error %v, want %v", c, err, ErrClosed)
+				}
+			}
+		})
+	}
+}
+
+func TestClockShutdownStopsRoutine(t *testing.T) {
+	c := NewClock()
+	c.NewTimer(time.Hour)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	t.Cleanup(cancel)
+	if err := c.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown: got error %v, want nil", err)
+	}
+	select {
+	case <-c.(*clock).doneC:
+	default:
+		t.Errorf("timer routine still running after Shutdown returned")
+	}
+}
+
+func TestClockIdleShutdown(t *testing.T) {
+	c := NewClock(WithIdleShutdown(20 * time.Millisecond)).(*clock)
+	defer c.Close()
+	running := func() bool {
+		c.mutex.Lock()
+		defer c.mutex.Unlock()
+		return c.running
+	}
+	if running() {
+		t.Fatalf("timer routine started before any timer")
+	}
+	for i := 0; i < 2; i++ {
+		waitFired(t, c.NewTimer(time.Millisecond))
+		if !running() {
+			t.Errorf("timer routine not running after a timer fired")
+		}
+		deadline := time.Now().Add(time.Second)
+		for running() && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+		}
+		if running() {
+			t.Fatalf("timer routine still running after the idle period")
+		}
+	}
+	// A clock whose routine never started or exited shuts down right away.
+	if err := NewClock().Close(); err != nil {
+		t.Errorf("Close of an unused clock: got error %v, want nil", err)
+	}
+}
+
+func TestClockHighResolution(t *testing.T) {
+	c := NewClock(WithHighResolution(), WithIdleShutdown(10*time.Millisecond))
+	defer c.Close()
+	for i := 0; i < 2; i++ {
+		start := time.Now()
+		timer := c.NewTimer(2 * time.Millisecond)
+		if got := waitFired(t, timer).Sub(start); got < 2*time.Millisecond || got >= 2*time.Millisecond+margin {
+			t.Errorf("timer fired after %v, want 2ms", got)
+		}
+		// Let the routine exit, restoring the system timer resolution, and start again.
+		time.Sleep(30 * time.Millisecond)
+	}
+}
+
+func TestSetClock(t *testing.T) {
+	fc := NewFakeClock(fakeStart)
+	restore := SetClock(fc)
+	timer := NewTimer(time.Hour)
+	if timer.clk != fc.clock {
+		t.Errorf("NewTimer did not use the installed clock")
+	}
+	func() {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Errorf("second SetClock did not panic")
+			}
+		}()
+		SetClock(NewFakeClock(fakeStart))
+	}()
+	restore()
+	if NewStoppedTimer().clk != realClock {
+		t.Errorf("NewStoppedTimer did not use the real clock after restore")
+	}
+	// Timers keep using the clock that created them.
+	fc.Advance(time.Hour)
+	if _, ok := recv(timer.C); !ok {
+		t.Errorf("timer created with the installed clock did not fire")
+	}
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("second restore did not panic")
+		}
+	}()
+	restore()
+}
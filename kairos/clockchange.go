@@ -0,0 +1,206 @@
+package kairos
+
+import (
+	"sync"
+	"time"
+)
+
+// A ClockChange is a discontinuity of a clock's wall time detected by a [ClockWatcher]: the system
+// clock was stepped, for example by NTP or an administrator, or the machine resumed from sleep,
+// during which the monotonic clock stands still on most systems.  Timers started with a duration
+// follow the monotonic clock, so after such a change they no longer expire at the wall-clock time
+// they were meant for.
+type ClockChange struct {
+	// Time is the clock's time when the change was detected.
+	Time time.Time
+	// Step is how far the wall time moved beyond the time that elapsed: positive if the wall clock
+	// jumped forward or the machine was asleep, and negative if the wall clock was set back.
+	Step time.Duration
+}
+
+// A ClockWatcher detects discontinuities of a clock's wall time by comparing, at regular
+// intervals, how far its wall time moved with how much time elapsed on its monotonic clock.  Only
+// clocks whose times carry a monotonic clock reading, such as those returned by [NewClock], can
+// report changes; the time of a [FakeClock] has no such reading, and its timers follow its time
+// anyway.
+type ClockWatcher struct {
+	// C delivers the detected changes.  A change is dropped if the receiver has not taken the
+	// previous one yet.
+	C <-chan ClockChange
+
+	c        chan ClockChange
+	clock    Clock
+	tk       *Ticker
+	steps    stepDetector
+	stopOnce sync.Once
+	stopC    chan struct{}
+	doneC    chan struct{}
+}
+
+// NewClockWatcher returns a [ClockWatcher] that checks c every interval and reports the changes of
+// its wall time larger than threshold.  A change that happens while the machine is asleep is
+// reported within interval after it resumes.  NewClockWatcher panics if interval is not positive.
+func NewClockWatcher(c Clock, interval, threshold time.Duration) *ClockWatcher {
+	w := newClockWatcher(c, interval, threshold)
+	go w.watch(c.Now())
+	return w
+}
+
+// newClockWatcher returns a new ClockWatcher without starting it.
+func newClockWatcher(c Clock, interval, threshold time.Duration) *ClockWatcher {
+	if interval <= 0 {
+		panic("kairos: non-positive interval for NewClockWatcher")
+	}
+	ch := make(chan ClockChange, 1)
+	return &ClockWatcher{
+		C:     ch,
+		c:     ch,
+		clock: c,
+		tk:    c.NewTicker(interval),
+		steps: newStepDetector(threshold),
+		stopC: make(chan struct{}),
+		doneC: make(chan struct{}),
+	}
+}
+
+func (w *ClockWatcher) watch(prev time.Time) {
+	defer close(w.doneC)
+	w.steps.prev = prev
+	w.steps.watch(w.clock.Now, w.tk.C, nil, w.stopC, func(now time.Time, step time.Duration) {
+		select {
+		case w.c <- ClockChange{Time: now, Step: step}:
+		default:
+		}
+	})
+}
+
+// Stop stops the watcher.  No change is sent on C after Stop returns.
+func (w *ClockWatcher) Stop() {
+	w.stopOnce.Do(func() { close(w.stopC) })
+	w.tk.Stop()
+	<-w.doneC
+}
+
+// WithClockChangeDetection makes a clock created by [NewClock] or [NewClockFromFunc] detect the
+// discontinuities of its wall time larger than threshold, like a [ClockWatcher] checking every
+// interval, and re-evaluate its armed timers when one happens.  Timers armed with
+// [Clock.NewTimerAt] then expire at their wall-clock deadlines: the clock drops the monotonic clock
+// reading of their deadlines, and after the system clock is set or the machine resumes from sleep,
+// it fires those that fell due and goes back to sleep until the earliest deadline left, instead of
+// firing them hours late or early.  Timers started with a duration keep following the monotonic
+// clock.
+//
+// Changes are detected by the clock's timer routine, only while it runs.  On Linux, settings of
+// the system clock are also notified by the kernel and handled right away; other changes, and
+// all of them on other platforms, are detected within interval.  The option has no effect with
+// [WithRuntimeTimers] or [WithManualExpiry].  It panics if interval is not positive.
+func WithClockChangeDetection(interval, threshold time.Duration) ClockOption {
+	if interval <= 0 {
+		panic("kairos: non-positive interval for WithClockChangeDetection")
+	}
+	return func(cfg *clockConfig) {
+		cfg.detect = &changeDetection{interval: interval, steps: newStepDetector(threshold)}
+	}
+}
+
+// changeDetection configures the detection of the changes of a clock's wall time.
+type changeDetection struct {
+	interval time.Duration
+	steps    stepDetector // Copied by each run of the detection.
+}
+
+// detectChanges notifies the timer routine when the wall time of clk changes by more than the
+// threshold, until stopC is closed.  It closes doneC when it returns.
+func (clk *clock) detectChanges(stopC <-chan struct{}, doneC chan<- struct{}) {
+	defer close(doneC)
+	setC, stopSet := notifyClockSet()
+	defer stopSet()
+	tk := time.NewTicker(clk.detect.interval)
+	defer tk.Stop()
+	steps := clk.detect.steps
+	steps.prev = clk.now()
+	steps.watch(clk.now, tk.C, setC, stopC, func(time.Time, time.Duration) { clk.wallStepped() })
+}
+
+// A wallStepper is a clock that re-evaluates its timers armed for wall-clock times when told that
+// its wall time stepped, such as one created with WithClockChangeDetection.
+type wallStepper interface {
+	wallStepped()
+}
+
+// wallStepped makes the timer routine re-evaluate the timers armed for wall-clock times, if the
+// clock detects the changes of its wall time.
+func (clk *clock) wallStepped() {
+	if clk.stepC == nil {
+		return
+	}
+	select {
+	case clk.stepC <- struct{}{}:
+	default:
+	}
+}
+
+// wallStepped makes every shard re-evaluate its timers armed for wall-clock times.
+func (sc *shardedClock) wallStepped() {
+	for _, shard := range sc.shards {
+		shard.wallStepped()
+	}
+}
+
+// A stepDetector detects the steps of a clock's wall time by comparing, at each check, how far the
+// wall time moved since the previous check with how much time elapsed on the monotonic clock.
+type stepDetector struct {
+	threshold time.Duration // Smallest step reported.
+	// elapsed returns the time that elapsed from prev to now; replaced by tests.
+	elapsed func(prev, now time.Time) time.Duration
+	prev    time.Time // Time of the previous check.
+}
+
+// newStepDetector returns a stepDetector of the steps larger than threshold.  Its prev field must
+// be set before the first check.
+func newStepDetector(threshold time.Duration) stepDetector {
+	return stepDetector{
+		threshold: threshold,
+		elapsed:   func(prev, now time.Time) time.Duration { return now.Sub(prev) },
+	}
+}
+
+// check returns the time that elapsed on the monotonic clock since the previous check and how far
+// the wall time moved beyond it, and reports whether that gain is a step larger than the threshold.
+func (d *stepDetector) check(now time.Time) (mono, gain time.Duration, step bool) {
+	mono = d.elapsed(d.prev, now)
+	// Round(0) strips the monotonic clock readings, so that Sub compares the wall times.
+	gain = now.Round(0).Sub(d.prev.Round(0)) - mono
+	d.prev = now
+	return mono, gain, gain > d.threshold || gain < -d.threshold
+}
+
+// watch checks the time returned by now each time tick or set delivers, until stopC is closed, and
+// calls found with the time and size of each step.  set may be nil.
+func (d *stepDetector) watch(now func() time.Time, tick <-chan time.Time, set, stopC <-chan struct{},
+	found func(now time.Time, step time.Duration)) {
+	for {
+		select {
+		case <-tick:
+		case <-set:
+		case <-stopC:
+			return
+		}
+		t := now()
+		if _, gain, step := d.check(t); step {
+			found(t, gain)
+		}
+	}
+}
+
+// requeueLocked removes the armed timers and inserts them again, after a change of the wall time
+// may have changed the order of their deadlines.  The mutex must be held.
+func (clk *clock) requeueLocked() {
+	ts := clk.timers.AppendTo(nil)
+	for _, t := range ts {
+		clk.timers.Remove(t)
+	}
+	for _, t := range ts {
+		clk.timers.Insert(t)
+	}
+}
@@ -0,0 +1,103 @@
+package kairos
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClockWatcher(t *testing.T) {
+	fc := NewFakeClock(fakeStart)
+	defer fc.Close()
+	w := newClockWatcher(fc, time.Second, time.Minute)
+	// The time that elapses on the simulated monotonic clock between two checks.
+	var mono atomic.Int64
+	w.steps.elapsed = func(prev, now time.Time) time.Duration { return time.Duration(mono.Load()) }
+	go w.watch(fc.Now())
+	defer w.Stop()
+	for _, step := range []struct {
+		desc    string
+		advance time.Duration // Advance of the wall time.
+		mono    time.Duration // Elapsed monotonic time.
+		want    time.Duration // Expected step, or 0 if no change.
+	}{
+		{"steady", time.Second, time.Second, 0},
+		{"below threshold", 31 * time.Second, time.Second, 0},
+		{"asleep", 8 * time.Hour, time.Second, 8*time.Hour - time.Second},
+		{"set back", time.Second, time.Hour, time.Second - time.Hour},
+	} {
+		mono.Store(int64(step.mono))
+		fc.Advance(step.advance)
+		var got time.Duration
+		select {
+		case c := <-w.C:
+			got = c.Step
+			if !c.Time.Equal(fc.Now()) {
+				t.Errorf("%s: got change at %v, want %v", step.desc, c.Time, fc.Now())
+			}
+		case <-time.After(50 * time.Millisecond):
+		}
+		if got != step.want {
+			t.Errorf("%s: got step %v, want %v", step.desc, got, step.want)
+		}
+	}
+}
+
+func TestClockWatcherRealClock(t *testing.T) {
+	c := NewClock()
+	defer c.Close()
+	w := NewClockWatcher(c, time.Millisecond, time.Second)
+	select {
+	case change := <-w.C:
+		t.Errorf("got change %+v without a step of the system clock", change)
+	case <-time.After(20 * time.Millisecond):
+	}
+	w.Stop()
+	w.Stop()
+}
+
+func TestClockChangeDetection(t *testing.T) {
+	// The clock's time is a wall time, without monotonic clock reading, that runs in real time
+	// plus the steps of the simulated wall clock.
+	start := time.Now()
+	var jumped atomic.Int64
+	now := func() time.Time {
+		return fakeStart.Add(time.Since(start) + time.Duration(jumped.Load()))
+	}
+	c := NewClockFromFunc(now, WithClockChangeDetection(5*time.Millisecond, time.Minute)).(*clock)
+	defer c.Close()
+	var seen time.Duration // Steps seen by the detector so far.
+	c.detect.steps.elapsed = func(prev, now time.Time) time.Duration {
+		step := time.Duration(jumped.Load()) - seen
+		seen += step
+		return now.Sub(prev) - step
+	}
+
+	wall := c.NewTimerAt(fakeStart.Add(time.Hour))
+	mono := c.NewTimer(time.Hour)
+	early := c.NewTimerAt(fakeStart.Add(3 * time.Hour))
+	jumped.Store(int64(2 * time.Hour))
+	waitFired(t, wall)
+	waitFired(t, mono) // Armed from the clock's time, which is a wall time too.
+	if _, ok := recv(early.C); ok {
+		t.Errorf("timer for after the step fired")
+	}
+	// Setting the clock back leaves the timers armed for later.
+	jumped.Store(0)
+	time.Sleep(20 * time.Millisecond)
+	if _, ok := recv(early.C); ok {
+		t.Errorf("timer for after the step fired after the clock was set back")
+	}
+	if got := c.Pending(); got != 1 {
+		t.Errorf("got %d pending timers, want 1", got)
+	}
+}
+
+func TestClockChangeDetectionNewTimerAt(t *testing.T) {
+	c := NewClock(WithClockChangeDetection(time.Second, time.Second)).(*clock)
+	defer c.Close()
+	timer := c.NewTimerAt(time.Now().Add(time.Hour))
+	if timer.when != timer.when.Round(0) {
+		t.Errorf("deadline %v has a monotonic clock reading, want only a wall time", timer.when)
+	}
+}
@@ -0,0 +1,41 @@
+package kairos
+
+import "time"
+
+// notifyClockSet returns a channel that receives a value when the system's wall clock is set, and
+// a function that stops the notifications.  The channel is nil where the platform cannot notify
+// such changes.
+//
+// On Linux, a CLOCK_REALTIME timerfd armed far in the future is canceled by the kernel when the
+// clock is set, and is armed again after each notification.
+func notifyClockSet() (<-chan struct{}, func()) {
+	s, err := NewRealtimeTimerfdSleeper()
+	if err != nil {
+		return nil, func() {}
+	}
+	const farFuture = 100 * 365 * 24 * time.Hour
+	s.Reset(farFuture)
+	c := make(chan struct{}, 1)
+	stopC := make(chan struct{})
+	doneC := make(chan struct{})
+	go func() {
+		defer close(doneC)
+		for {
+			select {
+			case <-s.Changes():
+				s.Reset(farFuture)
+				select {
+				case c <- struct{}{}:
+				default:
+				}
+			case <-stopC:
+				return
+			}
+		}
+	}()
+	return c, func() {
+		close(stopC)
+		<-doneC
+		s.Close()
+	}
+}
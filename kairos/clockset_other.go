@@ -0,0 +1,7 @@
+//go:build !linux
+
+package kairos
+
+// notifyClockSet returns a nil channel: the settings of the wall clock are only detected by
+// polling on this platform.
+func notifyClockSet() (<-chan struct{}, func()) { return nil, func() {} }
@@ -0,0 +1,90 @@
+// Package clockworkadapter converts between kairos clocks and [clockwork] clocks, so that projects
+// whose injection points are typed as [clockwork.Clock] can adopt kairos timers incrementally.
+//
+// [clockwork]: https://github.com/jonboulle/clockwork
+package clockworkadapter
+
+import (
+	"time"
+
+	"github.com/jonboulle/clockwork"
+	"github.com/rhansen/go-kairos/kairos"
+)
+
+// FromKairos returns a [clockwork.Clock] backed by c.  Timers, tickers, and AfterFunc callbacks
+// created through the returned clock are driven by c, so a [kairos.FakeClock] can control them.
+func FromKairos(c kairos.Clock) clockwork.Clock {
+	return kairosClock{c}
+}
+
+type kairosClock struct{ c kairos.Clock }
+
+func (k kairosClock) After(d time.Duration) <-chan time.Time { return k.c.After(d) }
+func (k kairosClock) Sleep(d time.Duration)                  { k.c.Sleep(d) }
+func (k kairosClock) Now() time.Time                         { return k.c.Now() }
+func (k kairosClock) Since(t time.Time) time.Duration        { return k.c.Since(t) }
+func (k kairosClock) Until(t time.Time) time.Duration        { return k.c.Until(t) }
+
+func (k kairosClock) NewTimer(d time.Duration) clockwork.Timer {
+	return timer{k.c.NewTimer(d)}
+}
+
+func (k kairosClock) AfterFunc(d time.Duration, f func()) clockwork.Timer {
+	return timer{k.c.AfterFunc(d, f)}
+}
+
+func (k kairosClock) NewTicker(d time.Duration) clockwork.Ticker {
+	return ticker{k.c.NewTicker(d)}
+}
+
+// timer adapts a [kairos.Timer] to [clockwork.Timer].
+type timer struct{ *kairos.Timer }
+
+func (t timer) Chan() <-chan time.Time { return t.C }
+
+// ticker adapts a [kairos.Ticker] to [clockwork.Ticker].
+type ticker struct{ *kairos.Ticker }
+
+func (tk ticker) Chan() <-chan time.Time { return tk.C }
+
+// ToKairos returns a [kairos.Clock] whose time is read from c and whose timer routine sleeps using
+// timers created by c, so that a [clockwork.FakeClock] can drive kairos timers.  opts configure the
+// returned clock; [kairos.WithSleeper] is overridden.
+func ToKairos(c clockwork.Clock, opts ...kairos.ClockOption) kairos.Clock {
+	opts = append(opts[:len(opts):len(opts)], kairos.WithSleeper(&sleeper{c: c}))
+	return kairos.NewClockFromFunc(c.Now, opts...)
+}
+
+// sleeper is a [kairos.Sleeper] backed by a [clockwork.Timer].  It is only used by the timer
+// routine of a single clock, so it needs no locking.
+type sleeper struct {
+	c clockwork.Clock
+	t clockwork.Timer // Created on first use.
+}
+
+func (s *sleeper) C() <-chan time.Time {
+	if s.t == nil {
+		return nil
+	}
+	return s.t.Chan()
+}
+
+func (s *sleeper) Reset(d time.Duration) {
+	if s.t == nil {
+		s.t = s.c.NewTimer(d)
+		return
+	}
+	s.Stop()
+	s.t.Reset(d)
+}
+
+func (s *sleeper) Stop() {
+	if s.t == nil {
+		return
+	}
+	s.t.Stop()
+	select {
+	case <-s.t.Chan():
+	default:
+	}
+}
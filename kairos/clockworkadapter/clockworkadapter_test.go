@@ -0,0 +1,76 @@
+package clockworkadapter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jonboulle/clockwork"
+	"github.com/rhansen/go-kairos/kairos"
+)
+
+var start = time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+func waitFor(t *testing.T, c <-chan time.Time) time.Time {
+	t.Helper()
+	select {
+	case got := <-c:
+		return got
+	case <-time.After(time.Second):
+		t.Fatalf("channel did not receive a value")
+		return time.Time{}
+	}
+}
+
+func TestFromKairos(t *testing.T) {
+	fc := kairos.NewFakeClock(start)
+	c := FromKairos(fc)
+	if got := c.Since(start.Add(-time.Second)); got != time.Second {
+		t.Errorf("got Since %v, want %v", got, time.Second)
+	}
+	timer := c.NewTimer(time.Second)
+	after := c.After(2 * time.Second)
+	called := make(chan time.Time, 1)
+	c.AfterFunc(time.Second, func() { called <- fc.Now() })
+	stopped := c.AfterFunc(time.Second, func() { t.Errorf("stopped AfterFunc ran") })
+	if !stopped.Stop() {
+		t.Errorf("AfterFunc Stop: was active is false")
+	}
+	ticker := c.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	fc.Advance(time.Second)
+	if got, want := waitFor(t, timer.Chan()), start.Add(time.Second); !got.Equal(want) {
+		t.Errorf("timer: got %v, want %v", got, want)
+	}
+	if got, want := waitFor(t, called), start.Add(time.Second); !got.Equal(want) {
+		t.Errorf("AfterFunc: got %v, want %v", got, want)
+	}
+	if got, want := waitFor(t, ticker.Chan()), start.Add(time.Second); !got.Equal(want) {
+		t.Errorf("first tick: got %v, want %v", got, want)
+	}
+	fc.Advance(time.Second)
+	if got, want := waitFor(t, after), start.Add(2*time.Second); !got.Equal(want) {
+		t.Errorf("After: got %v, want %v", got, want)
+	}
+	if got, want := waitFor(t, ticker.Chan()), start.Add(2*time.Second); !got.Equal(want) {
+		t.Errorf("second tick: got %v, want %v", got, want)
+	}
+}
+
+func TestToKairos(t *testing.T) {
+	cw := clockwork.NewFakeClockAt(start)
+	c := ToKairos(cw)
+	t.Cleanup(func() { c.Close() })
+	timer := c.NewTimer(time.Minute)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	t.Cleanup(cancel)
+	// Wait for the timer routine to go to sleep on the clockwork clock.
+	if err := cw.BlockUntilContext(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	cw.Advance(time.Minute)
+	if got, want := waitFor(t, timer.C), start.Add(time.Minute); !got.Equal(want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,10 @@
+module github.com/rhansen/go-kairos/kairos/clockworkadapter
+
+go 1.22
+
+require (
+	github.com/jonboulle/clockwork v0.5.0
+	github.com/rhansen/go-kairos v0.0.0-00010101000000-000000000000
+)
+
+replace github.com/rhansen/go-kairos => ../..
@@ -0,0 +1,201 @@
+package kairos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrTimerFired is the cause of the cancellation of the contexts returned by [Timer.Context].  It
+// wraps [context.DeadlineExceeded], so it is reported as a timeout.
+var ErrTimerFired = fmt.Errorf("kairos: timer fired: %w", context.DeadlineExceeded)
+
+// ErrTimerReleased is the cause of the cancellation of the contexts returned by [Timer.Context]
+// when the timer is released with [ReleaseTimer] before it fires.
+var ErrTimerReleased = errors.New("kairos: timer released")
+
+// NewTimerCtx is like [NewTimer], but the timer is bound to ctx: once ctx is done, the timer is
+// stopped and its channel emptied, so that it delivers nothing afterward unless it is reset.  This
+// spares request-scoped code a deferred Stop.  If ctx is already done, the timer is returned
+// stopped.  The binding lasts until ctx is done, so bind timers to contexts that end, such as
+// those of requests, rather than to long-lived ones.
+func NewTimerCtx(ctx context.Context, d time.Duration) *Timer {
+	return defaultClock().NewTimerCtx(ctx, d)
+}
+
+// NewTimerCtx is like NewTimer, but stops the timer once ctx is done.  See the package-level
+// [NewTimerCtx].
+func (clk *clock) NewTimerCtx(ctx context.Context, d time.Duration) *Timer {
+	if ctx.Err() != nil {
+		return clk.NewStoppedTimer()
+	}
+	t := clk.NewTimer(d)
+	if ctx.Done() != nil {
+		// The callback references the timer, not the handle, to keep leak detection working.
+		n := t.impl()
+		context.AfterFunc(ctx, func() { clk.stopDrained(n) })
+	}
+	return t
+}
+
+// stopDrained stops t and empties its channel, so that it delivers nothing until it is reset.
+func (clk *clock) stopDrained(t *Timer) {
+	clk.delTimer(t)
+	// Timers are fired with the mutex held, so t cannot fire after it is drained.
+	clk.mutex.Lock()
+	defer clk.mutex.Unlock()
+	select {
+	case <-t.C:
+	default:
+	}
+	t.drainFiringsLocked()
+}
+
+// A FuncTimer is the handle of a call scheduled by [AfterFuncContext].  Its Stop method both
+// cancels the call, if it has not started, and cancels the context passed to it, so that a
+// long-running call can be aborted cooperatively.
+type FuncTimer struct {
+	*Timer
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// AfterFuncContext is like [AfterFunc], but passes f a context that is cancelled when the returned
+// timer is stopped.  Resetting the timer schedules another call with the same context, so calls
+// scheduled after Stop receive a context that is already done.
+func AfterFuncContext(d time.Duration, f func(ctx context.Context)) *FuncTimer {
+	return defaultClock().AfterFuncContext(d, f)
+}
+
+// AfterFuncContext is like AfterFunc, but passes f a context cancelled by [FuncTimer.Stop].  See
+// the package-level [AfterFuncContext].
+func (clk *clock) AfterFuncContext(d time.Duration, f func(ctx context.Context)) *FuncTimer {
+	ft := &FuncTimer{}
+	ft.ctx, ft.cancel = context.WithCancel(context.Background())
+	ft.Timer = clk.AfterFunc(d, func() { f(ft.ctx) })
+	return ft
+}
+
+// Stop prevents the call from starting, like [Timer.Stop], and cancels the context passed to the
+// call, whether it is running, done, or yet to be started by Reset.  It reports whether the call
+// was prevented from starting.
+func (ft *FuncTimer) Stop() bool {
+	ft.cancel()
+	return ft.Timer.Stop()
+}
+
+// Context returns a context derived from parent that is cancelled, with cause [ErrTimerFired], the
+// next time the timer fires, so that the timer directly bounds the work done under the context.
+// Stopping the timer does not cancel the context, and resetting it postpones the cancellation.
+// The timer holds the context until it fires, so do not derive contexts from a timer that is
+// reused for long without firing.  [ReleaseTimer] cancels the context with cause
+// [ErrTimerReleased].  Context panics if t was not initialized.
+func (t *Timer) Context(parent context.Context) context.Context {
+	t = t.impl()
+	if t.clk == nil {
+		panic("timer: Context called on uninitialized Timer")
+	}
+	ctx, cancel := context.WithCancelCause(parent)
+	t.addContext(cancel)
+	return ctx
+}
+
+// addContext makes the timer call cancel with cause ErrTimerFired the next time it fires.
+func (t *Timer) addContext(cancel context.CancelCauseFunc) {
+	t.clk.mutex.Lock()
+	t.ctxs = append(t.ctxs, cancel)
+	t.clk.mutex.Unlock()
+}
+
+// cancelContextsLocked cancels the contexts returned by Context as the timer fires.  The clock's
+// mutex must be held.
+func (t *Timer) cancelContextsLocked() {
+	for _, cancel := range t.ctxs {
+		cancel(ErrTimerFired)
+	}
+	t.ctxs = nil
+}
+
+// releaseContextsLocked cancels the contexts returned by Context as the timer is released, since it
+// will no longer fire on their behalf.  The clock's mutex must be held.
+func (t *Timer) releaseContextsLocked() {
+	for _, cancel := range t.ctxs {
+		cancel(ErrTimerReleased)
+	}
+	t.ctxs = nil
+}
+
+// ContextWithTimeout is like [context.WithTimeout], but the timeout runs on the clock used by the
+// package-level functions.  See [Clock.ContextWithTimeout].
+func ContextWithTimeout(parent context.Context, d time.Duration) (context.Context,
+	context.CancelFunc) {
+	return defaultClock().ContextWithTimeout(parent, d)
+}
+
+// A clockContext is a context returned by ContextWithTimeout.  Its deadline follows the clock that
+// enforces it, not the real time.  It has its own Done channel, so that the contexts derived from
+// it learn of its cancellation through its Err method, which reports DeadlineExceeded, rather than
+// from the embedded context, which reports Canceled.
+type clockContext struct {
+	context.Context // Cancelled with cause ErrTimerFired at the deadline.
+	deadline        time.Time
+	done            chan struct{} // Closed once the embedded context is done.
+	once            sync.Once     // Closes done.
+}
+
+func (c *clockContext) Deadline() (time.Time, bool) { return c.deadline, true }
+func (c *clockContext) Done() <-chan struct{}       { return c.done }
+
+func (c *clockContext) Err() error {
+	select {
+	case <-c.done:
+	default:
+		return nil
+	}
+	if context.Cause(c.Context) == ErrTimerFired {
+		return context.DeadlineExceeded
+	}
+	return c.Context.Err()
+}
+
+// closeDone closes the Done channel, after the embedded context is cancelled.
+func (c *clockContext) closeDone() {
+	c.once.Do(func() { close(c.done) })
+}
+
+// ContextWithTimeout is like [context.WithTimeout], but the deadline is d after the clock's current
+// time and is enforced by a timer of the clock.  See [Clock.ContextWithTimeout].
+func (clk *clock) ContextWithTimeout(parent context.Context, d time.Duration) (context.Context,
+	context.CancelFunc) {
+	now := clk.now()
+	deadline := now.Add(d)
+	if pd, ok := parent.Deadline(); ok && pd.Before(deadline) {
+		deadline = pd
+	}
+	ctx, cancelCtx := context.WithCancelCause(parent)
+	c := &clockContext{Context: ctx, deadline: deadline, done: make(chan struct{})}
+	cancel := func(cause error) {
+		cancelCtx(cause)
+		c.closeDone()
+	}
+	if !deadline.After(now) {
+		cancel(ErrTimerFired)
+		return c, func() {}
+	}
+	if ctx.Err() != nil {
+		// The parent is done already.
+		c.closeDone()
+		return c, func() {}
+	}
+	t := clk.newStoppedTimer()
+	t.addContext(cancel)
+	clk.startTimerAt(t, deadline)
+	// Release the timer as soon as the context is done, for whatever reason.
+	context.AfterFunc(ctx, func() {
+		t.Stop()
+		c.closeDone()
+	})
+	return c, func() { cancel(context.Canceled) }
+}
@@ -0,0 +1,177 @@
+package kairos
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNewTimerCtx(t *testing.T) {
+	fc := NewFakeClock(fakeStart)
+	defer fc.Close()
+	for _, tc := range []struct {
+		desc  string
+		fire  bool // Whether the timer fires before ctx is cancelled.
+		after bool // Whether ctx is cancelled before the timer is created.
+	}{
+		{"cancelled", false, false},
+		{"fired then cancelled", true, false},
+		{"already cancelled", false, true},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			if tc.after {
+				cancel()
+			}
+			timer := fc.NewTimerCtx(ctx, time.Second)
+			if tc.fire {
+				fc.Advance(time.Second)
+			}
+			cancel()
+			// The timer is stopped and drained asynchronously.
+			for fc.Pending() != 0 || len(timer.C) != 0 {
+				time.Sleep(time.Millisecond)
+			}
+			fc.Advance(time.Second)
+			if now, ok := recv(timer.C); ok {
+				t.Errorf("got expiration at %v after ctx was cancelled, want none", now)
+			}
+			if timer.Reset(time.Second) {
+				t.Errorf("Reset: got true, want false for a stopped timer")
+			}
+			fc.Advance(time.Second)
+			if _, ok := recv(timer.C); !ok {
+				t.Errorf("got no expiration after Reset, want one")
+			}
+		})
+	}
+}
+
+func TestAfterFuncContext(t *testing.T) {
+	fc := NewFakeClock(fakeStart)
+	defer fc.Close()
+	started := make(chan struct{})
+	done := make(chan error)
+	ft := fc.AfterFuncContext(time.Second, func(ctx context.Context) {
+		close(started)
+		<-ctx.Done()
+		done <- ctx.Err()
+	})
+	fc.Advance(time.Second)
+	<-started
+	if ft.Stop() {
+		t.Errorf("Stop: got true for a running call, want false")
+	}
+	if err := <-done; err != context.Canceled {
+		t.Errorf("got context error %v, want %v", err, context.Canceled)
+	}
+
+	ft = fc.AfterFuncContext(time.Second, func(context.Context) {
+		t.Errorf("call was not prevented by Stop")
+	})
+	if !ft.Stop() {
+		t.Errorf("Stop: got false for a pending call, want true")
+	}
+	if err := ft.ctx.Err(); err != context.Canceled {
+		t.Errorf("got context error %v after Stop, want %v", err, context.Canceled)
+	}
+	fc.Advance(time.Second)
+}
+
+func TestTimerContext(t *testing.T) {
+	fc := NewFakeClock(fakeStart)
+	defer fc.Close()
+	timer := fc.NewTimer(time.Second)
+	ctx := timer.Context(context.Background())
+	timer.Reset(2 * time.Second)
+	fc.Advance(time.Second)
+	if err := ctx.Err(); err != nil {
+		t.Fatalf("got context error %v before the timer fired, want nil", err)
+	}
+	fc.Advance(time.Second)
+	<-ctx.Done()
+	if cause := context.Cause(ctx); cause != ErrTimerFired {
+		t.Errorf("got cause %v, want %v", cause, ErrTimerFired)
+	}
+	if !errors.Is(ErrTimerFired, context.DeadlineExceeded) {
+		t.Errorf("ErrTimerFired does not wrap context.DeadlineExceeded")
+	}
+
+	// Releasing the timer cancels the context, which it can no longer bound.
+	pooled := fc.AcquireTimer(time.Second)
+	ctx = pooled.Context(context.Background())
+	ReleaseTimer(pooled)
+	if cause := context.Cause(ctx); cause != ErrTimerReleased {
+		t.Errorf("got cause %v after ReleaseTimer, want %v", cause, ErrTimerReleased)
+	}
+
+	// Cancelling the parent cancels the context without the timer.
+	parent, cancel := context.WithCancel(context.Background())
+	ctx = timer.Context(parent)
+	cancel()
+	if cause := context.Cause(ctx); cause != context.Canceled {
+		t.Errorf("got cause %v after the parent was cancelled, want %v", cause, context.Canceled)
+	}
+}
+
+func TestContextWithTimeout(t *testing.T) {
+	fc := NewFakeClock(fakeStart)
+	defer fc.Close()
+	ctx, cancel := fc.ContextWithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if deadline, ok := ctx.Deadline(); !ok || !deadline.Equal(fakeStart.Add(time.Second)) {
+		t.Errorf("got deadline %v, %t, want %v, true", deadline, ok, fakeStart.Add(time.Second))
+	}
+	fc.Advance(time.Second - 1)
+	if err := ctx.Err(); err != nil {
+		t.Fatalf("got context error %v before the deadline, want nil", err)
+	}
+	fc.Advance(1)
+	<-ctx.Done()
+	if err := ctx.Err(); err != context.DeadlineExceeded {
+		t.Errorf("got context error %v, want %v", err, context.DeadlineExceeded)
+	}
+	if cause := context.Cause(ctx); cause != ErrTimerFired {
+		t.Errorf("got cause %v, want %v", cause, ErrTimerFired)
+	}
+
+	// Derived contexts report the timeout too.
+	ctx, cancel = fc.ContextWithTimeout(context.Background(), time.Second)
+	defer cancel()
+	child, cancelChild := context.WithCancel(ctx)
+	defer cancelChild()
+	fc.Advance(time.Second)
+	<-child.Done()
+	if err := child.Err(); err != context.DeadlineExceeded {
+		t.Errorf("got error %v from a derived context, want %v", err, context.DeadlineExceeded)
+	}
+	if cause := context.Cause(child); cause != ErrTimerFired {
+		t.Errorf("got cause %v from a derived context, want %v", cause, ErrTimerFired)
+	}
+
+	done, cancelDone := context.WithCancel(context.Background())
+	cancelDone()
+	for _, tc := range []struct {
+		desc   string
+		parent context.Context
+		d      time.Duration
+		want   error
+	}{
+		{"cancelled", context.Background(), time.Second, context.Canceled},
+		{"expired", context.Background(), 0, context.DeadlineExceeded},
+		{"parent done", done, time.Second, context.Canceled},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			ctx, cancel := fc.ContextWithTimeout(tc.parent, tc.d)
+			cancel()
+			if err := ctx.Err(); err != tc.want {
+				t.Errorf("got context error %v, want %v", err, tc.want)
+			}
+			// The timer is released once the context is done.
+			for fc.Pending() != 0 {
+				time.Sleep(time.Millisecond)
+			}
+		})
+	}
+}
@@ -0,0 +1,175 @@
+package cron
+
+import (
+	"sync"
+	"time"
+
+	"github.com/rhansen/go-kairos/kairos"
+)
+
+// A Calendar blocks the times at which jobs must not run, such as weekends, holidays, or
+// maintenance windows.
+type Calendar interface {
+	// Blocked reports whether t is blocked and, if so, returns the end of the blocked period: the
+	// first time after t that is not blocked, or the zero time if the block never ends.
+	Blocked(t time.Time) (end time.Time, blocked bool)
+}
+
+// CalendarFunc adapts a function to the [Calendar] interface.
+type CalendarFunc func(t time.Time) (end time.Time, blocked bool)
+
+// Blocked returns f(t).
+func (f CalendarFunc) Blocked(t time.Time) (time.Time, bool) { return f(t) }
+
+// A CalendarPolicy determines what happens to an activation that falls on a time blocked by a
+// [Calendar].
+type CalendarPolicy int
+
+const (
+	// SkipBlocked drops the activation: the job next runs at the first activation that is not
+	// blocked.
+	SkipBlocked CalendarPolicy = iota
+	// DeferBlocked moves the activation to the end of the blocked period.  The activations that
+	// fall within the same blocked period are merged into that single run.
+	DeferBlocked
+)
+
+// OnCalendar returns a [Schedule] whose activations are those of sched, except that activations
+// blocked by cal are skipped or deferred according to policy.  Its Next method returns the zero
+// time if every activation within ten years is blocked.
+func OnCalendar(sched Schedule, cal Calendar, policy CalendarPolicy) Schedule {
+	return &calendarSchedule{sched: sched, cal: cal, policy: policy}
+}
+
+type calendarSchedule struct {
+	sched  Schedule
+	cal    Calendar
+	policy CalendarPolicy
+}
+
+func (s *calendarSchedule) Next(t time.Time) time.Time {
+	limit := t.AddDate(10, 0, 0)
+	for {
+		next := s.sched.Next(t)
+		if next.IsZero() || next.After(limit) {
+			return time.Time{}
+		}
+		end, blocked := s.cal.Blocked(next)
+		switch {
+		case !blocked:
+			return next
+		case end.IsZero() || s.policy == DeferBlocked:
+			return end
+		}
+		// Skip every activation before the end of the blocked period at once.
+		t = end.Add(-time.Nanosecond)
+		if !t.After(next) {
+			t = next
+		}
+	}
+}
+
+// Calendars returns a [Calendar] that blocks the times blocked by any of cals.
+func Calendars(cals ...Calendar) Calendar {
+	return calendars(cals)
+}
+
+type calendars []Calendar
+
+func (cs calendars) Blocked(t time.Time) (time.Time, bool) {
+	end, blocked := t, false
+	// The end of one block can be the start of another, so repeat until no calendar blocks it.
+	for changed := true; changed; {
+		changed = false
+		for _, c := range cs {
+			e, ok := c.Blocked(end)
+			switch {
+			case ok && e.IsZero():
+				return e, true
+			case ok && e.After(end):
+				end, blocked, changed = e, true, true
+			}
+		}
+	}
+	if !blocked {
+		return time.Time{}, false
+	}
+	return end, true
+}
+
+// A Blackout is a [Calendar] that blocks the times from Start (inclusive) to End (exclusive).
+type Blackout struct {
+	Start, End time.Time
+}
+
+// Blocked reports whether t is within the blackout, which ends at End.
+func (b Blackout) Blocked(t time.Time) (time.Time, bool) {
+	if t.Before(b.Start) || !t.Before(b.End) {
+		return time.Time{}, false
+	}
+	return b.End, true
+}
+
+// A BusinessCalendar is a [Calendar] that blocks weekends and holidays, whole days in its time
+// zone.  A BusinessCalendar is safe for concurrent use, so holidays can be added while a scheduler
+// uses it.
+type BusinessCalendar struct {
+	loc     *time.Location
+	weekend [7]bool
+
+	mu       sync.RWMutex // protects:
+	holidays map[date]bool
+}
+
+// NewBusinessCalendar returns a [BusinessCalendar] for the time zone loc, or [time.Local] if nil,
+// whose weekend days are weekend, or Saturday and Sunday if none are given.
+func NewBusinessCalendar(loc *time.Location, weekend ...time.Weekday) *BusinessCalendar {
+	if loc == nil {
+		loc = time.Local
+	}
+	if len(weekend) == 0 {
+		weekend = []time.Weekday{time.Saturday, time.Sunday}
+	}
+	c := &BusinessCalendar{loc: loc, holidays: make(map[date]bool)}
+	for _, wd := range weekend {
+		c.weekend[wd] = true
+	}
+	return c
+}
+
+// AddHolidays adds holidays to the calendar.  The date of each holiday is its year, month, and
+// day in its own location, for example time.Date(2023, time.December, 25, 0, 0, 0, 0, time.UTC)
+// for Christmas 2023.
+func (c *BusinessCalendar) AddHolidays(days ...time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, d := range days {
+		c.holidays[dateOf(d)] = true
+	}
+}
+
+// IsBusinessDay reports whether the day of t, in the calendar's time zone, is neither a weekend
+// day nor a holiday.
+func (c *BusinessCalendar) IsBusinessDay(t time.Time) bool {
+	t = t.In(c.loc)
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return !c.weekend[t.Weekday()] && !c.holidays[dateOf(t)]
+}
+
+// Blocked reports whether t falls on a day that is not a business day, which is blocked until the
+// start of the next business day.  The block never ends if there is no business day within ten
+// years.
+func (c *BusinessCalendar) Blocked(t time.Time) (time.Time, bool) {
+	if c.IsBusinessDay(t) {
+		return time.Time{}, false
+	}
+	midnight := kairos.WallTime{Location: c.loc, Nonexistent: kairos.TransitionNonexistent}
+	end := t
+	for i := 0; i < 3660; i++ {
+		if end = midnight.Next(end); c.IsBusinessDay(end) {
+			return end, true
+		}
+	}
+	return time.Time{}, true
+}
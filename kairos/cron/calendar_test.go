@@ -0,0 +1,111 @@
+package cron
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOnCalendar(t *testing.T) {
+	business := NewBusinessCalendar(time.UTC)
+	// Friday 2023-05-19 and Monday 2023-05-29 are holidays.
+	business.AddHolidays(
+		time.Date(2023, 5, 19, 0, 0, 0, 0, time.UTC),
+		time.Date(2023, 5, 29, 0, 0, 0, 0, time.UTC),
+	)
+	maintenance := Blackout{
+		Start: time.Date(2023, 5, 17, 2, 0, 0, 0, time.UTC),
+		End:   time.Date(2023, 5, 17, 4, 0, 0, 0, time.UTC),
+	}
+	daily, _ := ParseInLocation("0 8 * * *", time.UTC)
+	hourly, _ := ParseInLocation("30 * * * *", time.UTC)
+	weekends, _ := ParseInLocation("0 8 * * sat,sun", time.UTC)
+	for _, tc := range []struct {
+		name   string
+		sched  Schedule
+		cal    Calendar
+		policy CalendarPolicy
+		from   string
+		want   []string
+	}{
+		// 2023-05-17 is a Wednesday.
+		{"business days", daily, business, SkipBlocked, "2023-05-17T12:00:00Z", []string{"2023-05-18T08:00:00Z", "2023-05-22T08:00:00Z", "2023-05-23T08:00:00Z"}},
+		{"business days deferred", daily, business, DeferBlocked, "2023-05-18T12:00:00Z", []string{"2023-05-22T00:00:00Z", "2023-05-22T08:00:00Z"}},
+		{"holiday on monday", daily, business, SkipBlocked, "2023-05-26T12:00:00Z", []string{"2023-05-30T08:00:00Z"}},
+		{"blackout skipped", hourly, maintenance, SkipBlocked, "2023-05-17T01:00:00Z", []string{"2023-05-17T01:30:00Z", "2023-05-17T04:30:00Z"}},
+		{"blackout deferred", hourly, maintenance, DeferBlocked, "2023-05-17T01:00:00Z", []string{"2023-05-17T01:30:00Z", "2023-05-17T04:00:00Z", "2023-05-17T04:30:00Z"}},
+		{"combined", hourly, Calendars(business, maintenance), DeferBlocked, "2023-05-17T01:00:00Z", []string{"2023-05-17T01:30:00Z", "2023-05-17T04:00:00Z"}},
+		{"combined holiday", daily, Calendars(maintenance, business), DeferBlocked, "2023-05-18T12:00:00Z", []string{"2023-05-22T00:00:00Z"}},
+		{"never", weekends, business, SkipBlocked, "2023-05-17T12:00:00Z", []string{"0001-01-01T00:00:00Z"}},
+		{"func", daily, CalendarFunc(func(t time.Time) (time.Time, bool) {
+			return t.Truncate(24 * time.Hour).Add(24 * time.Hour), t.Day()%2 == 0
+		}), SkipBlocked, "2023-05-17T12:00:00Z", []string{"2023-05-19T08:00:00Z", "2023-05-21T08:00:00Z"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			sched := OnCalendar(tc.sched, tc.cal, tc.policy)
+			from, _ := time.Parse(time.RFC3339, tc.from)
+			var got []string
+			for next := sched.Next(from); len(got) < len(tc.want); next = sched.Next(next) {
+				got = append(got, next.UTC().Format(time.RFC3339))
+				if next.IsZero() {
+					break
+				}
+			}
+			if strings.Join(got, " ") != strings.Join(tc.want, " ") {
+				t.Errorf("got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestBusinessCalendar(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("time zone database unavailable: %v", err)
+	}
+	// A Friday and Saturday weekend.
+	c := NewBusinessCalendar(berlin, time.Friday, time.Saturday)
+	for _, tc := range []struct {
+		t    string
+		want bool
+	}{
+		{"2023-05-18T12:00:00Z", true},
+		// Friday 00:30 in Berlin.
+		{"2023-05-18T22:30:00Z", false},
+		{"2023-05-20T12:00:00Z", false},
+		{"2023-05-21T12:00:00Z", true},
+	} {
+		tm, _ := time.Parse(time.RFC3339, tc.t)
+		if got := c.IsBusinessDay(tm); got != tc.want {
+			t.Errorf("IsBusinessDay(%s): got %v, want %v", tc.t, got, tc.want)
+		}
+	}
+	// The weekend ends at midnight in Berlin.
+	tm := time.Date(2023, 5, 19, 12, 0, 0, 0, berlin)
+	want := time.Date(2023, 5, 21, 0, 0, 0, 0, berlin)
+	if end, blocked := c.Blocked(tm); !blocked || !end.Equal(want) {
+		t.Errorf("Blocked(%v): got %v, %v, want %v, true", tm, end, blocked, want)
+	}
+}
+
+func TestSchedulerWithCalendar(t *testing.T) {
+	fc := newFake(t)
+	cal := NewBusinessCalendar(time.UTC)
+	s := New(fc, WithLocation(time.UTC), WithCalendar(cal, SkipBlocked))
+	t.Cleanup(func() { s.Close() })
+	var got []string
+	if _, err := s.Add("0 8 * * *", func(context.Context) error {
+		got = append(got, fc.Now().Weekday().String())
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 7; i++ {
+		fc.Advance(24 * time.Hour)
+	}
+	if want := "[Thursday Friday Monday Tuesday Wednesday]"; fmt.Sprint(got) != want {
+		t.Errorf("got runs on %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,652 @@
+// Package cron runs jobs on schedules given by cron expressions or iCalendar recurrence rules.  The
+// scheduler arms its timers on a [kairos.Clock], so schedules can be tested with a
+// [kairos.FakeClock] instead of waiting for real time to pass.
+package cron
+
+import (
+	"context"
+	"errors"
+	"runtime/debug"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/rhansen/go-kairos/kairos"
+)
+
+// A Job is the work run by a [Scheduler].  The context is canceled when the scheduler is closed.
+type Job func(ctx context.Context) error
+
+// An ID identifies a job registered with a [Scheduler].
+type ID string
+
+var (
+	// ErrClosed is returned when using a [Scheduler] that is closed.
+	ErrClosed = errors.New("cron: scheduler closed")
+	// ErrJobExists is returned when adding a job with the ID of a registered job.
+	ErrJobExists = errors.New("cron: job already exists")
+	// ErrJobNotFound is returned when referring to a job that is not registered.
+	ErrJobNotFound = errors.New("cron: job not found")
+)
+
+// An Option configures a [Scheduler].
+type Option func(*config)
+
+type config struct {
+	loc       *time.Location
+	cal       Calendar
+	calPolicy CalendarPolicy
+	store     Store
+	changes   <-chan kairos.ClockChange
+	maxRuns   int
+	hooks     Hooks
+	budget    time.Duration
+	overrun   func(info RunInfo) // If non-nil, called for the runs longer than budget.
+}
+
+// WithLocation sets the time zone in which the scheduler interprets cron expressions that do not
+// name one.  The default is [time.Local].
+func WithLocation(loc *time.Location) Option {
+	return func(cfg *config) { cfg.loc = loc }
+}
+
+// WithCalendar makes the scheduler skip or defer, according to policy, the activations of every
+// job that are blocked by cal.  See [OnCalendar].
+func WithCalendar(cal Calendar, policy CalendarPolicy) Option {
+	return func(cfg *config) { cfg.cal, cfg.calPolicy = cal, policy }
+}
+
+// WithStore makes the scheduler persist the state of its jobs in st.  When a job is added with the
+// ID of a saved job, it takes over the saved state: it stays paused if it was paused, and the runs
+// that fell due while the process was not running are handled according to the job's
+// [MissedRunPolicy].  Jobs should be added with [Scheduler.AddJob], whose IDs are stable across
+// restarts.
+//
+// The scheduler saves the state of a job each time the job is added, runs, is paused or resumed,
+// or is removed, while holding the lock that serializes all its operations.  A slow Store therefore
+// delays the runs of every job: a [FileStore] rewrites and syncs its file on each save.
+func WithStore(st Store) Option {
+	return func(cfg *config) { cfg.store = st }
+}
+
+// WithClockChanges makes the scheduler re-arm the timers of all jobs for the wall-clock times of
+// their next runs whenever a change of the wall clock is received from changes, typically the C
+// channel of a [kairos.ClockWatcher] on the scheduler's clock.  The timers of a clock follow its
+// monotonic time, so without this option, a job scheduled for 03:00 runs late if the machine
+// sleeps through that time or the system clock is set back or forward.  Runs that fell due during
+// the change are handled according to the [MissedRunPolicy] of each job.
+func WithClockChanges(changes <-chan kairos.ClockChange) Option {
+	return func(cfg *config) { cfg.changes = changes }
+}
+
+// WithMaxConcurrentRuns limits the number of runs of all jobs that execute at once to n.  A run
+// that falls due while n runs are executing waits for one of them to return.  A non-positive n
+// means no limit, which is the default.
+func WithMaxConcurrentRuns(n int) Option {
+	return func(cfg *config) { cfg.maxRuns = n }
+}
+
+// A JobOption configures a job added to a [Scheduler].
+type JobOption func(*jobConfig)
+
+type jobConfig struct {
+	missed  MissedRunPolicy
+	maxRuns int
+	overlap OverlapPolicy
+}
+
+// An OverlapPolicy determines what a [Scheduler] does with a run of a job that falls due while the
+// job already has as many runs executing as allowed by [WithConcurrency].
+type OverlapPolicy int
+
+const (
+	// SkipOverlap drops the new run.
+	SkipOverlap OverlapPolicy = iota
+	// QueueOverlap queues the new run, which starts as soon as one of the executing runs returns.
+	// Queued runs start in turn, however many have accumulated.
+	QueueOverlap
+	// ReplaceOverlap cancels the context of the executing runs and starts the new run as soon as
+	// one of them returns.  The runs that were queued are dropped.
+	ReplaceOverlap
+)
+
+// WithConcurrency limits the number of runs of the job that execute at once to n, and sets what
+// the scheduler does with a run that falls due while n runs are executing.  By default, the runs of
+// a job are not limited, so the runs of a slow job pile up.  WithConcurrency panics if n is not
+// positive.
+func WithConcurrency(n int, p OverlapPolicy) JobOption {
+	if n <= 0 {
+		panic("cron: non-positive limit for WithConcurrency")
+	}
+	return func(cfg *jobConfig) { cfg.maxRuns, cfg.overlap = n, p }
+}
+
+// A MissedRunPolicy determines what a [Scheduler] does with the missed runs of a job.  A run is
+// missed if it fell due while the process was not running, as recorded by the scheduler's
+// [Store], or if the scheduler only got to it after the following activation had also fallen due,
+// for example because the process was suspended or a [kairos.FakeClock] without deterministic
+// dispatch was advanced past several activations at once.
+type MissedRunPolicy int
+
+const (
+	// CoalesceMissed runs the job once, right away, in place of all its missed runs.
+	CoalesceMissed MissedRunPolicy = iota
+	// RunAllMissed runs the job once for each missed run, one after the other, right away.
+	RunAllMissed
+	// SkipMissed drops the missed runs: the job next runs at its first activation after the
+	// current time.
+	SkipMissed
+)
+
+// WithMissedRuns sets what the scheduler does with the missed runs of the job.  The default is
+// [CoalesceMissed].
+func WithMissedRuns(p MissedRunPolicy) JobOption {
+	return func(cfg *jobConfig) { cfg.missed = p }
+}
+
+// A Scheduler runs jobs according to their schedules.  Each job runs in its own goroutine (the
+// AfterFunc callback goroutine of the clock), so a slow job does not delay other jobs.  Runs that
+// are missed are handled according to the [MissedRunPolicy] of each job, and the runs that execute
+// at once can be limited with [WithConcurrency] and [WithMaxConcurrentRuns].  Jobs can be added,
+// removed, paused, and resumed at any time.  A Scheduler is safe for concurrent use.
+type Scheduler struct {
+	clock   kairos.Clock
+	cfg     config
+	ctx     context.Context
+	cancel  context.CancelFunc
+	running sync.WaitGroup // Running jobs.
+	slots   chan struct{}  // Held by the executing runs, if their number is limited.
+
+	mu      sync.Mutex // protects:
+	entries []*entry   // In order of registration.
+	byID    map[ID]*entry
+	lastID  int // Last number used for a generated ID.
+	closed  bool
+	// States loaded from the store that no job has taken over yet, or nil if not loaded yet.
+	saved    map[ID]JobState
+	storeErr error // First error of the store, returned by Close.
+}
+
+// An entry is a job registered with a scheduler.  Its fields other than id, sched, job, and timer
+// are protected by Scheduler.mu.
+type entry struct {
+	id      ID
+	sched   Schedule
+	job     Job
+	cfg     jobConfig
+	timer   *kairos.Timer // Fires at next.
+	next    time.Time     // Next activation, or the zero time if none or paused.
+	gen     uint64        // Bumped each time timer is armed or disarmed.
+	armed   uint64        // Generation whose call of timer is awaited, or 0 if none.
+	stale   int           // Calls of timer still to come for earlier generations.
+	prev    time.Time     // Start of the last run, or the zero time if none.
+	paused  bool
+	removed bool
+	late    bool             // True if the run at next was missed while the process was down.
+	active  map[*jobRun]bool // Executing runs.
+	queue   []time.Time      // Activations of the runs waiting for an executing run to return.
+}
+
+// A jobRun is a run of a job.
+type jobRun struct {
+	ctx       context.Context
+	cancel    context.CancelFunc
+	scheduled time.Time
+}
+
+// JobInfo describes a job registered with a [Scheduler].
+type JobInfo struct {
+	ID       ID
+	Schedule Schedule
+	// Next is the time of the next run, or the zero time if the job is paused or its schedule has
+	// no further activations.
+	Next time.Time
+	// Prev is the time at which the last run started, or the zero time if the job has not run.
+	Prev   time.Time
+	Paused bool
+}
+
+// New returns a [Scheduler] that arms its timers on c.
+func New(c kairos.Clock, opts ...Option) *Scheduler {
+	cfg := config{loc: time.Local}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &Scheduler{clock: c, cfg: cfg, ctx: ctx, cancel: cancel, byID: make(map[ID]*entry)}
+	if cfg.maxRuns > 0 {
+		s.slots = make(chan struct{}, cfg.maxRuns)
+	}
+	if cfg.changes != nil {
+		go s.watchClock()
+	}
+	return s
+}
+
+// watchClock re-arms the timers of all jobs after each change of the wall clock, until the
+// scheduler is closed.
+func (s *Scheduler) watchClock() {
+	for {
+		select {
+		case <-s.cfg.changes:
+			s.rearm()
+		case <-s.ctx.Done():
+			return
+		}
+	}
+}
+
+// rearm re-arms the timer of each job for its next run, keeping the time of that run, and starts
+// the jobs whose runs are due.
+func (s *Scheduler) rearm() {
+	s.mu.Lock()
+	var due []*kairos.Timer
+	now := s.clock.Now()
+	for _, e := range s.entries {
+		switch d := e.next.Sub(now); {
+		case s.closed || e.next.IsZero():
+		case d > 0:
+			e.resetLocked(d)
+		default:
+			due = append(due, e.dueLocked())
+		}
+	}
+	s.mu.Unlock()
+	for _, t := range due {
+		startDue(t)
+	}
+}
+
+// Add parses spec as described for [ParseInLocation], in the scheduler's time zone, and schedules
+// job accordingly.  It returns the ID generated for the job.
+func (s *Scheduler) Add(spec string, job Job, opts ...JobOption) (ID, error) {
+	sched, err := ParseInLocation(spec, s.cfg.loc)
+	if err != nil {
+		return "", err
+	}
+	return s.AddSchedule(sched, job, opts...)
+}
+
+// AddSchedule schedules job to run at each activation of sched.  It returns the ID generated for
+// the job, or [ErrClosed] if the scheduler is closed.
+func (s *Scheduler) AddSchedule(sched Schedule, job Job, opts ...JobOption) (ID, error) {
+	s.mu.Lock()
+	var id ID
+	for {
+		s.lastID++
+		if id = ID(strconv.Itoa(s.lastID)); s.byID[id] == nil {
+			break
+		}
+	}
+	due, err := s.addLocked(id, sched, job, opts)
+	s.mu.Unlock()
+	startDue(due)
+	return id, err
+}
+
+// AddJob schedules job to run at each activation of sched under the given ID, which stays the
+// same across restarts of the process, unlike the IDs generated by [Scheduler.Add].  It returns
+// [ErrJobExists] if a job with that ID is registered, or [ErrClosed] if the scheduler is closed.
+func (s *Scheduler) AddJob(id ID, sched Schedule, job Job, opts ...JobOption) error {
+	s.mu.Lock()
+	due, err := s.addLocked(id, sched, job, opts)
+	s.mu.Unlock()
+	startDue(due)
+	return err
+}
+
+// addLocked registers and arms a job.  If the job must run right away, it returns its timer, to be
+// started by startDue once s.mu is released: a [kairos.FakeClock] in deterministic dispatch mode
+// runs the callback of a timer that is due as soon as it is started.  s.mu must be held.
+func (s *Scheduler) addLocked(id ID, sched Schedule, job Job, opts []JobOption) (due *kairos.Timer, err error) {
+	if s.closed {
+		return nil, ErrClosed
+	}
+	if s.byID[id] != nil {
+		return nil, ErrJobExists
+	}
+	if s.cfg.store != nil && s.saved == nil {
+		states, err := s.cfg.store.Load()
+		if err != nil {
+			return nil, err
+		}
+		s.saved = make(map[ID]JobState, len(states))
+		for _, st := range states {
+			s.saved[st.ID] = st
+		}
+	}
+	if s.cfg.cal != nil {
+		sched = OnCalendar(sched, s.cfg.cal, s.cfg.calPolicy)
+	}
+	e := &entry{id: id, sched: sched, job: job, active: make(map[*jobRun]bool)}
+	for _, opt := range opts {
+		opt(&e.cfg)
+	}
+	e.timer = new(kairos.Timer)
+	s.clock.InitTimer(e.timer, func() { s.run(e) })
+	s.entries = append(s.entries, e)
+	s.byID[id] = e
+	st, ok := s.saved[id]
+	delete(s.saved, id)
+	e.prev, e.paused = st.Prev, st.Paused
+	switch {
+	case e.paused:
+		s.saveLocked(e)
+	case ok && !st.Next.IsZero() && !st.Next.After(s.clock.Now()):
+		// The run missed while the process was not running.
+		e.next, e.late = st.Next, true
+		s.saveLocked(e)
+		return e.dueLocked(), nil
+	default:
+		s.armLocked(e)
+	}
+	return nil, nil
+}
+
+// startDue starts the timer returned by addLocked, if any.
+func startDue(due *kairos.Timer) {
+	if due != nil {
+		due.Reset(0)
+	}
+}
+
+// RemoveJob unregisters the job with the given ID.  A run of the job that has already started is
+// not interrupted.  RemoveJob returns [ErrJobNotFound] if there is no such job.
+func (s *Scheduler) RemoveJob(id ID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e := s.byID[id]
+	if e == nil {
+		return ErrJobNotFound
+	}
+	e.removed = true
+	e.queue = nil
+	e.stopLocked()
+	delete(s.byID, id)
+	if s.cfg.store != nil {
+		s.storeErrLocked(s.cfg.store.Delete(id))
+	}
+	for i, other := range s.entries {
+		if other == e {
+			s.entries = append(s.entries[:i], s.entries[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+// PauseJob stops running the job with the given ID until [Scheduler.ResumeJob] is called.  A run
+// of the job that has already started is not interrupted.  PauseJob returns [ErrJobNotFound] if
+// there is no such job.
+func (s *Scheduler) PauseJob(id ID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e := s.byID[id]
+	if e == nil {
+		return ErrJobNotFound
+	}
+	e.paused = true
+	e.queue = nil
+	e.next = time.Time{}
+	e.stopLocked()
+	s.saveLocked(e)
+	return nil
+}
+
+// ResumeJob resumes running the job with the given ID, at the first activation of its schedule
+// after the current time.  Activations that fell while the job was paused are skipped.  ResumeJob
+// returns [ErrJobNotFound] if there is no such job.
+func (s *Scheduler) ResumeJob(id ID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e := s.byID[id]
+	if e == nil {
+		return ErrJobNotFound
+	}
+	if e.paused && !s.closed {
+		e.paused = false
+		s.armLocked(e)
+	}
+	return nil
+}
+
+// ListJobs returns the jobs registered with the scheduler, in the order in which they were added.
+func (s *Scheduler) ListJobs() []JobInfo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	infos := make([]JobInfo, len(s.entries))
+	for i, e := range s.entries {
+		infos[i] = e.infoLocked()
+	}
+	return infos
+}
+
+// Job returns a description of the job with the given ID, or [ErrJobNotFound] if there is no such
+// job.
+func (s *Scheduler) Job(id ID) (JobInfo, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e := s.byID[id]
+	if e == nil {
+		return JobInfo{}, ErrJobNotFound
+	}
+	return e.infoLocked(), nil
+}
+
+// NextRun returns the time of the next run of the job with the given ID, or the zero time if the
+// job is paused or has no further runs.  It returns [ErrJobNotFound] if there is no such job.
+func (s *Scheduler) NextRun(id ID) (time.Time, error) {
+	info, err := s.Job(id)
+	return info.Next, err
+}
+
+func (e *entry) infoLocked() JobInfo {
+	return JobInfo{ID: e.id, Schedule: e.sched, Next: e.next, Prev: e.prev, Paused: e.paused}
+}
+
+// armLocked arms the timer of e for its next activation.  s.mu must be held.
+func (s *Scheduler) armLocked(e *entry) {
+	now := s.clock.Now()
+	e.next = e.sched.Next(now)
+	s.saveLocked(e)
+	if e.next.IsZero() {
+		e.stopLocked()
+		return
+	}
+	e.resetLocked(e.next.Sub(now))
+}
+
+// resetLocked arms the timer of e to fire in d, for a new generation.  s.mu must be held.
+func (e *entry) resetLocked(d time.Duration) {
+	e.bumpLocked(e.timer.Reset(d))
+	e.armed = e.gen
+}
+
+// dueLocked stops the timer of e and returns it, to be started by startDue once s.mu is released,
+// for a new generation.  s.mu must be held.
+func (e *entry) dueLocked() *kairos.Timer {
+	e.stopLocked()
+	e.armed = e.gen
+	return e.timer
+}
+
+// stopLocked stops the timer of e, starting a new generation.  s.mu must be held.
+func (e *entry) stopLocked() {
+	e.bumpLocked(e.timer.Stop())
+}
+
+// bumpLocked starts a new generation of e, once its timer was stopped or reset, which returned
+// wasActive.  If the timer had already fired for the awaited generation, its call is still to
+// come, and run ignores it.  s.mu must be held.
+func (e *entry) bumpLocked(wasActive bool) {
+	if e.armed != 0 && !wasActive {
+		e.stale++
+	}
+	e.gen++
+	e.armed = 0
+}
+
+// saveLocked saves the state of e in the store, if any.  s.mu must be held.
+func (s *Scheduler) saveLocked(e *entry) {
+	if s.cfg.store != nil {
+		st := JobState{ID: e.id, Prev: e.prev, Next: e.next, Paused: e.paused}
+		s.storeErrLocked(s.cfg.store.Save(st))
+	}
+}
+
+// storeErrLocked records err if it is the first error of the store.  s.mu must be held.
+func (s *Scheduler) storeErrLocked(err error) {
+	if s.storeErr == nil {
+		s.storeErr = err
+	}
+}
+
+// run is called by the timer of e.
+func (s *Scheduler) run(e *entry) {
+	s.mu.Lock()
+	// A call made stale by a concurrent PauseJob, ResumeJob, RemoveJob, or re-arming is ignored.
+	if e.stale > 0 {
+		e.stale--
+		s.mu.Unlock()
+		return
+	}
+	if s.closed || e.armed == 0 {
+		s.mu.Unlock()
+		return
+	}
+	e.armed = 0
+	now := s.clock.Now()
+	if now.Before(e.next) {
+		// The timer fired on the monotonic clock, but the wall clock was stepped back since it was
+		// armed.  Wait until the wall clock reaches the activation.
+		e.resetLocked(e.next.Sub(now))
+		s.mu.Unlock()
+		return
+	}
+	scheduled := e.next
+	// The run is missed if the following activation has also fallen due.
+	following := e.sched.Next(e.next)
+	behind := !following.IsZero() && !following.After(now)
+	missed := e.late || behind
+	e.late = false
+	skip := RunInfo{ID: e.id, Scheduled: scheduled}
+	var r *jobRun
+	switch {
+	case missed && e.cfg.missed == SkipMissed:
+		skip.Err = ErrMissed
+	case e.cfg.maxRuns <= 0 || len(e.active) < e.cfg.maxRuns:
+		r = s.startLocked(e, now, scheduled)
+	case e.cfg.overlap == QueueOverlap:
+		e.queue = append(e.queue, scheduled)
+	case e.cfg.overlap == ReplaceOverlap:
+		for other := range e.active {
+			other.cancel()
+		}
+		e.queue = []time.Time{scheduled}
+	default:
+		skip.Err = ErrOverlap
+	}
+	var due *kairos.Timer
+	if behind && e.cfg.missed == RunAllMissed {
+		// Start the following run as soon as this one returns.
+		e.next = following
+		s.saveLocked(e)
+		due = e.dueLocked()
+	} else {
+		s.armLocked(e)
+	}
+	s.mu.Unlock()
+	if skip.Err != nil {
+		call(s.cfg.hooks.OnSkip, skip)
+	}
+	if r != nil {
+		s.execute(e, r)
+	}
+	startDue(due)
+}
+
+// startLocked registers a new run of e for the activation scheduled, starting at now.  The caller
+// must pass it to execute.  s.mu must be held.
+func (s *Scheduler) startLocked(e *entry, now, scheduled time.Time) *jobRun {
+	s.running.Add(1)
+	r := &jobRun{scheduled: scheduled}
+	r.ctx, r.cancel = context.WithCancel(s.ctx)
+	e.active[r] = true
+	e.prev = now
+	return r
+}
+
+// execute executes the run r of e, and then the runs of e queued in the meantime, if any.
+func (s *Scheduler) execute(e *entry, r *jobRun) {
+	for r != nil {
+		info := RunInfo{ID: e.id, Scheduled: r.scheduled}
+		if s.slots != nil {
+			select {
+			case s.slots <- struct{}{}:
+				s.runJob(e, r, &info)
+				<-s.slots
+			case <-r.ctx.Done():
+				info.Err = r.ctx.Err()
+				call(s.cfg.hooks.OnSkip, info)
+			}
+		} else {
+			s.runJob(e, r, &info)
+		}
+		r.cancel()
+		s.mu.Lock()
+		delete(e.active, r)
+		s.running.Done()
+		r = nil
+		if len(e.queue) > 0 && !s.closed && !e.removed {
+			r = s.startLocked(e, s.clock.Now(), e.queue[0])
+			e.queue = e.queue[1:]
+			s.saveLocked(e)
+		}
+		s.mu.Unlock()
+	}
+}
+
+// runJob runs the job of e for the run r, recovering from a panic, and calls the hooks of the run
+// with info.
+func (s *Scheduler) runJob(e *entry, r *jobRun, info *RunInfo) {
+	hooks := &s.cfg.hooks
+	info.Start = s.clock.Now()
+	call(hooks.OnStart, *info)
+	defer func() {
+		if v := recover(); v != nil {
+			info.Err = &PanicError{Value: v, Stack: debug.Stack()}
+		}
+		info.Duration = s.clock.Since(info.Start)
+		if info.Err != nil {
+			call(hooks.OnFailure, *info)
+		} else {
+			call(hooks.OnSuccess, *info)
+		}
+		if s.cfg.overrun != nil && info.Duration > s.cfg.budget {
+			s.cfg.overrun(*info)
+		}
+	}()
+	info.Err = e.job(r.ctx)
+}
+
+// Close stops the scheduler: no job runs after Close returns.  It cancels the context of the jobs
+// that are running and waits for them to return.  Close returns [ErrClosed] if the scheduler was
+// already closed, and otherwise the first error of the scheduler's [Store] that occurred while
+// saving state in the background, if any.
+func (s *Scheduler) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return ErrClosed
+	}
+	s.closed = true
+	for _, e := range s.entries {
+		e.timer.Stop()
+	}
+	s.mu.Unlock()
+	s.cancel()
+	s.running.Wait()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.storeErr
+}
@@ -0,0 +1,465 @@
+package cron
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rhansen/go-kairos/kairos"
+)
+
+var start = time.Date(2023, 5, 17, 12, 0, 0, 0, time.UTC)
+
+// Wall clock times can be scheduled directly.
+var _ Schedule = kairos.WallTime{}
+
+// newFake returns a fake clock whose AfterFunc callbacks, and therefore jobs, run synchronously
+// during Advance.
+func newFake(t *testing.T) *kairos.FakeClock {
+	fc := kairos.NewFakeClock(start, kairos.WithDeterministicDispatch())
+	t.Cleanup(func() { fc.Close() })
+	return fc
+}
+
+func TestScheduler(t *testing.T) {
+	fc := newFake(t)
+	s := New(fc, WithLocation(time.UTC))
+	t.Cleanup(func() { s.Close() })
+	var got []string
+	record := func(name string) Job {
+		return func(context.Context) error {
+			got = append(got, fmt.Sprintf("%s@%s", name, fc.Since(start)))
+			return nil
+		}
+	}
+	if _, err := s.Add("*/20 * * * * *", record("a")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Add("1 * * * *", record("b")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Add("bogus", record("c")); err == nil {
+		t.Errorf("Add of a bad expression: got nil error")
+	}
+	for i := 0; i < 12; i++ {
+		fc.Advance(10 * time.Second)
+	}
+	if want := "[a@20s a@40s b@1m0s a@1m0s a@1m20s a@1m40s a@2m0s]"; fmt.Sprint(got) != want {
+		t.Errorf("got runs %v, want %v", got, want)
+	}
+
+	if err := s.Close(); err != nil {
+		t.Errorf("Close: got error %v", err)
+	}
+	if err := s.Close(); err != ErrClosed {
+		t.Errorf("second Close: got error %v, want %v", err, ErrClosed)
+	}
+	if _, err := s.Add("* * * * *", record("d")); err != ErrClosed {
+		t.Errorf("Add after Close: got error %v, want %v", err, ErrClosed)
+	}
+	got = nil
+	fc.Advance(time.Hour)
+	if len(got) != 0 {
+		t.Errorf("jobs ran after Close: %v", got)
+	}
+	if n := fc.Pending(); n != 0 {
+		t.Errorf("got %d timers armed after Close, want 0", n)
+	}
+}
+
+func TestSchedulerAddStats(t *testing.T) {
+	fc := newFake(t)
+	s := New(fc, WithLocation(time.UTC))
+	t.Cleanup(func() { s.Close() })
+	if _, err := s.Add("* * * * *", func(context.Context) error { return nil }); err != nil {
+		t.Fatal(err)
+	}
+	// Adding a job arms its timer once, without stopping it.
+	if got, want := fc.Stats(), (kairos.Stats{Created: 1, Reset: 1, PeakPending: 1}); got != want {
+		t.Errorf("got stats %+v, want %+v", got, want)
+	}
+}
+
+func TestSchedulerCloseCancelsJobs(t *testing.T) {
+	c := kairos.NewClock()
+	t.Cleanup(func() { c.Close() })
+	s := New(c)
+	started := make(chan struct{})
+	s.AddSchedule(Every(time.Millisecond), func(ctx context.Context) error {
+		select {
+		case started <- struct{}{}:
+		default:
+		}
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	<-started
+	done := make(chan struct{})
+	go func() {
+		s.Close()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("Close did not return after canceling the running job")
+	}
+}
+
+func TestSchedulerJobManagement(t *testing.T) {
+	fc := newFake(t)
+	s := New(fc, WithLocation(time.UTC))
+	t.Cleanup(func() { s.Close() })
+	runs := make(map[ID]int)
+	count := func(id ID) Job {
+		return func(context.Context) error {
+			runs[id]++
+			return nil
+		}
+	}
+	if err := s.AddJob("report", Every(time.Hour), count("report")); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.AddJob("report", Every(time.Hour), count("report")); err != ErrJobExists {
+		t.Errorf("AddJob of a duplicate ID: got error %v, want %v", err, ErrJobExists)
+	}
+	id, err := s.Add("*/10 * * * *", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.RemoveJob(id); err != nil {
+		t.Errorf("RemoveJob(%q): got error %v", id, err)
+	}
+	if id, err = s.Add("*/10 * * * *", count("tick")); err != nil {
+		t.Fatal(err)
+	}
+	var ids []ID
+	for _, info := range s.ListJobs() {
+		ids = append(ids, info.ID)
+	}
+	if got, want := fmt.Sprint(ids), fmt.Sprintf("[report %s]", id); got != want {
+		t.Errorf("got jobs %v, want %v", got, want)
+	}
+	if next, err := s.NextRun("report"); err != nil || !next.Equal(start.Add(time.Hour)) {
+		t.Errorf("NextRun: got %v, %v, want %v, nil", next, err, start.Add(time.Hour))
+	}
+
+	fc.Advance(time.Hour)
+	if err := s.PauseJob("report"); err != nil {
+		t.Fatal(err)
+	}
+	info, err := s.Job("report")
+	if err != nil || !info.Paused || !info.Next.IsZero() || !info.Prev.Equal(start.Add(time.Hour)) {
+		t.Errorf("Job of a paused job: got %+v, %v", info, err)
+	}
+	fc.Advance(150 * time.Minute)
+	if err := s.ResumeJob("report"); err != nil {
+		t.Fatal(err)
+	}
+	if next, _ := s.NextRun("report"); !next.Equal(start.Add(4 * time.Hour)) {
+		t.Errorf("NextRun after ResumeJob: got %v, want %v", next, start.Add(4*time.Hour))
+	}
+	fc.Advance(30 * time.Minute)
+	if got, want := fmt.Sprint(runs), "map[report:2 tick:24]"; got != want {
+		t.Errorf("got runs %v, want %v", got, want)
+	}
+
+	if err := s.RemoveJob(id); err != nil {
+		t.Fatal(err)
+	}
+	fc.Advance(time.Hour)
+	if got := runs["tick"]; got != 24 {
+		t.Errorf("removed job ran: got %d runs, want 24", got)
+	}
+	for _, f := range []func(ID) error{s.RemoveJob, s.PauseJob, s.ResumeJob} {
+		if err := f(id); err != ErrJobNotFound {
+			t.Errorf("got error %v for a removed job, want %v", err, ErrJobNotFound)
+		}
+	}
+	if _, err := s.NextRun(id); err != ErrJobNotFound {
+		t.Errorf("NextRun of a removed job: got error %v, want %v", err, ErrJobNotFound)
+	}
+}
+
+func TestSchedulerMissedRuns(t *testing.T) {
+	for _, tc := range []struct {
+		policy MissedRunPolicy
+		runs   int // Runs after advancing past the activations at 13:00, 14:00, and 15:00.
+	}{
+		{CoalesceMissed, 1},
+		{RunAllMissed, 3},
+		{SkipMissed, 0},
+	} {
+		// Without deterministic dispatch, the callback of the timer reads the clock after the
+		// advance, so the scheduler only gets to the run of 13:00 at 15:30.
+		fc := kairos.NewFakeClock(start)
+		s := New(fc)
+		var mu sync.Mutex
+		runs := 0
+		id, _ := s.AddSchedule(Every(time.Hour), func(context.Context) error {
+			mu.Lock()
+			defer mu.Unlock()
+			runs++
+			return nil
+		}, WithMissedRuns(tc.policy))
+		// wait waits until the job has run n times in all and is armed for next.
+		wait := func(n int, next time.Time) {
+			deadline := time.Now().Add(time.Second)
+			for {
+				got, _ := s.NextRun(id)
+				mu.Lock()
+				r := runs
+				mu.Unlock()
+				if r == n && got.Equal(next) {
+					return
+				}
+				if time.Now().After(deadline) {
+					t.Fatalf("policy %d: got %d runs and next run at %v, want %d and %v", tc.policy, r, got, n, next)
+				}
+				time.Sleep(time.Millisecond)
+			}
+		}
+		fc.Advance(3*time.Hour + 30*time.Minute)
+		wait(tc.runs, start.Add(4*time.Hour))
+		// The run of 16:00 is on time.
+		fc.Advance(30 * time.Minute)
+		wait(tc.runs+1, start.Add(5*time.Hour))
+		s.Close()
+		fc.Close()
+	}
+}
+
+func TestSchedulerMissedRunsOnRestart(t *testing.T) {
+	daily, _ := ParseInLocation("0 8 * * *", time.UTC)
+	day := func(d, h int) time.Time { return time.Date(2023, 5, d, h, 0, 0, 0, time.UTC) }
+	for _, tc := range []struct {
+		policy MissedRunPolicy
+		want   []time.Time
+	}{
+		{CoalesceMissed, []time.Time{day(19, 12), day(20, 8)}},
+		{RunAllMissed, []time.Time{day(19, 12), day(19, 12), day(19, 12), day(20, 8)}},
+		{SkipMissed, []time.Time{day(20, 8)}},
+	} {
+		// The process was down at 08:00 on the 17th, 18th, and 19th.
+		store := NewFileStore(filepath.Join(t.TempDir(), "jobs.json"))
+		if err := store.Save(JobState{ID: "daily", Prev: day(16, 8), Next: day(17, 8)}); err != nil {
+			t.Fatal(err)
+		}
+		fc := kairos.NewFakeClock(day(19, 12), kairos.WithDeterministicDispatch())
+		s := New(fc, WithStore(store))
+		var runs []time.Time
+		if err := s.AddJob("daily", daily, func(context.Context) error {
+			runs = append(runs, fc.Now())
+			return nil
+		}, WithMissedRuns(tc.policy)); err != nil {
+			t.Fatal(err)
+		}
+		fc.Advance(24 * time.Hour)
+		s.Close()
+		fc.Close()
+		if !reflect.DeepEqual(runs, tc.want) {
+			t.Errorf("policy %d: got runs at %v, want %v", tc.policy, runs, tc.want)
+		}
+	}
+}
+
+// A steppedClock is a fake clock whose wall time can be stepped without moving its timers, like
+// the system clock after a suspend.
+type steppedClock struct {
+	*kairos.FakeClock
+	step atomic.Int64
+}
+
+func (c *steppedClock) Now() time.Time {
+	return c.FakeClock.Now().Add(time.Duration(c.step.Load()))
+}
+
+func TestSchedulerClockChanges(t *testing.T) {
+	fc := newFake(t)
+	c := &steppedClock{FakeClock: fc}
+	changes := make(chan kairos.ClockChange)
+	s := New(c, WithLocation(time.UTC), WithClockChanges(changes))
+	t.Cleanup(func() { s.Close() })
+	var mu sync.Mutex
+	var runs []time.Time
+	id, err := s.Add("0 3 * * *", func(context.Context) error {
+		mu.Lock()
+		defer mu.Unlock()
+		runs = append(runs, c.Now())
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The machine sleeps from 12:00 to 05:00 the next day, while its timers stand still.
+	fc.Advance(time.Minute)
+	c.step.Store(int64(17 * time.Hour))
+	changes <- kairos.ClockChange{Time: c.Now(), Step: 17 * time.Hour}
+	changes <- kairos.ClockChange{} // Waits for the first change to be handled.
+	mu.Lock()
+	got := fmt.Sprint(runs)
+	mu.Unlock()
+	if want := fmt.Sprint([]time.Time{start.Add(17*time.Hour + time.Minute)}); got != want {
+		t.Errorf("got runs at %v, want %v", got, want)
+	}
+	want := time.Date(2023, 5, 19, 3, 0, 0, 0, time.UTC)
+	if next, _ := s.NextRun(id); !next.Equal(want) {
+		t.Errorf("got next run at %v, want %v", next, want)
+	}
+	// The timer now expires at 03:00 on the wall clock.
+	if got := c.Now().Add(fc.PendingTimers()[0].When.Sub(fc.Now())); !got.Equal(want) {
+		t.Errorf("got timer expiring at %v, want %v", got, want)
+	}
+}
+
+// A stepBack steps an offset clock back by a second when its first timer fires, before the
+// callback of the timer runs, like an NTP correction just before the fire.
+type stepBack struct {
+	oc      *kairos.OffsetClock
+	stepped atomic.Bool
+	rearmed chan struct{}
+}
+
+func (*stepBack) TimerCreated()     {}
+func (*stepBack) TimerStopped(bool) {}
+func (o *stepBack) TimerReset(bool) {
+	if o.stepped.Load() {
+		select {
+		case o.rearmed <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (o *stepBack) TimerFired(time.Duration) {
+	if o.stepped.CompareAndSwap(false, true) {
+		o.oc.SetOffset(-time.Second)
+	}
+}
+
+func TestSchedulerClockStepsBack(t *testing.T) {
+	fc := kairos.NewFakeClock(start)
+	t.Cleanup(func() { fc.Close() })
+	obs := &stepBack{rearmed: make(chan struct{}, 1)}
+	oc := kairos.NewOffsetClock(fc, 0, kairos.WithObserver(obs))
+	obs.oc = oc
+	t.Cleanup(func() { oc.Close() })
+	s := New(oc, WithLocation(time.UTC))
+	t.Cleanup(func() { s.Close() })
+	ran := make(chan time.Time, 1)
+	if _, err := s.Add("* * * * *", func(context.Context) error {
+		ran <- oc.Now()
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	fc.BlockUntil(1)
+	fc.Advance(time.Minute)
+	select {
+	case <-obs.rearmed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the job was not re-armed after the clock stepped back")
+	}
+	fc.BlockUntil(1)
+	fc.Advance(time.Second)
+	select {
+	case got := <-ran:
+		if want := start.Add(time.Minute); !got.Equal(want) {
+			t.Errorf("got run at %v, want %v", got, want)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the job did not run")
+	}
+}
+
+func TestSchedulerOverlap(t *testing.T) {
+	for _, tc := range []struct {
+		policy   OverlapPolicy
+		runs     int  // Runs started after the activations at 13:00 and 14:00.
+		canceled bool // Whether the first run is canceled.
+	}{
+		{SkipOverlap, 1, false},
+		{QueueOverlap, 2, false},
+		{ReplaceOverlap, 2, true},
+	} {
+		fc := kairos.NewFakeClock(start)
+		s := New(fc)
+		started := make(chan int, 10)
+		canceled := make(chan int, 10)
+		release := make(chan struct{})
+		var n atomic.Int32
+		id, _ := s.AddSchedule(Every(time.Hour), func(ctx context.Context) error {
+			i := int(n.Add(1))
+			started <- i
+			select {
+			case <-release:
+			case <-ctx.Done():
+				canceled <- i
+			}
+			return nil
+		}, WithConcurrency(1, tc.policy))
+		fc.Advance(time.Hour)
+		<-started
+		fc.Advance(time.Hour)
+		// Wait for the scheduler to handle the run of 14:00.
+		for next, _ := s.NextRun(id); !next.Equal(start.Add(3 * time.Hour)); next, _ = s.NextRun(id) {
+			time.Sleep(time.Millisecond)
+		}
+		if tc.canceled {
+			if got := <-canceled; got != 1 {
+				t.Errorf("policy %d: got run %d canceled, want run 1", tc.policy, got)
+			}
+		} else {
+			release <- struct{}{}
+		}
+		if tc.runs > 1 {
+			<-started
+			release <- struct{}{}
+		}
+		select {
+		case i := <-started:
+			t.Errorf("policy %d: got unexpected run %d", tc.policy, i)
+		case <-time.After(20 * time.Millisecond):
+		}
+		if got := int(n.Load()); got != tc.runs {
+			t.Errorf("policy %d: got %d runs, want %d", tc.policy, got, tc.runs)
+		}
+		s.Close()
+		fc.Close()
+	}
+}
+
+func TestSchedulerMaxConcurrentRuns(t *testing.T) {
+	fc := kairos.NewFakeClock(start)
+	defer fc.Close()
+	s := New(fc, WithMaxConcurrentRuns(1))
+	started := make(chan ID, 2)
+	release := make(chan struct{})
+	job := func(id ID) Job {
+		return func(context.Context) error {
+			started <- id
+			<-release
+			return nil
+		}
+	}
+	s.AddJob("a", Every(time.Hour), job("a"))
+	s.AddJob("b", Every(time.Hour), job("b"))
+	fc.Advance(time.Hour)
+	<-started
+	select {
+	case id := <-started:
+		t.Errorf("job %s started while another run was executing", id)
+	case <-time.After(20 * time.Millisecond):
+	}
+	release <- struct{}{}
+	<-started
+	release <- struct{}{}
+	if err := s.Close(); err != nil {
+		t.Errorf("Close: got error %v", err)
+	}
+}
@@ -0,0 +1,82 @@
+package cron
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	// ErrMissed is the reason given to [Hooks.OnSkip] for a run dropped by [SkipMissed].
+	ErrMissed = errors.New("cron: run missed")
+	// ErrOverlap is the reason given to [Hooks.OnSkip] for a run dropped by [SkipOverlap].
+	ErrOverlap = errors.New("cron: previous run still executing")
+)
+
+// Hooks are functions that a [Scheduler] calls as the runs of its jobs progress, for example to
+// log them or record metrics.  Nil hooks are not called.  The hooks of a run are called in the
+// goroutine of the run, so they must return quickly, and may be called concurrently for different
+// runs.  They may call the methods of the scheduler, except Close.
+type Hooks struct {
+	// OnStart is called before a run of a job starts.
+	OnStart func(info RunInfo)
+	// OnSuccess is called after a run of a job returned a nil error.
+	OnSuccess func(info RunInfo)
+	// OnFailure is called after a run of a job returned an error or panicked.
+	OnFailure func(info RunInfo)
+	// OnSkip is called when a run of a job is dropped instead of started.
+	OnSkip func(info RunInfo)
+}
+
+// WithHooks makes the scheduler call h as the runs of its jobs progress.
+func WithHooks(h Hooks) Option {
+	return func(cfg *config) { cfg.hooks = h }
+}
+
+// WithRunBudget makes the scheduler call f, after the OnSuccess or OnFailure hook, with the
+// [RunInfo] of each run of a job that took longer than budget by the scheduler's clock.  A slow run
+// holds up the runs waiting for it under [WithConcurrency] or [WithMaxConcurrentRuns].  f is called
+// in the goroutine of the run, like the hooks.
+func WithRunBudget(budget time.Duration, f func(info RunInfo)) Option {
+	return func(cfg *config) { cfg.budget, cfg.overrun = max(budget, 0), f }
+}
+
+// A RunInfo describes a run of a job to [Hooks].
+type RunInfo struct {
+	ID ID
+	// Scheduled is the activation of the job's schedule that the run is for.  It is earlier than
+	// Start if the run started late, for example because it was missed or queued.
+	Scheduled time.Time
+	// Start is the time at which the run started, or the zero time if it was skipped.
+	Start time.Time
+	// Duration is how long the run took, for OnSuccess and OnFailure.
+	Duration time.Duration
+	// Err is, for OnFailure, the error returned by the job or a [*PanicError] if it panicked, and
+	// for OnSkip, the reason why the run was dropped: [ErrMissed], [ErrOverlap], or the error of
+	// the run's context if it was canceled while the run waited to start.
+	Err error
+}
+
+// A PanicError is the error reported to [Hooks.OnFailure] for a run of a job that panicked.  The
+// scheduler recovers from such panics, so a failing job does not crash the process.
+type PanicError struct {
+	Value any    // Value passed to panic.
+	Stack []byte // Stack trace of the panicking goroutine.
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("cron: job panicked: %v", e.Value)
+}
+
+// Unwrap returns the value passed to panic if it is an error, and nil otherwise.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// call calls hook with info if it is not nil.
+func call(hook func(RunInfo), info RunInfo) {
+	if hook != nil {
+		hook(info)
+	}
+}
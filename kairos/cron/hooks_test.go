@@ -0,0 +1,106 @@
+package cron
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rhansen/go-kairos/kairos"
+)
+
+func TestSchedulerHooks(t *testing.T) {
+	fc := newFake(t)
+	store := NewFileStore(filepath.Join(t.TempDir(), "jobs.json"))
+	// The run of "skipped" at 11:00 was missed while the process was down.
+	if err := store.Save(JobState{ID: "skipped", Next: start.Add(-time.Hour)}); err != nil {
+		t.Fatal(err)
+	}
+	var events []string
+	event := func(kind string) func(RunInfo) {
+		return func(info RunInfo) {
+			e := fmt.Sprintf("%s %s %s", kind, info.ID, info.Scheduled.Format("15:04"))
+			if !info.Start.IsZero() {
+				e += " at " + info.Start.Format("15:04")
+			}
+			var perr *PanicError
+			switch {
+			case errors.As(info.Err, &perr):
+				e += fmt.Sprintf(": panic %v", perr.Value)
+				if !strings.Contains(string(perr.Stack), "TestSchedulerHooks") {
+					t.Errorf("stack of the panic does not show the job:\n%s", perr.Stack)
+				}
+			case info.Err != nil:
+				e += ": " + info.Err.Error()
+			}
+			events = append(events, e)
+		}
+	}
+	s := New(fc, WithLocation(time.UTC), WithStore(store), WithHooks(Hooks{
+		OnStart:   event("start"),
+		OnSuccess: event("success"),
+		OnFailure: event("failure"),
+		OnSkip:    event("skip"),
+	}))
+	t.Cleanup(func() { s.Close() })
+	hourly := Every(time.Hour)
+	s.AddJob("ok", hourly, func(context.Context) error { return nil })
+	s.AddJob("error", hourly, func(context.Context) error { return errors.New("boom") })
+	s.AddJob("panic", hourly, func(context.Context) error { panic("bang") })
+	s.AddJob("skipped", hourly, func(context.Context) error { return nil }, WithMissedRuns(SkipMissed))
+	fc.Advance(time.Hour)
+	want := []string{
+		"skip skipped 11:00: cron: run missed",
+		"start ok 13:00 at 13:00",
+		"success ok 13:00 at 13:00",
+		"start error 13:00 at 13:00",
+		"failure error 13:00 at 13:00: boom",
+		"start panic 13:00 at 13:00",
+		"failure panic 13:00 at 13:00: panic bang",
+		"start skipped 13:00 at 13:00",
+		"success skipped 13:00 at 13:00",
+	}
+	if got := strings.Join(events, "\n"); got != strings.Join(want, "\n") {
+		t.Errorf("got events:\n%s\nwant:\n%s", got, strings.Join(want, "\n"))
+	}
+}
+
+func TestRunBudget(t *testing.T) {
+	for _, tc := range []struct {
+		took time.Duration
+		want []time.Duration
+	}{
+		{5 * time.Minute, nil},
+		{10 * time.Minute, nil},
+		{30 * time.Minute, []time.Duration{30 * time.Minute}},
+	} {
+		t.Run(tc.took.String(), func(t *testing.T) {
+			// Without deterministic dispatch, the job can advance the clock while it runs.
+			fc := kairos.NewFakeClock(start)
+			defer fc.Close()
+			var got []time.Duration
+			s := New(fc, WithRunBudget(10*time.Minute, func(info RunInfo) {
+				if info.ID != "slow" {
+					t.Errorf("got overrun of job %q, want slow", info.ID)
+				}
+				got = append(got, info.Duration)
+			}))
+			s.AddJob("slow", Every(time.Hour), func(context.Context) error {
+				fc.Advance(tc.took)
+				return nil
+			})
+			fc.Advance(time.Hour)
+			for fc.Now().Before(start.Add(time.Hour + tc.took)) {
+				time.Sleep(time.Millisecond)
+			}
+			s.Close()
+			if !slices.Equal(got, tc.want) {
+				t.Errorf("got overruns of %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
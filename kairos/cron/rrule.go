@@ -0,0 +1,498 @@
+package cron
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A frequency is the FREQ of a recurrence rule.  Lower values are coarser.
+type frequency int
+
+const (
+	yearly frequency = iota
+	monthly
+	weekly
+	daily
+	hourly
+	minutely
+	secondly
+)
+
+var frequencies = map[string]frequency{
+	"YEARLY": yearly, "MONTHLY": monthly, "WEEKLY": weekly, "DAILY": daily,
+	"HOURLY": hourly, "MINUTELY": minutely, "SECONDLY": secondly,
+}
+
+var weekdays = map[string]time.Weekday{
+	"SU": time.Sunday, "MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday,
+	"TH": time.Thursday, "FR": time.Friday, "SA": time.Saturday,
+}
+
+// A weekdayNum is an element of BYDAY: a weekday, optionally with the ordinal n of its occurrence
+// within the month or year (negative to count from the end), or 0 for every occurrence.
+type weekdayNum struct {
+	n  int
+	wd time.Weekday
+}
+
+// An RRule is a [Schedule] given by an iCalendar recurrence rule (RFC 5545, section 3.3.10), such
+// as "every second Tuesday of the month" or "the last weekday of the month", which cron expressions
+// cannot express.
+type RRule struct {
+	dtstart    time.Time
+	freq       frequency
+	interval   int
+	count      int
+	until      time.Time
+	byMonth    []int
+	byMonthDay []int
+	byDay      []weekdayNum
+	bySetPos   []int
+	byHour     []int
+	byMinute   []int
+	bySecond   []int
+	wkst       time.Weekday
+}
+
+// ParseRRule parses an iCalendar recurrence rule, with or without the "RRULE:" prefix, for
+// occurrences starting at dtstart, for example
+//
+//	FREQ=MONTHLY;BYDAY=MO,TU,WE,TH,FR;BYSETPOS=-1
+//
+// for the last weekday of each month, at the time of day of dtstart.  The rule parts FREQ,
+// INTERVAL, COUNT, UNTIL, BYMONTH, BYMONTHDAY, BYDAY, BYSETPOS, BYHOUR, BYMINUTE, BYSECOND, and
+// WKST are supported; BYYEARDAY and BYWEEKNO are not.  Occurrences are computed in the time zone
+// of dtstart; a local time that does not exist because of a daylight saving time transition is
+// interpreted with the offset from before the transition, as RFC 5545 requires, which moves it
+// forward by the length of the transition: 02:30 becomes 03:30 on a day that skips from 02:00 to
+// 03:00.  As in most implementations, dtstart itself is an occurrence only if it matches the rule.
+func ParseRRule(rule string, dtstart time.Time) (*RRule, error) {
+	r := &RRule{dtstart: dtstart.Truncate(time.Second), freq: -1, interval: 1, wkst: time.Monday}
+	rule = strings.TrimPrefix(strings.TrimSpace(rule), "RRULE:")
+	fail := func(format string, args ...any) (*RRule, error) {
+		return nil, fmt.Errorf("cron: bad recurrence rule %q: %s", rule, fmt.Sprintf(format, args...))
+	}
+	for _, part := range strings.Split(rule, ";") {
+		name, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return fail("part %q is not NAME=VALUE", part)
+		}
+		var err error
+		switch strings.ToUpper(name) {
+		case "FREQ":
+			f, ok := frequencies[strings.ToUpper(value)]
+			if !ok {
+				return fail("unknown frequency %q", value)
+			}
+			r.freq = f
+		case "INTERVAL":
+			if r.interval, err = strconv.Atoi(value); err != nil || r.interval < 1 {
+				return fail("bad interval %q", value)
+			}
+		case "COUNT":
+			if r.count, err = strconv.Atoi(value); err != nil || r.count < 1 {
+				return fail("bad count %q", value)
+			}
+		case "UNTIL":
+			if r.until, err = parseUntil(value, dtstart.Location()); err != nil {
+				return fail("bad until %q", value)
+			}
+		case "BYMONTH":
+			r.byMonth, err = parseInts(value, 1, 12, false)
+		case "BYMONTHDAY":
+			r.byMonthDay, err = parseInts(value, 1, 31, true)
+		case "BYSETPOS":
+			r.bySetPos, err = parseInts(value, 1, 366, true)
+		case "BYHOUR":
+			r.byHour, err = parseInts(value, 0, 23, false)
+		case "BYMINUTE":
+			r.byMinute, err = parseInts(value, 0, 59, false)
+		case "BYSECOND":
+			r.bySecond, err = parseInts(value, 0, 59, false)
+		case "BYDAY":
+			for _, s := range strings.Split(strings.ToUpper(value), ",") {
+				if len(s) < 2 {
+					return fail("bad day %q", s)
+				}
+				wd, ok := weekdays[s[len(s)-2:]]
+				n := 0
+				if num := s[:len(s)-2]; num != "" {
+					n, err = strconv.Atoi(num)
+					if err != nil || n == 0 || n < -53 || n > 53 {
+						return fail("bad day %q", s)
+					}
+				}
+				if !ok {
+					return fail("bad day %q", s)
+				}
+				r.byDay = append(r.byDay, weekdayNum{n: n, wd: wd})
+			}
+		case "WKST":
+			wd, ok := weekdays[strings.ToUpper(value)]
+			if !ok {
+				return fail("bad week start %q", value)
+			}
+			r.wkst = wd
+		case "BYYEARDAY", "BYWEEKNO":
+			return fail("%s is not supported", strings.ToUpper(name))
+		default:
+			return fail("unknown part %q", name)
+		}
+		if err != nil {
+			return fail("bad %s %q", strings.ToUpper(name), value)
+		}
+	}
+	switch {
+	case r.freq < 0:
+		return fail("FREQ is missing")
+	case r.count > 0 && !r.until.IsZero():
+		return fail("COUNT and UNTIL are mutually exclusive")
+	case len(r.bySetPos) > 0 && len(r.byMonth)+len(r.byMonthDay)+len(r.byDay)+len(r.byHour)+
+		len(r.byMinute)+len(r.bySecond) == 0:
+		return fail("BYSETPOS requires another BYxxx part")
+	}
+	for _, wdn := range r.byDay {
+		if wdn.n != 0 && r.freq != monthly && r.freq != yearly {
+			return fail("numbered days are only allowed with FREQ=MONTHLY or FREQ=YEARLY")
+		}
+	}
+	return r, nil
+}
+
+// parseUntil parses the value of UNTIL: a date, a UTC date-time, or a local date-time in loc.
+func parseUntil(s string, loc *time.Location) (time.Time, error) {
+	switch {
+	case len(s) == 8:
+		t, err := time.ParseInLocation("20060102", s, loc)
+		// A date includes the whole day.
+		return t.AddDate(0, 0, 1).Add(-time.Second), err
+	case strings.HasSuffix(s, "Z"):
+		return time.Parse("20060102T150405Z", s)
+	}
+	return time.ParseInLocation("20060102T150405", s, loc)
+}
+
+// parseInts parses a comma-separated list of integers whose absolute values are in [min, max],
+// rejecting negative values unless neg is true.
+func parseInts(s string, min, max int, neg bool) ([]int, error) {
+	var vals []int
+	for _, f := range strings.Split(s, ",") {
+		v, err := strconv.Atoi(f)
+		if err != nil {
+			return nil, err
+		}
+		abs := v
+		if neg && v < 0 {
+			abs = -v
+		}
+		if abs < min || abs > max {
+			return nil, fmt.Errorf("%d out of range", v)
+		}
+		vals = append(vals, v)
+	}
+	return vals, nil
+}
+
+// A date is a day in the proleptic Gregorian calendar.
+type date struct {
+	y int
+	m time.Month
+	d int
+}
+
+func dateOf(t time.Time) date { return date{t.Year(), t.Month(), t.Day()} }
+
+// addDays returns the date n days after dt, normalizing it like time.Date.
+func (dt date) addDays(n int) date {
+	return dateOf(time.Date(dt.y, dt.m, dt.d+n, 0, 0, 0, 0, time.UTC))
+}
+
+func (dt date) weekday() time.Weekday {
+	return time.Date(dt.y, dt.m, dt.d, 0, 0, 0, 0, time.UTC).Weekday()
+}
+
+// daysBetween returns the number of days from a to b.
+func daysBetween(a, b date) int {
+	ta := time.Date(a.y, a.m, a.d, 0, 0, 0, 0, time.UTC)
+	tb := time.Date(b.y, b.m, b.d, 0, 0, 0, 0, time.UTC)
+	return int(tb.Sub(ta) / (24 * time.Hour))
+}
+
+// at returns the time h:mi:s on dt in loc.  Unlike time.Date, which may move a time that falls in
+// a gap of loc backward or forward, it interprets such a time with the offset from before the gap.
+func (dt date) at(h, mi, s int, loc *time.Location) time.Time {
+	t := time.Date(dt.y, dt.m, dt.d, h, mi, s, 0, loc)
+	wall := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.UTC)
+	if wall.Before(time.Date(dt.y, dt.m, dt.d, h, mi, s, 0, time.UTC)) {
+		// time.Date used the offset from after the gap: add the length of the gap.
+		_, end := t.ZoneBounds()
+		_, before := t.Zone()
+		_, after := end.Zone()
+		t = t.Add(time.Duration(after-before) * time.Second)
+	}
+	return t
+}
+
+func daysIn(y int, m time.Month) int {
+	return time.Date(y, m+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+// unit returns the length of a period of a sub-daily frequency.
+func (r *RRule) unit() time.Duration {
+	switch r.freq {
+	case hourly:
+		return time.Hour
+	case minutely:
+		return time.Minute
+	}
+	return time.Second
+}
+
+// weekStart returns the first day of the week of dtstart.
+func (r *RRule) weekStart() date {
+	start := dateOf(r.dtstart)
+	return start.addDays(-((int(start.weekday()) - int(r.wkst) + 7) % 7))
+}
+
+// periodStart returns the first day of period k, or, for sub-daily frequencies, its instant.
+func (r *RRule) periodStart(k int) (date, time.Time) {
+	start := dateOf(r.dtstart)
+	switch r.freq {
+	case yearly:
+		return date{start.y + k*r.interval, time.January, 1}, time.Time{}
+	case monthly:
+		m := start.m + time.Month(k*r.interval)
+		return dateOf(time.Date(start.y, m, 1, 0, 0, 0, 0, time.UTC)), time.Time{}
+	case weekly:
+		return r.weekStart().addDays(7 * k * r.interval), time.Time{}
+	case daily:
+		return start.addDays(k * r.interval), time.Time{}
+	}
+	t := r.dtstart.Add(time.Duration(k*r.interval) * r.unit())
+	return dateOf(t), t
+}
+
+// periodBefore returns the index of a period that starts no later than t.
+func (r *RRule) periodBefore(t time.Time) int {
+	t = t.In(r.dtstart.Location())
+	start := dateOf(r.dtstart)
+	var k int
+	switch r.freq {
+	case yearly:
+		k = (t.Year() - start.y) / r.interval
+	case monthly:
+		k = ((t.Year()-start.y)*12 + int(t.Month()-start.m)) / r.interval
+	case weekly:
+		k = daysBetween(r.weekStart(), dateOf(t)) / (7 * r.interval)
+	case daily:
+		k = daysBetween(start, dateOf(t)) / r.interval
+	default:
+		k = int(t.Sub(r.dtstart) / (time.Duration(r.interval) * r.unit()))
+	}
+	return max(k-1, 0)
+}
+
+// dayAllowed reports whether dt passes the BYMONTH, BYMONTHDAY, and BYDAY limits.
+func (r *RRule) dayAllowed(dt date) bool {
+	if len(r.byMonth) > 0 && !slices.Contains(r.byMonth, int(dt.m)) {
+		return false
+	}
+	if len(r.byMonthDay) > 0 {
+		n := daysIn(dt.y, dt.m)
+		if !slices.ContainsFunc(r.byMonthDay, func(md int) bool { return md == dt.d || n+md+1 == dt.d }) {
+			return false
+		}
+	}
+	if len(r.byDay) > 0 {
+		wd := dt.weekday()
+		if !slices.ContainsFunc(r.byDay, func(wdn weekdayNum) bool { return wdn.wd == wd }) {
+			return false
+		}
+	}
+	return true
+}
+
+// nthWeekdays returns the days among the n days starting at first that match BYDAY, honoring the
+// ordinals relative to that span.
+func (r *RRule) nthWeekdays(first date, n int) []date {
+	var days []date
+	for _, wdn := range r.byDay {
+		var matches []date
+		for d := (int(wdn.wd) - int(first.weekday()) + 7) % 7; d < n; d += 7 {
+			matches = append(matches, first.addDays(d))
+		}
+		switch {
+		case wdn.n == 0:
+			days = append(days, matches...)
+		case wdn.n > 0 && wdn.n <= len(matches):
+			days = append(days, matches[wdn.n-1])
+		case wdn.n < 0 && -wdn.n <= len(matches):
+			days = append(days, matches[len(matches)+wdn.n])
+		}
+	}
+	return days
+}
+
+// monthDays returns the days of month m of year y selected by BYMONTHDAY and BYDAY.
+func (r *RRule) monthDays(y int, m time.Month) []date {
+	n := daysIn(y, m)
+	if len(r.byMonthDay) == 0 {
+		return r.nthWeekdays(date{y, m, 1}, n)
+	}
+	var days []date
+	for _, md := range r.byMonthDay {
+		if md < 0 {
+			md += n + 1
+		}
+		if md >= 1 && md <= n && r.dayAllowed(date{y, m, md}) {
+			days = append(days, date{y, m, md})
+		}
+	}
+	return days
+}
+
+// days returns the days of period k on which occurrences can fall.
+func (r *RRule) days(k int) []date {
+	first, _ := r.periodStart(k)
+	start := dateOf(r.dtstart)
+	valid := func(dt date) []date {
+		if dt.d > daysIn(dt.y, dt.m) {
+			return nil // For example, the 31st in a month of 30 days.
+		}
+		return []date{dt}
+	}
+	expand := len(r.byMonthDay) > 0 || len(r.byDay) > 0
+	switch r.freq {
+	case yearly:
+		if len(r.byDay) > 0 && len(r.byMonth) == 0 && len(r.byMonthDay) == 0 {
+			return r.nthWeekdays(first, daysBetween(first, date{first.y + 1, time.January, 1}))
+		}
+		months := r.byMonth
+		if len(months) == 0 {
+			if !expand {
+				return valid(date{first.y, start.m, start.d})
+			}
+			months = []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}
+		}
+		var days []date
+		for _, m := range months {
+			if expand {
+				days = append(days, r.monthDays(first.y, time.Month(m))...)
+			} else {
+				days = append(days, valid(date{first.y, time.Month(m), start.d})...)
+			}
+		}
+		return days
+	case monthly:
+		if len(r.byMonth) > 0 && !slices.Contains(r.byMonth, int(first.m)) {
+			return nil
+		}
+		if !expand {
+			return valid(date{first.y, first.m, start.d})
+		}
+		return r.monthDays(first.y, first.m)
+	case weekly:
+		var days []date
+		for i := 0; i < 7; i++ {
+			dt := first.addDays(i)
+			if len(r.byDay) == 0 && dt.weekday() != r.dtstart.Weekday() {
+				continue
+			}
+			if r.dayAllowed(dt) {
+				days = append(days, dt)
+			}
+		}
+		return days
+	}
+	if r.dayAllowed(first) {
+		return []date{first}
+	}
+	return nil
+}
+
+// expandTime returns the values of a time unit: the period's own value for frequencies at least as
+// fine as the unit (if it passes the limit by), and otherwise by or, if empty, dtstart's value.
+func expandTime(fine bool, own, start int, by []int) []int {
+	switch {
+	case fine && (len(by) == 0 || slices.Contains(by, own)):
+		return []int{own}
+	case fine:
+		return nil
+	case len(by) > 0:
+		return by
+	}
+	return []int{start}
+}
+
+// occurrences returns the occurrences in period k that are not before dtstart, in order.
+func (r *RRule) occurrences(k int) []time.Time {
+	loc := r.dtstart.Location()
+	_, instant := r.periodStart(k)
+	instant = instant.In(loc)
+	hours := expandTime(r.freq >= hourly, instant.Hour(), r.dtstart.Hour(), r.byHour)
+	minutes := expandTime(r.freq >= minutely, instant.Minute(), r.dtstart.Minute(), r.byMinute)
+	seconds := expandTime(r.freq >= secondly, instant.Second(), r.dtstart.Second(), r.bySecond)
+	var set []time.Time
+	for _, dt := range r.days(k) {
+		for _, h := range hours {
+			for _, mi := range minutes {
+				for _, s := range seconds {
+					set = append(set, dt.at(h, mi, s, loc))
+				}
+			}
+		}
+	}
+	slices.SortFunc(set, func(a, b time.Time) int { return a.Compare(b) })
+	set = slices.CompactFunc(set, time.Time.Equal)
+	if len(r.bySetPos) > 0 {
+		var picked []time.Time
+		for _, pos := range r.bySetPos {
+			i := pos - 1
+			if pos < 0 {
+				i = len(set) + pos
+			}
+			if i >= 0 && i < len(set) {
+				picked = append(picked, set[i])
+			}
+		}
+		slices.SortFunc(picked, func(a, b time.Time) int { return a.Compare(b) })
+		set = slices.CompactFunc(picked, time.Time.Equal)
+	}
+	i := 0
+	for i < len(set) && set[i].Before(r.dtstart) {
+		i++
+	}
+	return set[i:]
+}
+
+// Next returns the first occurrence strictly after t, or the zero time if there is none (because
+// of COUNT or UNTIL, or because the rule matches nothing within ten years after t).
+func (r *RRule) Next(t time.Time) time.Time {
+	k := 0
+	if r.count == 0 {
+		// Without COUNT, earlier periods do not matter.
+		k = r.periodBefore(t)
+	}
+	limit := dateOf(t.In(r.dtstart.Location()).AddDate(10, 0, 0))
+	seen := 0
+	for ; ; k++ {
+		first, _ := r.periodStart(k)
+		if daysBetween(first, limit) < 0 {
+			return time.Time{}
+		}
+		for _, o := range r.occurrences(k) {
+			if !r.until.IsZero() && o.After(r.until) {
+				return time.Time{}
+			}
+			if seen++; r.count > 0 && seen > r.count {
+				return time.Time{}
+			}
+			if o.After(t) {
+				return o
+			}
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package cron
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRRuleNext(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("time zone database unavailable: %v", err)
+	}
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("time zone database unavailable: %v", err)
+	}
+	// A Wednesday.
+	dtstart := time.Date(2023, 5, 17, 9, 0, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		rule    string
+		dtstart time.Time
+		from    string
+		want    []string // Successive occurrences, in UTC.
+	}{
+		{"FREQ=DAILY", dtstart, "2023-05-17T09:00:00Z", []string{"2023-05-18T09:00:00Z", "2023-05-19T09:00:00Z"}},
+		{"RRULE:FREQ=DAILY;INTERVAL=3", dtstart, "2023-05-10T00:00:00Z", []string{"2023-05-17T09:00:00Z", "2023-05-20T09:00:00Z"}},
+		// Every second Tuesday of the month.
+		{"FREQ=MONTHLY;BYDAY=2TU", dtstart, "2023-05-17T00:00:00Z", []string{"2023-06-13T09:00:00Z", "2023-07-11T09:00:00Z"}},
+		// Every other Tuesday.
+		{"FREQ=WEEKLY;INTERVAL=2;BYDAY=TU", dtstart, "2023-05-17T00:00:00Z", []string{"2023-05-30T09:00:00Z", "2023-06-13T09:00:00Z"}},
+		// The last weekday of the month.
+		{"FREQ=MONTHLY;BYDAY=MO,TU,WE,TH,FR;BYSETPOS=-1", dtstart, "2023-05-17T00:00:00Z", []string{"2023-05-31T09:00:00Z", "2023-06-30T09:00:00Z", "2023-07-31T09:00:00Z"}},
+		{"FREQ=MONTHLY;BYMONTHDAY=-1", dtstart, "2023-05-31T09:00:00Z", []string{"2023-06-30T09:00:00Z", "2023-07-31T09:00:00Z"}},
+		// The 31st is skipped in months without one.
+		{"FREQ=MONTHLY", time.Date(2023, 1, 31, 0, 0, 0, 0, time.UTC), "2023-01-31T00:00:00Z", []string{"2023-03-31T00:00:00Z", "2023-05-31T00:00:00Z"}},
+		// Friday the 13th.
+		{"FREQ=MONTHLY;BYDAY=FR;BYMONTHDAY=13", dtstart, "2023-05-17T00:00:00Z", []string{"2023-10-13T09:00:00Z", "2024-09-13T09:00:00Z"}},
+		// The last Sunday of March, and the first Monday of the year.
+		{"FREQ=YEARLY;BYMONTH=3;BYDAY=-1SU", dtstart, "2023-05-17T00:00:00Z", []string{"2024-03-31T09:00:00Z", "2025-03-30T09:00:00Z"}},
+		{"FREQ=YEARLY;BYDAY=1MO", dtstart, "2023-05-17T00:00:00Z", []string{"2024-01-01T09:00:00Z", "2025-01-06T09:00:00Z"}},
+		{"FREQ=YEARLY", time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC), "2024-02-29T00:00:00Z", []string{"2028-02-29T00:00:00Z"}},
+		{"FREQ=DAILY;BYHOUR=9,17;BYMINUTE=30", dtstart, "2023-05-17T12:00:00Z", []string{"2023-05-17T17:30:00Z", "2023-05-18T09:30:00Z"}},
+		{"FREQ=HOURLY;INTERVAL=5;BYDAY=SA", dtstart, "2023-05-17T00:00:00Z", []string{"2023-05-20T02:00:00Z", "2023-05-20T07:00:00Z"}},
+		{"FREQ=MINUTELY;INTERVAL=20;BYHOUR=10", dtstart, "2023-05-17T00:00:00Z", []string{"2023-05-17T10:00:00Z", "2023-05-17T10:20:00Z", "2023-05-17T10:40:00Z", "2023-05-18T10:00:00Z"}},
+		{"FREQ=WEEKLY;COUNT=3", dtstart, "2023-05-01T00:00:00Z", []string{"2023-05-17T09:00:00Z", "2023-05-24T09:00:00Z", "2023-05-31T09:00:00Z", "0001-01-01T00:00:00Z"}},
+		{"FREQ=DAILY;UNTIL=20230518", dtstart, "2023-05-17T00:00:00Z", []string{"2023-05-17T09:00:00Z", "2023-05-18T09:00:00Z", "0001-01-01T00:00:00Z"}},
+		{"FREQ=DAILY;UNTIL=20230518T090000Z", dtstart, "2023-05-18T00:00:00Z", []string{"2023-05-18T09:00:00Z", "0001-01-01T00:00:00Z"}},
+		{"FREQ=YEARLY;BYMONTH=2;BYMONTHDAY=30", dtstart, "2023-05-17T00:00:00Z", []string{"0001-01-01T00:00:00Z"}},
+		// 09:00 in Berlin, across the end of daylight saving time.
+		{"FREQ=DAILY", time.Date(2023, 10, 27, 9, 0, 0, 0, berlin), "2023-10-28T12:00:00Z", []string{"2023-10-29T08:00:00Z", "2023-10-30T08:00:00Z"}},
+		// 02:30 does not exist in Berlin on 2023-03-26.
+		{"FREQ=DAILY", time.Date(2023, 3, 24, 2, 30, 0, 0, berlin), "2023-03-25T12:00:00Z", []string{"2023-03-26T01:30:00Z", "2023-03-27T00:30:00Z"}},
+		// 02:30 does not exist in New York on 2024-03-10, where time.Date moves it back to 01:30 EST.
+		{"FREQ=DAILY", time.Date(2024, 3, 9, 2, 30, 0, 0, newYork), "2024-03-09T12:00:00Z", []string{"2024-03-10T07:30:00Z", "2024-03-11T06:30:00Z"}},
+	} {
+		t.Run(tc.rule, func(t *testing.T) {
+			r, err := ParseRRule(tc.rule, tc.dtstart)
+			if err != nil {
+				t.Fatal(err)
+			}
+			from, _ := time.Parse(time.RFC3339, tc.from)
+			var got []string
+			for next := r.Next(from); len(got) < len(tc.want); next = r.Next(next) {
+				got = append(got, next.UTC().Format(time.RFC3339))
+				if next.IsZero() {
+					break
+				}
+			}
+			if strings.Join(got, " ") != strings.Join(tc.want, " ") {
+				t.Errorf("got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseRRuleErrors(t *testing.T) {
+	for _, rule := range []string{
+		"",
+		"INTERVAL=2",
+		"FREQ=FORTNIGHTLY",
+		"FREQ=DAILY;INTERVAL=0",
+		"FREQ=DAILY;COUNT=-1",
+		"FREQ=DAILY;COUNT=2;UNTIL=20230101",
+		"FREQ=DAILY;UNTIL=tomorrow",
+		"FREQ=MONTHLY;BYMONTHDAY=32",
+		"FREQ=MONTHLY;BYMONTH=0",
+		"FREQ=MONTHLY;BYDAY=XX",
+		"FREQ=MONTHLY;BYDAY=0MO",
+		"FREQ=WEEKLY;BYDAY=2MO",
+		"FREQ=DAILY;BYHOUR=24",
+		"FREQ=MONTHLY;BYSETPOS=1",
+		"FREQ=YEARLY;BYWEEKNO=20",
+		"FREQ=DAILY;COLOR=blue",
+		"FREQ=DAILY;BYDAY",
+	} {
+		if _, err := ParseRRule(rule, time.Now()); err == nil {
+			t.Errorf("ParseRRule(%q): got nil error", rule)
+		} else if !strings.HasPrefix(err.Error(), "cron: ") {
+			t.Errorf("ParseRRule(%q): error %q lacks the package prefix", rule, err)
+		}
+	}
+}
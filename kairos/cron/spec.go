@@ -0,0 +1,411 @@
+package cron
+
+import (
+	"fmt"
+	"math/bits"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A Schedule describes when a job runs.
+type Schedule interface {
+	// Next returns the first activation time strictly after t, or the zero time if there is none.
+	Next(t time.Time) time.Time
+}
+
+// A field describes the range and names of one field of a cron expression.
+type field struct {
+	name     string
+	min, max uint
+	names    map[string]uint
+}
+
+var (
+	secondField = field{name: "second", min: 0, max: 59}
+	minuteField = field{name: "minute", min: 0, max: 59}
+	hourField   = field{name: "hour", min: 0, max: 23}
+	domField    = field{name: "day of month", min: 1, max: 31}
+	monthField  = field{name: "month", min: 1, max: 12, names: map[string]uint{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	// Day 7 is accepted as an alias of Sunday and folded into day 0 after parsing.
+	dowField = field{name: "day of week", min: 0, max: 7, names: map[string]uint{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+// starBit marks a field that was given as "*" or "?", which matters for the days of the month
+// and of the week: a day matches if either field matches, unless one of them is a star.
+const starBit = 1 << 63
+
+// SpecSchedule is a [Schedule] parsed from a cron expression.  Each field is a bit set of the
+// values that match.
+type SpecSchedule struct {
+	second, minute, hour, dom, month, dow uint64
+
+	// Location is the time zone in which the expression is interpreted.
+	Location *time.Location
+}
+
+var descriptors = map[string]string{
+	"@yearly":   "0 0 0 1 1 *",
+	"@annually": "0 0 0 1 1 *",
+	"@monthly":  "0 0 0 1 * *",
+	"@weekly":   "0 0 0 * * 0",
+	"@daily":    "0 0 0 * * *",
+	"@midnight": "0 0 0 * * *",
+	"@hourly":   "0 0 * * * *",
+}
+
+// Parse parses a cron expression in the local time zone.  See [ParseInLocation].
+func Parse(spec string) (Schedule, error) {
+	return ParseInLocation(spec, time.Local)
+}
+
+// ParseInLocation parses a cron expression interpreted in the time zone loc.  The expression has
+// the standard five fields (minute, hour, day of month, month, and day of week) or six, with a
+// leading field for the second.  Each field is "*" (or "?"), a value, a range "a-b", or a
+// comma-separated list of these, and a value or range may be followed by a step "/n"; months and
+// days of the week may be given by their English three-letter names, and Sunday is both 0 and 7.
+// As in standard cron, if both the day of the month and the day of the week are restricted, a day
+// matching either one matches.  The descriptors @yearly (or @annually), @monthly, @weekly, @daily
+// (or @midnight), and @hourly are accepted, as is "@every d", which runs every duration d as
+// understood by [time.ParseDuration].  A prefix "CRON_TZ=zone " or "TZ=zone " overrides loc.
+func ParseInLocation(spec string, loc *time.Location) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	if strings.HasPrefix(spec, "CRON_TZ=") || strings.HasPrefix(spec, "TZ=") {
+		zone, rest, _ := strings.Cut(spec, " ")
+		_, name, _ := strings.Cut(zone, "=")
+		var err error
+		if loc, err = time.LoadLocation(name); err != nil {
+			return nil, fmt.Errorf("cron: bad time zone in %q: %w", spec, err)
+		}
+		spec = strings.TrimSpace(rest)
+	}
+	if strings.HasPrefix(spec, "@every ") {
+		d, err := time.ParseDuration(strings.TrimSpace(strings.TrimPrefix(spec, "@every ")))
+		if err != nil {
+			return nil, fmt.Errorf("cron: bad duration in %q: %w", spec, err)
+		}
+		if d <= 0 {
+			return nil, fmt.Errorf("cron: non-positive duration in %q", spec)
+		}
+		return Every(d), nil
+	}
+	expr := spec
+	if d, ok := descriptors[strings.ToLower(spec)]; ok {
+		expr = d
+	}
+	fields := strings.Fields(expr)
+	switch len(fields) {
+	case 5:
+		fields = append([]string{"0"}, fields...)
+	case 6:
+	default:
+		return nil, fmt.Errorf("cron: %q has %d fields, want 5 or 6", spec, len(fields))
+	}
+	s := &SpecSchedule{Location: loc}
+	for i, f := range []struct {
+		bits *uint64
+		desc field
+	}{
+		{&s.second, secondField},
+		{&s.minute, minuteField},
+		{&s.hour, hourField},
+		{&s.dom, domField},
+		{&s.month, monthField},
+		{&s.dow, dowField},
+	} {
+		b, err := parseField(fields[i], f.desc)
+		if err != nil {
+			return nil, fmt.Errorf("cron: bad %s in %q: %w", f.desc.name, spec, err)
+		}
+		*f.bits = b
+	}
+	if s.dow&(1<<7) != 0 {
+		s.dow = s.dow&^(1<<7) | 1
+	}
+	return s, nil
+}
+
+// parseField returns the bit set of the values matched by the comma-separated list s.
+func parseField(s string, f field) (uint64, error) {
+	var b uint64
+	for _, part := range strings.Split(s, ",") {
+		pb, err := parseRange(part, f)
+		if err != nil {
+			return 0, err
+		}
+		b |= pb
+	}
+	return b, nil
+}
+
+// parseRange returns the bit set of the values matched by a single element of a list.
+func parseRange(s string, f field) (uint64, error) {
+	rng, stepStr, hasStep := strings.Cut(s, "/")
+	lo, hi := f.min, f.max
+	var star uint64
+	switch {
+	case rng == "*" || rng == "?":
+		if !hasStep {
+			star = starBit
+		}
+	default:
+		loStr, hiStr, isRange := strings.Cut(rng, "-")
+		var err error
+		if lo, err = parseValue(loStr, f); err != nil {
+			return 0, err
+		}
+		switch {
+		case isRange:
+			if hi, err = parseValue(hiStr, f); err != nil {
+				return 0, err
+			}
+		case !hasStep:
+			hi = lo
+		}
+	}
+	step := uint(1)
+	if hasStep {
+		n, err := strconv.ParseUint(stepStr, 10, 8)
+		if err != nil || n == 0 {
+			return 0, fmt.Errorf("bad step %q", stepStr)
+		}
+		step = uint(n)
+	}
+	if lo > hi {
+		return 0, fmt.Errorf("range %q is empty", s)
+	}
+	var b uint64
+	for v := lo; v <= hi; v += step {
+		b |= 1 << v
+	}
+	return b | star, nil
+}
+
+// parseValue parses a number or name within the range of f.
+func parseValue(s string, f field) (uint, error) {
+	if v, ok := f.names[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+	v, err := strconv.ParseUint(s, 10, 8)
+	if err != nil {
+		return 0, fmt.Errorf("bad value %q", s)
+	}
+	if uint(v) < f.min || uint(v) > f.max {
+		return 0, fmt.Errorf("value %d out of range [%d, %d]", v, f.min, f.max)
+	}
+	return uint(v), nil
+}
+
+// String returns a six-field cron expression equivalent to s, without the time zone.
+func (s *SpecSchedule) String() string {
+	list := func(b uint64) string {
+		if b&starBit != 0 {
+			return "*"
+		}
+		var vals []string
+		for b != 0 {
+			v := bits.TrailingZeros64(b)
+			b &^= 1 << v
+			vals = append(vals, strconv.Itoa(v))
+		}
+		return strings.Join(vals, ",")
+	}
+	return strings.Join([]string{
+		list(s.second), list(s.minute), list(s.hour), list(s.dom), list(s.month), list(s.dow),
+	}, " ")
+}
+
+// Next returns the first time strictly after t that matches the expression, in the schedule's time
+// zone, or the zero time if no time matches within five years.  Around a daylight saving time
+// transition, times that do not exist in the time zone are skipped, and times that exist twice
+// match twice.
+func (s *SpecSchedule) Next(t time.Time) time.Time {
+	loc := s.Location
+	if loc == nil {
+		loc = time.Local
+	}
+	orig := t
+	t = t.In(loc)
+	// Start at the next whole second.
+	t = t.Add(time.Second - time.Duration(t.Nanosecond()))
+	added := false
+	yearLimit := t.Year() + 5
+
+wrap:
+	if t.Year() > yearLimit {
+		return time.Time{}
+	}
+	for 1<<uint(t.Month())&s.month == 0 {
+		if !added {
+			added = true
+			t = time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, loc)
+		}
+		t = t.AddDate(0, 1, 0)
+		if t.Month() == time.January {
+			goto wrap
+		}
+	}
+	for !s.dayMatches(t) {
+		if !added {
+			added = true
+			t = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+		}
+		t = t.AddDate(0, 0, 1)
+		// A day can start at 01:00 if midnight does not exist because of a DST transition.
+		if h := t.Hour(); h != 0 {
+			if h > 12 {
+				t = t.Add(time.Duration(24-h) * time.Hour)
+			} else {
+				t = t.Add(-time.Duration(h) * time.Hour)
+			}
+		}
+		if t.Day() == 1 {
+			goto wrap
+		}
+	}
+	for 1<<uint(t.Hour())&s.hour == 0 {
+		if !added {
+			added = true
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, loc)
+		}
+		t = t.Add(time.Hour)
+		if t.Hour() == 0 {
+			goto wrap
+		}
+	}
+	for 1<<uint(t.Minute())&s.minute == 0 {
+		if !added {
+			added = true
+			t = t.Truncate(time.Minute)
+		}
+		t = t.Add(time.Minute)
+		if t.Minute() == 0 {
+			goto wrap
+		}
+	}
+	for 1<<uint(t.Second())&s.second == 0 {
+		if !added {
+			added = true
+			t = t.Truncate(time.Second)
+		}
+		t = t.Add(time.Second)
+		if t.Second() == 0 {
+			goto wrap
+		}
+	}
+	if !t.After(orig) {
+		// Only possible for times before the start of the time zone's history.
+		return time.Time{}
+	}
+	return t
+}
+
+// dayMatches reports whether the day of t matches the day-of-month and day-of-week fields.
+func (s *SpecSchedule) dayMatches(t time.Time) bool {
+	dom := 1<<uint(t.Day())&s.dom != 0
+	dow := 1<<uint(t.Weekday())&s.dow != 0
+	if s.dom&starBit != 0 || s.dow&starBit != 0 {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+// Every returns a [Schedule] that activates every d.  Activations fall on multiples of d since the
+// zero time (see [time.Time.Truncate]), so that all jobs with the same interval run together.
+// Every panics if d is not positive.
+func Every(d time.Duration) Schedule {
+	if d <= 0 {
+		panic("cron: non-positive duration for Every")
+	}
+	return everySchedule(d)
+}
+
+type everySchedule time.Duration
+
+func (e everySchedule) Next(t time.Time) time.Time {
+	d := time.Duration(e)
+	return t.Truncate(d).Add(d)
+}
+
+// EveryFrom returns a [Schedule] that activates every d, at the instants that are multiples of d
+// since epoch, for example every 15 minutes at 00:07, 00:22, 00:37, and so on.  The activations
+// extend before epoch as well as after it, so the first one after any time lands on a boundary.
+// EveryFrom panics if d is not positive.
+func EveryFrom(epoch time.Time, d time.Duration) Schedule {
+	if d <= 0 {
+		panic("cron: non-positive duration for EveryFrom")
+	}
+	return &epochSchedule{epoch: epoch, d: d}
+}
+
+type epochSchedule struct {
+	epoch time.Time
+	d     time.Duration
+}
+
+func (e *epochSchedule) Next(t time.Time) time.Time {
+	t = t.Round(0)
+	r := t.Sub(e.epoch) % e.d
+	if r < 0 {
+		r += e.d
+	}
+	return t.Add(e.d - r)
+}
+
+// EveryDate returns a [Schedule] that activates at start and then every interval of the given
+// numbers of years, months, and days, at the time of day of start in its location, for example
+// EveryDate(start, 0, 1, 0) for monthly and EveryDate(start, 0, 0, 14) for every two weeks.
+// Unlike a fixed [time.Duration], the interval follows the calendar, so activations keep their
+// time of day across daylight saving time transitions and their day of the month across months of
+// different lengths.  A day of the month that does not exist in a month is clamped to the last day
+// of that month, so a monthly schedule starting on January 31st activates on February 28th (or
+// 29th) and then on March 31st.  A time of day that does not exist on a date is normalized as by
+// [time.Date].  EveryDate panics if any count is negative or all of them are zero.
+func EveryDate(start time.Time, years, months, days int) Schedule {
+	if years < 0 || months < 0 || days < 0 || years == 0 && months == 0 && days == 0 {
+		panic("cron: non-positive interval for EveryDate")
+	}
+	return &dateSchedule{start: start, months: 12*years + months, days: days}
+}
+
+type dateSchedule struct {
+	start  time.Time
+	months int
+	days   int
+}
+
+// at returns the n-th activation after start.
+func (s *dateSchedule) at(n int) time.Time {
+	y, m, d := s.start.Date()
+	hour, minute, sec := s.start.Clock()
+	m += time.Month(n * s.months)
+	// Normalize the month before clamping the day.
+	first := time.Date(y, m, 1, 0, 0, 0, 0, time.UTC)
+	y, m = first.Year(), first.Month()
+	d = min(d, daysIn(y, m)) + n*s.days
+	return time.Date(y, m, d, hour, minute, sec, s.start.Nanosecond(), s.start.Location())
+}
+
+func (s *dateSchedule) Next(t time.Time) time.Time {
+	if t.Before(s.start) {
+		return s.start
+	}
+	// Estimate the number of intervals between start and t from below, then step forward.
+	from, to := dateOf(s.start), dateOf(t.In(s.start.Location()))
+	var n int
+	if s.months > 0 {
+		n = ((to.y-from.y)*12 + int(to.m-from.m)) / s.months
+	} else {
+		n = daysBetween(from, to) / s.days
+	}
+	n = max(n-1, 0)
+	for !s.at(n).After(t) {
+		n++
+	}
+	return s.at(n)
+}
@@ -0,0 +1,147 @@
+package cron
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseNext(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("time zone database unavailable: %v", err)
+	}
+	for _, tc := range []struct {
+		spec string
+		from string
+		want []string // Successive activations, in UTC.
+	}{
+		{"* * * * *", "2023-05-17T12:00:30Z", []string{"2023-05-17T12:01:00Z", "2023-05-17T12:02:00Z"}},
+		{"*/20 * * * * *", "2023-05-17T12:00:30Z", []string{"2023-05-17T12:00:40Z", "2023-05-17T12:01:00Z"}},
+		{"0 9-17/4 * * MON-FRI", "2023-05-19T16:00:00Z", []string{"2023-05-19T17:00:00Z", "2023-05-22T09:00:00Z"}},
+		{"30 2 29 feb *", "2023-01-01T00:00:00Z", []string{"2024-02-29T02:30:00Z", "2028-02-29T02:30:00Z"}},
+		// Both days restricted: the 13th or any Friday.
+		{"0 0 13 * 5", "2023-10-11T00:00:00Z", []string{"2023-10-13T00:00:00Z", "2023-10-20T00:00:00Z"}},
+		{"0 0 * * 7", "2023-05-17T00:00:00Z", []string{"2023-05-21T00:00:00Z"}},
+		{"@monthly", "2023-12-15T00:00:00Z", []string{"2024-01-01T00:00:00Z", "2024-02-01T00:00:00Z"}},
+		{"@every 90m", "2023-05-17T00:00:00Z", []string{"2023-05-17T01:30:00Z", "2023-05-17T03:00:00Z"}},
+		// 02:30 does not exist in Berlin on 2023-03-26 and exists twice on 2023-10-29.
+		{"CRON_TZ=Europe/Berlin 0 30 2 * * *", "2023-03-24T12:00:00Z", []string{"2023-03-25T01:30:00Z", "2023-03-27T00:30:00Z"}},
+		{"TZ=Europe/Berlin 30 2 * * *", "2023-10-28T12:00:00Z", []string{"2023-10-29T00:30:00Z", "2023-10-29T01:30:00Z", "2023-10-30T01:30:00Z"}},
+		{"0 0 30 2 *", "2023-01-01T00:00:00Z", []string{"0001-01-01T00:00:00Z"}},
+	} {
+		t.Run(tc.spec, func(t *testing.T) {
+			sched, err := ParseInLocation(tc.spec, time.UTC)
+			if err != nil {
+				t.Fatal(err)
+			}
+			from, _ := time.Parse(time.RFC3339, tc.from)
+			var got []string
+			for next := sched.Next(from); len(got) < len(tc.want); next = sched.Next(next) {
+				got = append(got, next.UTC().Format(time.RFC3339))
+				if next.IsZero() {
+					break
+				}
+			}
+			if strings.Join(got, " ") != strings.Join(tc.want, " ") {
+				t.Errorf("got %v, want %v", got, tc.want)
+			}
+		})
+	}
+	if s, err := ParseInLocation("0 0 * * *", berlin); err != nil || s.(*SpecSchedule).Location != berlin {
+		t.Errorf("ParseInLocation did not use the location")
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		"* * * *",
+		"* * * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"a * * * *",
+		"* * * foo *",
+		"@every -1s",
+		"@every 1x",
+		"CRON_TZ=Nowhere/Special * * * * *",
+	} {
+		if _, err := Parse(spec); err == nil {
+			t.Errorf("Parse(%q): got nil error", spec)
+		} else if !strings.HasPrefix(err.Error(), "cron: ") {
+			t.Errorf("Parse(%q): error %q lacks the package prefix", spec, err)
+		}
+	}
+}
+
+func TestSpecScheduleString(t *testing.T) {
+	s, err := Parse("0 */6 1,15 * sun-tue")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := s.(*SpecSchedule).String(), "0 0 0,6,12,18 1,15 * 0,1,2"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestEveryFrom(t *testing.T) {
+	epoch := time.Date(2023, 5, 17, 0, 7, 0, 0, time.UTC)
+	sched := EveryFrom(epoch, 15*time.Minute)
+	for _, tc := range []struct {
+		from, want string
+	}{
+		{"2023-05-17T12:00:00Z", "2023-05-17T12:07:00Z"},
+		{"2023-05-17T12:07:00Z", "2023-05-17T12:22:00Z"},
+		{"2023-05-17T12:21:59Z", "2023-05-17T12:22:00Z"},
+		// Before the epoch.
+		{"2023-05-16T23:59:00Z", "2023-05-17T00:07:00Z"},
+		{"2023-05-16T23:52:00Z", "2023-05-17T00:07:00Z"},
+		{"2023-05-16T23:51:00Z", "2023-05-16T23:52:00Z"},
+	} {
+		from, _ := time.Parse(time.RFC3339, tc.from)
+		if got := sched.Next(from).UTC().Format(time.RFC3339); got != tc.want {
+			t.Errorf("Next(%s): got %s, want %s", tc.from, got, tc.want)
+		}
+	}
+}
+
+func TestEveryDate(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("time zone database unavailable: %v", err)
+	}
+	for _, tc := range []struct {
+		desc  string
+		sched Schedule
+		from  string
+		want  []string // Successive activations, in UTC.
+	}{
+		{"monthly on the 31st", EveryDate(time.Date(2023, 1, 31, 9, 0, 0, 0, time.UTC), 0, 1, 0), "2023-01-01T00:00:00Z",
+			[]string{"2023-01-31T09:00:00Z", "2023-02-28T09:00:00Z", "2023-03-31T09:00:00Z", "2023-04-30T09:00:00Z"}},
+		{"leap day", EveryDate(time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC), 1, 0, 0), "2024-03-01T00:00:00Z",
+			[]string{"2025-02-28T00:00:00Z", "2026-02-28T00:00:00Z", "2027-02-28T00:00:00Z", "2028-02-29T00:00:00Z"}},
+		{"quarterly from later", EveryDate(time.Date(2023, 1, 15, 0, 0, 0, 0, time.UTC), 0, 3, 0), "2024-05-20T00:00:00Z",
+			[]string{"2024-07-15T00:00:00Z", "2024-10-15T00:00:00Z"}},
+		// Berlin switches to summer time on 2023-03-26, and back on 2023-10-29.
+		{"every two weeks across DST", EveryDate(time.Date(2023, 3, 19, 9, 0, 0, 0, berlin), 0, 0, 14), "2023-03-19T09:00:00Z",
+			[]string{"2023-04-02T07:00:00Z", "2023-04-16T07:00:00Z"}},
+		{"daily across DST", EveryDate(time.Date(2023, 10, 27, 9, 0, 0, 0, berlin), 0, 0, 1), "2023-10-28T07:00:00Z",
+			[]string{"2023-10-29T08:00:00Z", "2023-10-30T08:00:00Z"}},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			from, _ := time.Parse(time.RFC3339, tc.from)
+			var got []string
+			for next := tc.sched.Next(from); len(got) < len(tc.want); next = tc.sched.Next(next) {
+				got = append(got, next.UTC().Format(time.RFC3339))
+			}
+			if strings.Join(got, " ") != strings.Join(tc.want, " ") {
+				t.Errorf("got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package cron
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// A JobState is the state of a job that a [Store] persists.
+type JobState struct {
+	ID ID `json:"id"`
+	// Prev is the time at which the last run started, or the zero time if the job has not run.
+	Prev time.Time `json:"prev"`
+	// Next is the time of the next run, or the zero time if the job is paused or has no further
+	// runs.
+	Next   time.Time `json:"next"`
+	Paused bool      `json:"paused,omitempty"`
+}
+
+// A Store persists the state of the jobs of a [Scheduler], so that a restarted process resumes its
+// schedules.  The scheduler does not call the methods of a Store concurrently.
+type Store interface {
+	// Load returns the saved states of all jobs.
+	Load() ([]JobState, error)
+	// Save saves the state of a job, replacing any previous state with the same ID.
+	Save(state JobState) error
+	// Delete deletes the state of the job with the given ID, if any.
+	Delete(id ID) error
+}
+
+// A FileStore is a [Store] that keeps the states of all jobs in a JSON file.  Each change rewrites
+// the file atomically, by writing a temporary file in the same directory and renaming it.
+type FileStore struct {
+	path string
+
+	mu     sync.Mutex      // protects:
+	states map[ID]JobState // Nil until the file is read.
+}
+
+// NewFileStore returns a [FileStore] that keeps its states in the file at path.  The file need not
+// exist.
+func NewFileStore(path string) *FileStore {
+	return &FileStore{path: path}
+}
+
+// Load returns the states saved in the file, ordered by ID.
+func (s *FileStore) Load() ([]JobState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.readLocked(); err != nil {
+		return nil, err
+	}
+	return sorted(s.states), nil
+}
+
+// Save saves state in the file.
+func (s *FileStore) Save(state JobState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.readLocked(); err != nil {
+		return err
+	}
+	states := maps.Clone(s.states)
+	states[state.ID] = state
+	return s.writeLocked(states)
+}
+
+// Delete deletes the state with the given ID from the file.
+func (s *FileStore) Delete(id ID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.readLocked(); err != nil {
+		return err
+	}
+	if _, ok := s.states[id]; !ok {
+		return nil
+	}
+	states := maps.Clone(s.states)
+	delete(states, id)
+	return s.writeLocked(states)
+}
+
+// readLocked reads the file unless it was already read.  s.mu must be held.
+func (s *FileStore) readLocked() error {
+	if s.states != nil {
+		return nil
+	}
+	data, err := os.ReadFile(s.path)
+	var states []JobState
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		return err
+	default:
+		if err := json.Unmarshal(data, &states); err != nil {
+			return &os.PathError{Op: "decode", Path: s.path, Err: err}
+		}
+	}
+	s.states = make(map[ID]JobState, len(states))
+	for _, st := range states {
+		s.states[st.ID] = st
+	}
+	return nil
+}
+
+func sorted(m map[ID]JobState) []JobState {
+	states := make([]JobState, 0, len(m))
+	for _, st := range m {
+		states = append(states, st)
+	}
+	slices.SortFunc(states, func(a, b JobState) int {
+		return strings.Compare(string(a.ID), string(b.ID))
+	})
+	return states
+}
+
+// writeLocked replaces the file with states, and makes states the current states once the file is
+// replaced, so that a failed write leaves the states unchanged.  s.mu must be held.
+func (s *FileStore) writeLocked(states map[ID]JobState) error {
+	data, err := json.MarshalIndent(sorted(states), "", "\t")
+	if err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name()) // Fails harmlessly once renamed.
+	_, err = f.Write(append(data, '\n'))
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(f.Name(), s.path); err != nil {
+		return err
+	}
+	s.states = states
+	return nil
+}
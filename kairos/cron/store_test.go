@@ -0,0 +1,130 @@
+package cron
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/rhansen/go-kairos/kairos"
+)
+
+func TestFileStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jobs.json")
+	fs := NewFileStore(path)
+	if states, err := fs.Load(); err != nil || len(states) != 0 {
+		t.Fatalf("Load of a missing file: got %v, %v, want no states", states, err)
+	}
+	a := JobState{ID: "a", Prev: start, Next: start.Add(time.Hour)}
+	b := JobState{ID: "b", Paused: true}
+	for _, st := range []JobState{b, a, {ID: "c"}} {
+		if err := fs.Save(st); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := fs.Delete("c"); err != nil {
+		t.Fatal(err)
+	}
+	// A new store reads what the first one wrote.
+	states, err := NewFileStore(path).Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []JobState{a, b}; !reflect.DeepEqual(states, want) {
+		t.Errorf("got states %+v, want %+v", states, want)
+	}
+
+	if err := os.WriteFile(path, []byte("{"), 0o666); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewFileStore(path).Load(); err == nil {
+		t.Errorf("Load of a corrupt file: got nil error")
+	}
+}
+
+func TestFileStoreFailedWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jobs.json")
+	fs := NewFileStore(path)
+	a := JobState{ID: "a", Next: start}
+	if err := fs.Save(a); err != nil {
+		t.Fatal(err)
+	}
+	// A non-empty directory in place of the file makes the rename fail.
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(path, "x"), 0o777); err != nil {
+		t.Fatal(err)
+	}
+	if err := fs.Save(JobState{ID: "b"}); err == nil {
+		t.Fatal("Save: got nil error, want the rename to fail")
+	}
+	if err := fs.Delete("a"); err == nil {
+		t.Fatal("Delete: got nil error, want the rename to fail")
+	}
+	if err := os.RemoveAll(path); err != nil {
+		t.Fatal(err)
+	}
+	// The failed changes must not reach the file with the next successful write.
+	c := JobState{ID: "c"}
+	if err := fs.Save(c); err != nil {
+		t.Fatal(err)
+	}
+	states, err := NewFileStore(path).Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []JobState{a, c}; !reflect.DeepEqual(states, want) {
+		t.Errorf("got states %+v, want %+v", states, want)
+	}
+}
+
+func TestSchedulerStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jobs.json")
+	daily, _ := ParseInLocation("0 8 * * *", time.UTC)
+	// run starts a scheduler with a fresh store at now, adds the jobs, lets d pass, and returns the
+	// times at which the job "daily" ran.
+	run := func(now time.Time, d time.Duration, pause bool) []time.Time {
+		fc := kairos.NewFakeClock(now, kairos.WithDeterministicDispatch())
+		defer fc.Close()
+		s := New(fc, WithStore(NewFileStore(path)))
+		var runs []time.Time
+		if err := s.AddJob("daily", daily, func(context.Context) error {
+			runs = append(runs, fc.Now())
+			return nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if pause {
+			s.PauseJob("daily")
+		}
+		fc.Advance(d)
+		if err := s.Close(); err != nil {
+			t.Errorf("Close: got error %v", err)
+		}
+		return runs
+	}
+	day := func(d, h int) time.Time { return time.Date(2023, 5, d, h, 0, 0, 0, time.UTC) }
+	for _, tc := range []struct {
+		name  string
+		now   time.Time
+		d     time.Duration
+		pause bool
+		want  []time.Time
+	}{
+		{"first start", day(17, 7), 2 * time.Hour, false, []time.Time{day(17, 8)}},
+		// The process is restarted before the next run: nothing runs twice.
+		{"early restart", day(17, 10), time.Hour, false, nil},
+		// The process was down at 08:00 on the 18th and 19th: one run catches up.
+		{"late restart", day(19, 12), 24 * time.Hour, false, []time.Time{day(19, 12), day(20, 8)}},
+		{"pause", day(20, 12), time.Hour, true, nil},
+		// The job stays paused across restarts.
+		{"paused restart", day(22, 12), 24 * time.Hour, false, nil},
+	} {
+		if got := run(tc.now, tc.d, tc.pause); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: got runs at %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,289 @@
+// Package debughttp serves the state of kairos clocks over HTTP for ad-hoc inspection of a running
+// service, in the manner of net/http/pprof: the armed timers, the counts of timer operations, the
+// state of the timer routine, and the recent late expirations, as an HTML page or, with
+// ?format=json or an Accept header of application/json, as JSON.
+//
+//	h := debughttp.New()
+//	c := kairos.NewClock(kairos.WithOnLateFire(100*time.Millisecond, h.OnLateFire))
+//	h.Track("main", c)
+//	http.Handle("/debug/kairos", h)
+//
+// The page lists the timers with the stacks that created them if the clocks record them; see
+// [kairos.WithCreationSites].  It should not be exposed to untrusted clients.
+package debughttp
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rhansen/go-kairos/kairos"
+)
+
+// maxLateFires is the number of recent late expirations a Handler keeps.
+const maxLateFires = 100
+
+// A Handler is an [http.Handler] that reports the state of the clocks it tracks.
+type Handler struct {
+	mu     sync.Mutex
+	clocks []namedClock
+	late   []LateFire // Recent late expirations, oldest first.
+	now    func() time.Time
+}
+
+type namedClock struct {
+	name string
+	c    kairos.Diagnostics
+}
+
+// A LateFire is a late expiration reported to [Handler.OnLateFire].
+type LateFire struct {
+	Time   time.Time     // Time at which the late expiration was reported.
+	Timer  uint64        // Number of the timer; see [kairos.Timer.ID].
+	Label  string        // Label of the timer.
+	LateBy time.Duration // How late the timer fired.
+}
+
+// New returns a new Handler with no clocks.
+func New() *Handler {
+	return &Handler{now: time.Now}
+}
+
+// Track adds c to the clocks reported by h, under name.  It panics if c does not implement
+// [kairos.Diagnostics], as the clocks of package kairos do.
+func (h *Handler) Track(name string, c kairos.Clock) {
+	d, ok := c.(kairos.Diagnostics)
+	if !ok {
+		panic(fmt.Sprintf("debughttp: %T does not implement kairos.Diagnostics", c))
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.clocks = append(h.clocks, namedClock{name, d})
+}
+
+// OnLateFire records a late expiration, to be listed among the recent late fires.  Pass it to
+// [kairos.WithOnLateFire] when creating the clocks to track.  Only the last 100 are kept.
+func (h *Handler) OnLateFire(t *kairos.Timer, lateBy time.Duration) {
+	f := LateFire{Time: h.now(), Timer: t.ID(), Label: t.Label(), LateBy: lateBy}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.late) == maxLateFires {
+		h.late = append(h.late[:0], h.late[1:]...)
+	}
+	h.late = append(h.late, f)
+}
+
+// report is the state served by a Handler.
+type report struct {
+	Time      time.Time     `json:"time"`
+	Clocks    []clockReport `json:"clocks"`
+	LateFires []lateReport  `json:"late_fires"`
+}
+
+type clockReport struct {
+	Name    string        `json:"name"`
+	Health  healthReport  `json:"health"`
+	Stats   statsReport   `json:"stats"`
+	Latency latencyReport `json:"fire_latency"`
+	Timers  []timerReport `json:"timers"`
+}
+
+type healthReport struct {
+	Running bool   `json:"running"`
+	Stopped bool   `json:"stopped"`
+	Pending int    `json:"pending"`
+	Lag     string `json:"lag"`
+}
+
+type statsReport struct {
+	Created        uint64 `json:"created"`
+	Reset          uint64 `json:"reset"`
+	Stopped        uint64 `json:"stopped"`
+	Fired          uint64 `json:"fired"`
+	CallbackPanics uint64 `json:"callback_panics"`
+	PeakPending    uint64 `json:"peak_pending"`
+	Wakeups        uint64 `json:"wakeups"`
+	RoutineFired   uint64 `json:"routine_fired"`
+}
+
+type latencyReport struct {
+	Count uint64 `json:"count"`
+	Mean  string `json:"mean"`
+	P99   string `json:"p99"`
+	Max   string `json:"max"`
+}
+
+type timerReport struct {
+	ID        uint64    `json:"id"`
+	When      time.Time `json:"when"`
+	Remaining string    `json:"remaining"`
+	Kind      string    `json:"kind"`
+	Label     string    `json:"label,omitempty"`
+	Created   []string  `json:"created,omitempty"`
+	Reset     []string  `json:"reset,omitempty"`
+}
+
+type lateReport struct {
+	Timer  uint64    `json:"timer"`
+	Time   time.Time `json:"time"`
+	Label  string    `json:"label,omitempty"`
+	LateBy string    `json:"late_by"`
+}
+
+// report returns the state of the tracked clocks.
+func (h *Handler) report() report {
+	h.mu.Lock()
+	clocks := append([]namedClock(nil), h.clocks...)
+	late := append([]LateFire(nil), h.late...)
+	h.mu.Unlock()
+
+	r := report{Time: h.now(), Clocks: []clockReport{}, LateFires: []lateReport{}}
+	for _, nc := range clocks {
+		health := nc.c.Health()
+		lat := nc.c.FireLatency()
+		stats := nc.c.Stats()
+		cr := clockReport{
+			Name: nc.name,
+			Health: healthReport{
+				Running: health.Running,
+				Stopped: health.Stopped,
+				Pending: health.Pending,
+				Lag:     health.Lag.String(),
+			},
+			Stats: statsReport{
+				Created:        stats.Created,
+				Reset:          stats.Reset,
+				Stopped:        stats.Stopped,
+				Fired:          stats.Fired,
+				CallbackPanics: stats.CallbackPanics,
+				PeakPending:    stats.PeakPending,
+				Wakeups:        stats.Wakeups,
+				RoutineFired:   stats.RoutineFired,
+			},
+			Latency: latencyReport{
+				Count: lat.Count,
+				Mean:  lat.Mean().String(),
+				P99:   lat.Quantile(0.99).String(),
+				Max:   lat.Max.String(),
+			},
+			Timers: []timerReport{},
+		}
+		for _, t := range nc.c.DumpTimers() {
+			kind := "timer"
+			switch {
+			case t.Period > 0:
+				kind = fmt.Sprintf("ticker every %v", t.Period)
+			case t.Func:
+				kind = "func"
+			}
+			cr.Timers = append(cr.Timers, timerReport{
+				ID:        t.ID,
+				When:      t.When,
+				Remaining: t.Remaining.String(),
+				Kind:      kind,
+				Label:     t.Label,
+				Created:   frames(t.Created),
+				Reset:     frames(t.Reset),
+			})
+		}
+		r.Clocks = append(r.Clocks, cr)
+	}
+	for i := len(late) - 1; i >= 0; i-- {
+		f := late[i]
+		r.LateFires = append(r.LateFires,
+			lateReport{Time: f.Time, Timer: f.Timer, Label: f.Label, LateBy: f.LateBy.String()})
+	}
+	return r
+}
+
+// frames formats the frames of a stack as "function file:line".
+func frames(stack []runtime.Frame) []string {
+	var s []string
+	for _, f := range stack {
+		s = append(s, fmt.Sprintf("%s %s:%d", f.Function, f.File, f.Line))
+	}
+	return s
+}
+
+// ServeHTTP implements [http.Handler].  The late fires are listed newest first.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rep := h.report()
+	w.Header().Set("Cache-Control", "no-cache")
+	if wantsJSON(r) {
+		w.Header().Set("Content-Type", "application/json")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		enc.Encode(rep)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	page.Execute(w, rep)
+}
+
+// wantsJSON reports whether the client asked for JSON.
+func wantsJSON(r *http.Request) bool {
+	return r.URL.Query().Get("format") == "json" ||
+		strings.Contains(r.Header.Get("Accept"), "application/json")
+}
+
+// timeFormat is the format of the times on the page.
+const timeFormat = "2006-01-02T15:04:05.000Z07:00"
+
+var page = template.Must(template.New("page").Funcs(template.FuncMap{
+	"time": func(t time.Time) string { return t.Format(timeFormat) },
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<title>kairos</title>
+<style>
+table { border-collapse: collapse; margin-bottom: 1em; }
+th, td { border: 1px solid #ccc; padding: 2px 6px; text-align: left; vertical-align: top; }
+pre { margin: 0; }
+</style>
+</head>
+<body>
+<p>As of {{time .Time}}.  <a href="?format=json">JSON</a></p>
+{{range .Clocks}}
+<h2>Clock {{.Name}}</h2>
+<table>
+<tr><th>Routine</th><td>
+{{- if .Health.Running}}running{{else if .Health.Stopped}}stopped{{else}}idle{{end}},
+lag {{.Health.Lag}}</td></tr>
+<tr><th>Armed</th><td>{{.Health.Pending}}, at most {{.Stats.PeakPending}}</td></tr>
+<tr><th>Created</th><td>{{.Stats.Created}}</td></tr>
+<tr><th>Reset</th><td>{{.Stats.Reset}}</td></tr>
+<tr><th>Stopped</th><td>{{.Stats.Stopped}}</td></tr>
+<tr><th>Fired</th><td>{{.Stats.Fired}}</td></tr>
+<tr><th>Callback panics</th><td>{{.Stats.CallbackPanics}}</td></tr>
+<tr><th>Wakeups</th><td>{{.Stats.Wakeups}}, firing {{.Stats.RoutineFired}}</td></tr>
+{{- with .Latency}}{{if .Count}}
+<tr><th>Fire latency</th><td>mean {{.Mean}}, p99 {{.P99}}, max {{.Max}}</td></tr>
+{{- end}}{{end}}
+</table>
+<table>
+<tr><th>ID</th><th>Deadline</th><th>Remaining</th><th>Kind</th><th>Label</th><th>Created at</th>
+<th>Last reset at</th></tr>
+{{- range .Timers}}
+<tr><td>{{.ID}}</td><td>{{time .When}}</td><td>{{.Remaining}}</td><td>{{.Kind}}</td><td>{{.Label}}</td>
+<td><pre>{{range .Created}}{{.}}
+{{end}}</pre></td>
+<td><pre>{{range .Reset}}{{.}}
+{{end}}</pre></td></tr>
+{{- end}}
+</table>
+{{end}}
+<h2>Recent late fires</h2>
+<table>
+<tr><th>Time</th><th>Timer</th><th>Label</th><th>Late by</th></tr>
+{{- range .LateFires}}
+<tr><td>{{time .Time}}</td><td>{{.Timer}}</td><td>{{.Label}}</td><td>{{.LateBy}}</td></tr>
+{{- end}}
+</table>
+</body>
+</html>
+`))
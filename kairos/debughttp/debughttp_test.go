@@ -0,0 +1,112 @@
+package debughttp
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rhansen/go-kairos/kairos"
+)
+
+var start = time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// newHandler returns a handler tracking a fake clock with a labeled timer armed, and a late fire of
+// the timer returned.
+func newHandler(t *testing.T) (*Handler, *kairos.Timer) {
+	h := New()
+	h.now = func() time.Time { return start }
+	fc := kairos.NewFakeClock(start, kairos.WithCreationSites())
+	t.Cleanup(func() { fc.Close() })
+	h.Track("main", fc)
+	fc.NewTimer(time.Minute).SetLabel("http-idle-timeout")
+	fc.After(time.Second)
+	fc.Advance(time.Second)
+	late := fc.NewStoppedTimer()
+	late.SetLabel("retry-backoff")
+	h.OnLateFire(late, 250*time.Millisecond)
+	return h, late
+}
+
+func TestJSON(t *testing.T) {
+	h, late := newHandler(t)
+	for _, req := range []*http.Request{
+		httptest.NewRequest("GET", "/debug/kairos?format=json", nil),
+		func() *http.Request {
+			r := httptest.NewRequest("GET", "/debug/kairos", nil)
+			r.Header.Set("Accept", "application/json")
+			return r
+		}(),
+	} {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		if got := w.Header().Get("Content-Type"); got != "application/json" {
+			t.Errorf("%v: got content type %q, want application/json", req.URL, got)
+		}
+		var rep report
+		if err := json.Unmarshal(w.Body.Bytes(), &rep); err != nil {
+			t.Fatalf("%v: %v", req.URL, err)
+		}
+		if len(rep.Clocks) != 1 {
+			t.Fatalf("%v: got %d clocks, want 1", req.URL, len(rep.Clocks))
+		}
+		c := rep.Clocks[0]
+		if c.Name != "main" || c.Stats.Created != 3 || c.Stats.Fired != 1 || c.Health.Pending != 1 {
+			t.Errorf("%v: got clock %+v, want main with 3 created, 1 fired, and 1 pending", req.URL, c)
+		}
+		if len(c.Timers) != 1 {
+			t.Fatalf("%v: got %d timers, want 1", req.URL, len(c.Timers))
+		}
+		if tr := c.Timers[0]; tr.Label != "http-idle-timeout" || tr.Remaining != "59s" || tr.Kind != "timer" ||
+			len(tr.Created) == 0 || !strings.Contains(tr.Created[0], "newHandler") {
+			t.Errorf("%v: got timer %+v, want http-idle-timeout in 59s created by newHandler", req.URL, tr)
+		}
+		want := []lateReport{{Timer: late.ID(), Time: start, Label: "retry-backoff", LateBy: "250ms"}}
+		if fmt.Sprint(rep.LateFires) != fmt.Sprint(want) {
+			t.Errorf("%v: got late fires %+v, want %+v", req.URL, rep.LateFires, want)
+		}
+	}
+}
+
+func TestHTML(t *testing.T) {
+	h, _ := newHandler(t)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/debug/kairos", nil))
+	if got := w.Header().Get("Content-Type"); !strings.HasPrefix(got, "text/html") {
+		t.Errorf("got content type %q, want text/html", got)
+	}
+	body := w.Body.String()
+	for _, want := range []string{
+		"<h2>Clock main</h2>",
+		"<td>http-idle-timeout</td>",
+		"<td>retry-backoff</td><td>250ms</td>",
+		"newHandler",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("page does not contain %q:\n%s", want, body)
+		}
+	}
+}
+
+func TestLateFiresLimit(t *testing.T) {
+	h := New()
+	fc := kairos.NewFakeClock(start)
+	defer fc.Close()
+	timer := fc.NewStoppedTimer()
+	for i := 1; i <= maxLateFires+10; i++ {
+		h.OnLateFire(timer, time.Duration(i))
+	}
+	rep := h.report()
+	if len(rep.LateFires) != maxLateFires {
+		t.Fatalf("got %d late fires, want %d", len(rep.LateFires), maxLateFires)
+	}
+	if got, want := rep.LateFires[0].LateBy, time.Duration(maxLateFires+10).String(); got != want {
+		t.Errorf("got newest late fire by %s, want %s", got, want)
+	}
+	if got, want := rep.LateFires[maxLateFires-1].LateBy, time.Duration(11).String(); got != want {
+		t.Errorf("got oldest late fire by %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,192 @@
+package kairos
+
+import (
+	"context"
+	"reflect"
+	"runtime"
+	"runtime/pprof"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// WithMaxBatch spreads the dispatch of a mass expiry, such as after the machine resumes from sleep
+// or a fake clock jumps, so that it does not stall the process: the timer routine of a clock
+// created by [NewClock] or [NewClockFromFunc] fires at most n expired timers per wakeup, then waits
+// for pause before firing the next n, so that the expirations of m timers are spread over about
+// m/n times pause.  A zero pause only lets other goroutines run between batches.  Timers that are
+// held back fire late, in deadline order.  A non-positive n means no limit, the default.  The
+// option has no effect with [WithRuntimeTimers], which fire each timer on its own, or on a
+// [FakeClock], whose Advance fires the timers synchronously.
+func WithMaxBatch(n int, pause time.Duration) ClockOption {
+	return func(cfg *clockConfig) { cfg.maxBatch, cfg.batchPause = n, max(pause, 0) }
+}
+
+// WithMaxConcurrentCallbacks limits the number of callbacks of AfterFunc timers and [TickFunc]
+// tickers of the clock that run at the same time to n, so that a mass expiry does not start a
+// goroutine for each of thousands of callbacks at once.  Callbacks that expire while n are running
+// are queued, and started in order as the running ones return; [Clock.Shutdown] waits for the
+// queued callbacks too.  A callback must therefore not wait for another callback of the same
+// clock, which may be queued behind it.  A non-positive n means no limit, the default.  The option
+// has no effect together with [WithDeterministicDispatch], which runs the callbacks one at a time.
+func WithMaxConcurrentCallbacks(n int) ClockOption {
+	return func(cfg *clockConfig) { cfg.maxCallbacks = n }
+}
+
+// WithProfilerLabels makes the clock run the callbacks of AfterFunc timers and [TickFunc] tickers
+// with pprof labels, so that CPU and goroutine profiles attribute their work to the timer that ran
+// it rather than to one anonymous goroutine.  The label "kairos_func" is the name of the callback
+// function, and "kairos_timer" the label of the timer set with [Timer.SetLabel] or
+// [Ticker.SetLabel], if any.  Labeling a callback allocates, so the option is off by default.
+func WithProfilerLabels() ClockOption {
+	return func(cfg *clockConfig) { cfg.pprofLabels = true }
+}
+
+// WithCallbackBudget makes the clock call f when a callback of an AfterFunc timer or [TickFunc]
+// ticker runs for longer than budget by the clock's time, with the timer, or the timer backing the
+// ticker, and how long the callback took, since a slow callback holds back the callbacks queued
+// behind it with [WithMaxConcurrentCallbacks] or [WithDeterministicDispatch].  f runs in the
+// goroutine of the callback, after the callback returns; it is not called for a callback that
+// panics.
+func WithCallbackBudget(budget time.Duration, f func(t *Timer, took time.Duration)) ClockOption {
+	return func(cfg *clockConfig) { cfg.budget = &callbackBudget{budget: max(budget, 0), f: f} }
+}
+
+type callbackBudget struct {
+	budget time.Duration
+	f      func(t *Timer, took time.Duration)
+}
+
+// A callbackLimit is the state of a clock's limit of running callbacks.
+type callbackLimit struct {
+	n       int
+	running int      // Number of goroutines running callbacks.
+	queue   []func() // Callbacks waiting for a running one to return.
+}
+
+// setDispatch applies the dispatch options of cfg to clk.
+func (clk *clock) setDispatch(cfg clockConfig) {
+	if cfg.maxBatch > 0 {
+		clk.maxBatch, clk.batchPause = cfg.maxBatch, cfg.batchPause
+	}
+	if cfg.maxCallbacks > 0 {
+		clk.callbacks = &callbackLimit{n: cfg.maxCallbacks}
+	}
+	clk.pprofLabels = cfg.pprofLabels
+	clk.budget = cfg.budget
+}
+
+// callbackLocked returns the callback f of timer t, whose function is fn, to be run with the pprof
+// labels of t if the clock has [WithProfilerLabels], logging its panic if the clock has
+// [WithLogger], and timed if the clock has [WithCallbackBudget].  The mutex must be held.
+func (clk *clock) callbackLocked(t *Timer, fn any, f func()) func() {
+	if b := clk.budget; b != nil {
+		f = func(f func()) func() {
+			return func() {
+				start := clk.now()
+				f()
+				if took := clk.now().Sub(start); took > b.budget {
+					b.f(t, took)
+				}
+			}
+		}(f)
+	}
+	if clk.log != nil && t.tk == nil {
+		// Log the panic before it crashes the program.  Tickers recover from theirs in callOne.
+		f = func(f func()) func() {
+			return func() {
+				defer func() {
+					if v := recover(); v != nil {
+						clk.logPanic(t, v)
+						panic(v)
+					}
+				}()
+				f()
+			}
+		}(f)
+	}
+	if !clk.pprofLabels {
+		return f
+	}
+	labels := []string{"kairos_func", runtime.FuncForPC(reflect.ValueOf(fn).Pointer()).Name()}
+	if t.label != "" {
+		labels = append(labels, "kairos_timer", t.label)
+	}
+	set := pprof.Labels(labels...)
+	return func() { pprof.Do(context.Background(), set, func(context.Context) { f() }) }
+}
+
+// A funcGroup waits for the callbacks of a clock, and the goroutines of its reports, like a
+// WaitGroup, and counts them for Stats.
+type funcGroup struct {
+	sync.WaitGroup
+	n atomic.Int64
+}
+
+func (g *funcGroup) Add(delta int) {
+	g.n.Add(int64(delta))
+	g.WaitGroup.Add(delta)
+}
+
+func (g *funcGroup) Done() {
+	g.n.Add(-1)
+	g.WaitGroup.Done()
+}
+
+// goLocked runs the callback f in its own goroutine, or queues it if the limit of running
+// callbacks is reached.  The mutex must be held.
+func (clk *clock) goLocked(f func()) {
+	clk.funcs.Add(1)
+	l := clk.callbacks
+	if l == nil {
+		go func() {
+			defer clk.funcs.Done()
+			f()
+		}()
+		return
+	}
+	if l.running == l.n {
+		l.queue = append(l.queue, f)
+		return
+	}
+	l.running++
+	go clk.runCallbacks(f)
+}
+
+// goFuncs runs the callbacks of AfterFunc timers fs like goLocked.  The mutex must not be held.
+func (clk *clock) goFuncs(fs []func()) {
+	if clk.callbacks == nil {
+		clk.funcs.Add(len(fs))
+		for _, f := range fs {
+			go func() {
+				defer clk.funcs.Done()
+				f()
+			}()
+		}
+		return
+	}
+	clk.mutex.Lock()
+	defer clk.mutex.Unlock()
+	for _, f := range fs {
+		clk.goLocked(f)
+	}
+}
+
+// runCallbacks runs f, then the queued callbacks in turn until none is left.
+func (clk *clock) runCallbacks(f func()) {
+	l := clk.callbacks
+	for {
+		f()
+		clk.funcs.Done()
+		clk.mutex.Lock()
+		if len(l.queue) == 0 {
+			l.running--
+			clk.mutex.Unlock()
+			return
+		}
+		f = l.queue[0]
+		l.queue[0] = nil
+		l.queue = l.queue[1:]
+		clk.mutex.Unlock()
+	}
+}
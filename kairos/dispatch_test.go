@@ -0,0 +1,158 @@
+package kairos
+
+import (
+	"runtime/pprof"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMaxBatch(t *testing.T) {
+	fc := NewFakeClock(fakeStart)
+	c := NewClockFromFunc(fc.Now, WithSleeper(NewClockSleeper(fc)), WithMaxBatch(10, time.Second))
+	defer c.Close()
+	timers := make([]*Timer, 25)
+	for i := range timers {
+		timers[i] = c.NewTimer(time.Second)
+	}
+	fc.BlockUntil(1)
+	for _, batch := range []struct {
+		first, end int // Range of the timers fired by the batch.
+	}{
+		{0, 10},
+		{10, 20},
+		{20, 25},
+	} {
+		fc.Advance(time.Second)
+		for i := batch.first; i < batch.end; i++ {
+			if got, want := waitFired(t, timers[i]), fc.Now(); !got.Equal(want) {
+				t.Errorf("timer %d: got fire time %v, want %v", i, got, want)
+			}
+		}
+		if batch.end < len(timers) {
+			fc.BlockUntil(1) // The timer routine went back to sleep.
+			if _, ok := recv(timers[batch.end].C); ok {
+				t.Errorf("timer %d fired in the batch of timers %d to %d", batch.end, batch.first, batch.end-1)
+			}
+		}
+	}
+	if got := diagnostics(c).Pending(); got != 0 {
+		t.Errorf("got %d pending timers, want 0", got)
+	}
+}
+
+func TestMaxConcurrentCallbacks(t *testing.T) {
+	for _, tc := range []struct {
+		desc string
+		c    Clock
+	}{
+		{"fake", NewFakeClock(fakeStart, WithMaxConcurrentCallbacks(2))},
+		{"real", NewClock(WithMaxConcurrentCallbacks(2))},
+		{"runtime timers", NewClock(WithRuntimeTimers(), WithMaxConcurrentCallbacks(2))},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			const n = 20
+			var running, peak atomic.Int64
+			run := func() {
+				r := running.Add(1)
+				for p := peak.Load(); r > p && !peak.CompareAndSwap(p, r); p = peak.Load() {
+				}
+				time.Sleep(time.Millisecond)
+				running.Add(-1)
+			}
+			var called sync.WaitGroup
+			called.Add(n)
+			for i := 0; i < n; i++ {
+				tc.c.AfterFunc(time.Millisecond, func() {
+					run()
+					called.Done()
+				})
+			}
+			tk := tc.c.TickFunc(time.Millisecond, func(time.Time) { run() })
+			if fc, ok := tc.c.(*FakeClock); ok {
+				fc.Advance(time.Millisecond)
+			}
+			called.Wait()
+			tk.Stop()
+			if got := peak.Load(); got > 2 {
+				t.Errorf("got %d callbacks running at once, want at most 2", got)
+			}
+			if err := tc.c.Close(); err != nil {
+				t.Errorf("Close: got error %v, want nil", err)
+			}
+		})
+	}
+}
+
+func TestProfilerLabels(t *testing.T) {
+	for _, tc := range []struct {
+		desc string
+		c    Clock
+	}{
+		{"fake", NewFakeClock(fakeStart, WithProfilerLabels())},
+		{"fake deterministic", NewFakeClock(fakeStart, WithProfilerLabels(), WithDeterministicDispatch())},
+		{"real", NewClock(WithProfilerLabels())},
+		{"runtime timers", NewClock(WithRuntimeTimers(), WithProfilerLabels())},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			defer tc.c.Close()
+			// The goroutine profile lists the labels of each goroutine, including the callback's.
+			profile := func(profiles chan<- string) {
+				var b strings.Builder
+				pprof.Lookup("goroutine").WriteTo(&b, 1)
+				select {
+				case profiles <- b.String():
+				default: // A later tick.
+				}
+			}
+			timerProfile, tickProfile := make(chan string, 1), make(chan string, 1)
+			// Label the timer and the ticker before they can fire.
+			timer := tc.c.AfterFunc(time.Hour, func() { profile(timerProfile) })
+			timer.SetLabel("heartbeat")
+			timer.Reset(time.Millisecond)
+			tk := tc.c.TickFunc(time.Hour, func(time.Time) { profile(tickProfile) })
+			tk.SetLabel("poll")
+			tk.Reset(time.Millisecond)
+			if fc, ok := tc.c.(*FakeClock); ok {
+				fc.Advance(time.Millisecond)
+			}
+			got := []string{<-timerProfile, <-tickProfile}
+			tk.Stop()
+			for _, want := range []string{`"kairos_timer":"heartbeat"`, `"kairos_timer":"poll"`, `"kairos_func":"`} {
+				if !strings.Contains(got[0], want) && !strings.Contains(got[1], want) {
+					t.Errorf("got no goroutine labeled %s in the profiles", want)
+				}
+			}
+		})
+	}
+}
+
+func TestCallbackBudget(t *testing.T) {
+	type overrun struct {
+		label string
+		took  time.Duration
+	}
+	overruns := make(chan overrun, 10)
+	fc := NewFakeClock(fakeStart, WithCallbackBudget(time.Second, func(t *Timer, took time.Duration) {
+		overruns <- overrun{t.Label(), took}
+	}))
+	// The slow callback takes 2s of the fake clock's time.
+	slow := fc.AfterFunc(time.Hour, func() { fc.Advance(2 * time.Second) })
+	slow.SetLabel("slow")
+	slow.Reset(time.Millisecond)
+	fc.Advance(time.Millisecond)
+	if got, want := <-overruns, (overrun{"slow", 2 * time.Second}); got != want {
+		t.Errorf("got overrun %v, want %v", got, want)
+	}
+	fast := fc.AfterFunc(time.Millisecond, func() {})
+	fast.SetLabel("fast")
+	fc.Advance(time.Millisecond)
+	fc.Close()
+	select {
+	case o := <-overruns:
+		t.Errorf("got overrun %v of a fast callback, want none", o)
+	default:
+	}
+}
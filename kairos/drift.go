@@ -0,0 +1,141 @@
+package kairos
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// A DriftEvent is a misbehavior of a clock's wall time detected by a [DriftMonitor]: either a step,
+// as reported by a [ClockWatcher], or a drift, the wall clock running slower or faster than the
+// monotonic clock, as when NTP slews it to correct a large offset or a misconfigured daemon keeps
+// adjusting it.
+type DriftEvent struct {
+	// Time is the clock's time when the event was detected.
+	Time time.Time
+	// Step is, for a step, how far the wall time moved beyond the time that elapsed over the last
+	// interval, positive if it jumped forward, and zero for a drift.
+	Step time.Duration
+	// Drift is, for a drift, the rate at which the wall time gained on the monotonic clock over
+	// Span, in parts per million, positive if the wall clock runs fast, and zero for a step.
+	Drift float64
+	// Span is the monotonic time over which the drift was measured, since the monitor started or
+	// the last step.
+	Span time.Duration
+}
+
+// A DriftMonitor compares, at regular intervals, how far a clock's wall time moved with how much
+// time elapsed on its monotonic clock, and reports the steps and drifts of the wall time.  As for
+// a [ClockWatcher], the clock's times must carry a monotonic clock reading for anything to be
+// detected.  The steps are detected as by [WithClockChangeDetection], and a clock created with that
+// option re-evaluates its timers armed for wall-clock times as soon as the monitor detects a step,
+// without waiting for its own check.
+type DriftMonitor struct {
+	tk             *Ticker
+	report         func(DriftEvent)
+	driftThreshold float64
+	// If non-nil, makes the clock re-evaluate its wall-clock timers; see WithClockChangeDetection.
+	stepped func()
+
+	mu       sync.Mutex // protects:
+	stopped  bool
+	steps    stepDetector
+	span     time.Duration // Monotonic time elapsed since the start of the drift measurement.
+	gain     time.Duration // Time the wall clock gained on the monotonic clock over span.
+	drifting bool          // Whether the drift is beyond the threshold, and was reported.
+}
+
+// NewDriftMonitor returns a [DriftMonitor] that checks c every interval and calls report with the
+// steps of its wall time larger than stepThreshold, and with its drifts larger than
+// driftThreshold parts per million.  The drift is measured over the time since the monitor
+// started, or since the last step, so its estimate gets more precise over time; a drift is
+// reported once when it crosses the threshold, and again only after it has gone back within the
+// threshold.  A non-positive driftThreshold disables the reports of drifts.  report is called
+// from the goroutine of a [TickFunc] ticker of c, one call at a time, and may call the methods of
+// the monitor.
+// NewDriftMonitor panics if interval is not positive.
+func NewDriftMonitor(c Clock, interval, stepThreshold time.Duration, driftThreshold float64,
+	report func(DriftEvent)) *DriftMonitor {
+	if interval <= 0 {
+		panic("kairos: non-positive interval for NewDriftMonitor")
+	}
+	m := &DriftMonitor{
+		report:         report,
+		driftThreshold: driftThreshold,
+		steps:          newStepDetector(stepThreshold),
+	}
+	if s, ok := c.(wallStepper); ok {
+		m.stepped = s.wallStepped
+	}
+	m.steps.prev = c.Now()
+	m.tk = c.TickFunc(interval, func(time.Time) { m.check(c.Now()) })
+	return m
+}
+
+// check compares the wall time and the monotonic time elapsed since the previous check, and
+// reports the event detected, if any, after releasing the mutex, so that report may call the
+// methods of m.
+func (m *DriftMonitor) check(now time.Time) {
+	e, ok := m.update(now)
+	if !ok {
+		return
+	}
+	if e.Step != 0 && m.stepped != nil {
+		m.stepped()
+	}
+	m.report(e)
+}
+
+// update updates the measurement of the drift with the check at now, and returns the event to
+// report, if any.
+func (m *DriftMonitor) update(now time.Time) (DriftEvent, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.stopped {
+		return DriftEvent{}, false
+	}
+	mono, gain, step := m.steps.check(now)
+	if step {
+		// Start measuring the drift afresh, as the step may have come with a change of rate.
+		m.span, m.gain, m.drifting = 0, 0, false
+		return DriftEvent{Time: now, Step: gain}, true
+	}
+	m.span += mono
+	m.gain += gain
+	if m.driftThreshold <= 0 || m.span <= 0 {
+		return DriftEvent{}, false
+	}
+	drift := m.driftLocked()
+	wasDrifting := m.drifting
+	m.drifting = math.Abs(drift) > m.driftThreshold
+	if m.drifting && !wasDrifting {
+		return DriftEvent{Time: now, Drift: drift, Span: m.span}, true
+	}
+	return DriftEvent{}, false
+}
+
+// driftLocked returns the drift measured over m.span, in parts per million.  The mutex must be
+// held.
+func (m *DriftMonitor) driftLocked() float64 {
+	if m.span <= 0 {
+		return 0
+	}
+	return float64(m.gain) / float64(m.span) * 1e6
+}
+
+// Drift returns the current estimate of the drift of the wall time, in parts per million, and the
+// monotonic time over which it was measured.
+func (m *DriftMonitor) Drift() (ppm float64, span time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.driftLocked(), m.span
+}
+
+// Stop stops the monitor.  report is not called after Stop returns, except to finish a call for a
+// check already in progress.
+func (m *DriftMonitor) Stop() {
+	m.tk.Stop()
+	m.mu.Lock()
+	m.stopped = true
+	m.mu.Unlock()
+}
@@ -0,0 +1,103 @@
+package kairos
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestDriftMonitor(t *testing.T) {
+	fc := NewFakeClock(fakeStart, WithDeterministicDispatch())
+	defer fc.Close()
+	var events []DriftEvent
+	m := NewDriftMonitor(fc, time.Second, time.Minute, 100, func(e DriftEvent) {
+		events = append(events, e)
+	})
+	defer m.Stop()
+	// The wall time advances by a second between two checks, and the time that elapses on the
+	// simulated monotonic clock is given by each step.
+	var mono time.Duration
+	m.steps.elapsed = func(prev, now time.Time) time.Duration { return mono }
+	for _, step := range []struct {
+		desc string
+		mono time.Duration // Elapsed monotonic time.
+		want *DriftEvent   // Expected event, if any.
+	}{
+		{"steady", time.Second, nil},
+		{"small drift", time.Second - 50*time.Microsecond, nil},
+		// 400µs gained over 3s of wall time, less the gain.
+		{"large drift", time.Second - 350*time.Microsecond,
+			&DriftEvent{Drift: 400e-6 / (3 - 400e-6) * 1e6}},
+		{"still drifting", time.Second - 350*time.Microsecond, nil},
+		{"set back", time.Second + time.Hour, &DriftEvent{Step: -time.Hour}},
+		{"steady after step", time.Second, nil},
+		{"slow", time.Second + 300*time.Microsecond, &DriftEvent{Drift: -300e-6 / (2 + 300e-6) * 1e6}},
+	} {
+		events = nil
+		mono = step.mono
+		fc.Advance(time.Second)
+		switch {
+		case step.want == nil && len(events) > 0:
+			t.Errorf("%s: got events %+v, want none", step.desc, events)
+		case step.want != nil && len(events) != 1:
+			t.Errorf("%s: got events %+v, want one", step.desc, events)
+		case step.want != nil:
+			got, want := events[0], *step.want
+			if !got.Time.Equal(fc.Now()) || got.Step != want.Step || math.Abs(got.Drift-want.Drift) > 1e-6 {
+				t.Errorf("%s: got event %+v, want %+v at %v", step.desc, got, want, fc.Now())
+			}
+		}
+	}
+	if ppm, span := m.Drift(); math.Abs(ppm+150) > 0.1 || span != 2*time.Second+300*time.Microsecond {
+		t.Errorf("got drift %v ppm over %v, want about -150 ppm over 2.0003s", ppm, span)
+	}
+	m.Stop()
+	events = nil
+	fc.Advance(8 * time.Hour)
+	if len(events) > 0 {
+		t.Errorf("got events %+v after Stop, want none", events)
+	}
+}
+
+func TestDriftMonitorStopFromReport(t *testing.T) {
+	fc := NewFakeClock(fakeStart, WithDeterministicDispatch())
+	defer fc.Close()
+	var m *DriftMonitor
+	n := 0
+	m = NewDriftMonitor(fc, time.Second, time.Minute, 0, func(DriftEvent) {
+		n++
+		m.Drift()
+		m.Stop()
+	})
+	// Every check sees the wall clock set back by an hour.
+	m.steps.elapsed = func(prev, now time.Time) time.Duration { return time.Hour }
+	fc.Advance(time.Second)
+	fc.Advance(time.Second)
+	if n != 1 {
+		t.Errorf("got %d reports, want 1 before report stopped the monitor", n)
+	}
+}
+
+func TestDriftMonitorRearms(t *testing.T) {
+	c := NewClock(WithClockChangeDetection(time.Hour, time.Minute)).(*clock)
+	defer c.Close()
+	// Keep the routine from taking the notification.
+	if err := c.StopRunner(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	m := NewDriftMonitor(c, time.Hour, time.Minute, 0, func(DriftEvent) {})
+	defer m.Stop()
+	m.mu.Lock()
+	m.steps.elapsed = func(prev, now time.Time) time.Duration { return 0 }
+	prev := m.steps.prev
+	m.mu.Unlock()
+	m.check(prev.Add(time.Second))
+	if len(c.stepC) != 0 {
+		t.Errorf("clock notified of a step of a second, below the threshold")
+	}
+	m.check(prev.Add(2 * time.Hour))
+	if len(c.stepC) != 1 {
+		t.Errorf("clock not notified of a step of two hours")
+	}
+}
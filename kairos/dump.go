@@ -0,0 +1,177 @@
+package kairos
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+)
+
+// WithCreationSites makes the clock record the stack of the code that creates each timer and
+// ticker, and of the code that last starts or resets it, reported by [Diagnostics.DumpTimers] and
+// by [WithLeakDetection], to trace leaked or runaway timers back to their origin.  It is a
+// debugging aid: recording the stacks makes creating and resetting timers slower and allocate.
+func WithCreationSites() ClockOption {
+	return func(cfg *clockConfig) { cfg.sites = true }
+}
+
+// A TimerInfo describes an armed timer in the result of [Diagnostics.DumpTimers].
+type TimerInfo struct {
+	ID        uint64        // Number of the timer; see [Timer.ID].
+	When      time.Time     // Deadline of the timer.
+	Remaining time.Duration // Time left until the deadline at the dump, negative if overdue.
+	Label     string        // Label set with [Timer.SetLabel] or [Ticker.SetLabel].
+	Func      bool          // True if the timer was created by AfterFunc or InitTimer.
+	Period    time.Duration // Interval between ticks if the timer backs a [Ticker], or 0.
+	// Created is the stack of the code that created the timer, innermost first, without the frames
+	// of this package, if recorded; see [WithCreationSites] and [WithLeakDetection].
+	Created []runtime.Frame
+	// Reset is the stack of the code that last started or reset the timer, in the same form, if
+	// recorded; see [WithCreationSites].  The restarts of a ticker after its ticks are not resets.
+	Reset []runtime.Frame
+}
+
+// writeFrames writes the frames of a stack recorded for a timer, if any, under a heading.
+func writeFrames(b *strings.Builder, heading string, frames []runtime.Frame) {
+	if len(frames) == 0 {
+		return
+	}
+	fmt.Fprintf(b, "\t%s:\n", heading)
+	for _, f := range frames {
+		fmt.Fprintf(b, "\t\t%s\n\t\t\t%s:%d\n", f.Function, f.File, f.Line)
+	}
+}
+
+// maxStack is the maximum number of frames recorded of the stack that created or reset a timer.
+const maxStack = 32
+
+// callers returns the stack of the code creating or resetting a timer.
+func callers() []uintptr {
+	pc := make([]uintptr, maxStack)
+	return pc[:runtime.Callers(2, pc)]
+}
+
+// pkgPrefix is the prefix of the names of the functions of this package.
+var pkgPrefix = reflect.TypeOf(clock{}).PkgPath() + "."
+
+// creationFrames returns the frames of stack outside this package, tests excepted.
+func creationFrames(stack []uintptr) []runtime.Frame {
+	if len(stack) == 0 {
+		return nil
+	}
+	var frames []runtime.Frame
+	it := runtime.CallersFrames(stack)
+	for {
+		f, more := it.Next()
+		if !strings.HasPrefix(f.Function, pkgPrefix) || strings.HasSuffix(f.File, "_test.go") {
+			frames = append(frames, f)
+		}
+		if !more {
+			return frames
+		}
+	}
+}
+
+// DumpTimers returns a description of the armed timers, in deadline order.
+func (clk *clock) DumpTimers() []TimerInfo {
+	now := clk.now()
+	clk.mutex.Lock()
+	timers := clk.timers.AppendTo(nil)
+	for t := range clk.rtimers {
+		timers = append(timers, t)
+	}
+	descs := make([]timerDesc, len(timers))
+	for i, t := range timers {
+		descs[i] = describeLocked(t, now)
+	}
+	clk.mutex.Unlock()
+	return resolveDescs(descs)
+}
+
+// A timerDesc is the description of a timer taken with the clock's mutex held, whose stacks are
+// resolved into frames after releasing it.
+type timerDesc struct {
+	info           TimerInfo
+	created, reset []uintptr
+}
+
+// describeLocked describes the armed timer t at time now.  The mutex must be held.
+func describeLocked(t *Timer, now time.Time) timerDesc {
+	return timerDesc{
+		info: TimerInfo{
+			ID:        t.id,
+			When:      t.when,
+			Remaining: t.when.Sub(now),
+			Label:     t.label,
+			Func:      t.f != nil,
+			Period:    t.period,
+		},
+		created: t.stack,
+		reset:   t.resetStack,
+	}
+}
+
+// resolveDescs returns the descriptions descs with their stacks, in deadline order.
+func resolveDescs(descs []timerDesc) []TimerInfo {
+	infos := make([]TimerInfo, len(descs))
+	for i, d := range descs {
+		infos[i] = d.resolve()
+	}
+	sortTimerInfos(infos)
+	return infos
+}
+
+// resolve returns the description with its stacks.
+func (d timerDesc) resolve() TimerInfo {
+	info := d.info
+	info.Created = creationFrames(d.created)
+	info.Reset = creationFrames(d.reset)
+	return info
+}
+
+// DumpTimers returns the armed timers of every shard, in deadline order.
+func (sc *shardedClock) DumpTimers() []TimerInfo {
+	var infos []TimerInfo
+	for _, shard := range sc.shards {
+		infos = append(infos, shard.DumpTimers()...)
+	}
+	sortTimerInfos(infos)
+	return infos
+}
+
+func sortTimerInfos(infos []TimerInfo) {
+	sort.SliceStable(infos, func(i, j int) bool { return infos[i].When.Before(infos[j].When) })
+}
+
+// WriteTimers writes a human-readable description of timers, as returned by
+// [Diagnostics.DumpTimers], to w: one line per timer, with its deadline, the time remaining, its
+// kind, and its label, followed by the stack that created it, if recorded.
+func WriteTimers(w io.Writer, timers []TimerInfo) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d armed timers\n", len(timers))
+	for _, t := range timers {
+		kind := "timer"
+		switch {
+		case t.Period > 0:
+			kind = fmt.Sprintf("ticker every %v", t.Period)
+		case t.Func:
+			kind = "func"
+		}
+		due := fmt.Sprintf("in %v", t.Remaining)
+		if t.Remaining < 0 {
+			due = fmt.Sprintf("overdue by %v", -t.Remaining)
+		}
+		fmt.Fprintf(&b, "%v (%s): #%d %s", t.When.Format(time.RFC3339Nano), due, t.ID, kind)
+		if t.Label != "" {
+			fmt.Fprintf(&b, " %q", t.Label)
+		}
+		b.WriteString("\n")
+		writeFrames(&b, "created at", t.Created)
+		writeFrames(&b, "last reset at", t.Reset)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,118 @@
+package kairos
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDumpTimers(t *testing.T) {
+	fc := NewFakeClock(fakeStart, WithCreationSites())
+	defer fc.Close()
+	ticker := fc.NewTicker(3 * time.Second)
+	defer ticker.Stop()
+	ticker.SetLabel("poll")
+	fc.AfterFunc(2*time.Second, func() {})
+	timer := fc.NewTimer(time.Second)
+	timer.SetLabel("request")
+	fc.NewTimer(time.Hour).Stop()
+	fc.Advance(500 * time.Millisecond)
+
+	infos := fc.DumpTimers()
+	for i, want := range []TimerInfo{
+		{ID: timer.ID(), When: fakeStart.Add(time.Second), Remaining: 500 * time.Millisecond, Label: "request"},
+		{When: fakeStart.Add(2 * time.Second), Remaining: 1500 * time.Millisecond, Func: true},
+		{ID: ticker.ID(), When: fakeStart.Add(3 * time.Second), Remaining: 2500 * time.Millisecond, Label: "poll", Period: 3 * time.Second},
+	} {
+		if i >= len(infos) {
+			t.Fatalf("got %d timers, want 3", len(infos))
+		}
+		got := infos[i]
+		if want.ID != 0 && got.ID != want.ID {
+			t.Errorf("timer %d: got ID %d, want %d", i, got.ID, want.ID)
+		}
+		if !got.When.Equal(want.When) || got.Remaining != want.Remaining || got.Label != want.Label ||
+			got.Func != want.Func || got.Period != want.Period {
+			t.Errorf("timer %d: got %+v, want %+v", i, got, want)
+		}
+		if len(got.Created) == 0 || !strings.HasSuffix(got.Created[0].Function, ".TestDumpTimers") {
+			t.Errorf("timer %d: got creation site %v, want TestDumpTimers", i, got.Created)
+		}
+	}
+	if len(infos) != 3 {
+		t.Errorf("got %d timers, want 3", len(infos))
+	}
+
+	var b strings.Builder
+	if err := WriteTimers(&b, infos); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"3 armed timers\n",
+		fmt.Sprintf(`(in 500ms): #%d timer "request"`, timer.ID()) +
+			"\n\tcreated at:\n\t\tgithub.com/rhansen/go-kairos/kairos.TestDumpTimers\n",
+		fmt.Sprintf("(in 1.5s): #%d func\n", infos[1].ID),
+		fmt.Sprintf(`(in 2.5s): #%d ticker every 3s "poll"`, ticker.ID()),
+		"dump_test.go:",
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("WriteTimers: got %q, want it to contain %q", b.String(), want)
+		}
+	}
+}
+
+// createTimer and resetTimer are the creation and reset sites of the timer of TestResetSites.
+func createTimer(c Clock) *Timer { return c.NewTimer(time.Hour) }
+func resetTimer(t *Timer)        { t.Reset(time.Minute) }
+
+func TestResetSites(t *testing.T) {
+	fc := NewFakeClock(fakeStart, WithCreationSites())
+	defer fc.Close()
+	timer := createTimer(fc)
+	resetTimer(timer)
+	infos := fc.DumpTimers()
+	if len(infos) != 1 {
+		t.Fatalf("got %d timers, want 1", len(infos))
+	}
+	for _, tc := range []struct {
+		desc   string
+		frames []runtime.Frame
+		want   string
+	}{
+		{"creation", infos[0].Created, ".createTimer"},
+		{"reset", infos[0].Reset, ".resetTimer"},
+	} {
+		if len(tc.frames) == 0 || !strings.HasSuffix(tc.frames[0].Function, tc.want) {
+			t.Errorf("got %s site %v, want %s", tc.desc, tc.frames, tc.want[1:])
+		}
+	}
+	var b strings.Builder
+	if err := WriteTimers(&b, infos); err != nil {
+		t.Fatal(err)
+	}
+	want := "\tlast reset at:\n\t\tgithub.com/rhansen/go-kairos/kairos.resetTimer\n"
+	if !strings.Contains(b.String(), want) {
+		t.Errorf("WriteTimers: got %q, want it to contain %q", b.String(), want)
+	}
+}
+
+func TestDumpTimersWithoutSites(t *testing.T) {
+	c := NewClock(WithShards(2))
+	defer c.Close()
+	c.NewTimer(2 * time.Hour)
+	c.NewTimer(time.Hour)
+	infos := diagnostics(c).DumpTimers()
+	if len(infos) != 2 {
+		t.Fatalf("got %d timers, want 2", len(infos))
+	}
+	if !infos[0].When.Before(infos[1].When) {
+		t.Errorf("got deadlines %v and %v, want them in order", infos[0].When, infos[1].When)
+	}
+	for i, info := range infos {
+		if info.Created != nil {
+			t.Errorf("timer %d: got creation site %v, want none", i, info.Created)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package kairos
+
+import (
+	"expvar"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// WithExpvar publishes statistics of a clock created by [NewClock], [NewClockFromFunc], or
+// [NewFakeClock] with the expvar package, for services that do not export metrics with the
+// prommetrics package:
+//
+//   - prefix.active_timers, the number of armed timers, including those backing tickers;
+//   - prefix.next_deadline, the deadline of the next timer to fire, or null if none is armed;
+//   - prefix.fires_per_second, the number of timer expirations and ticks per second of the
+//     clock's time, averaged between reads at least a second apart;
+//   - prefix.peak_timers, the largest number of timers armed at once;
+//   - prefix.wakeups_per_second, the number of wakeups of the timer routine per second, averaged
+//     like fires_per_second;
+//   - prefix.fires_per_wakeup, the average number of timers fired per wakeup of the timer
+//     routine; see [Stats.FiresPerWakeup].
+//
+// Since expvar variables cannot be removed, a clock created later with the same prefix takes over
+// the variables.  Publishing panics if a variable of the same name was published by other means.
+func WithExpvar(prefix string) ClockOption {
+	return func(cfg *clockConfig) { cfg.expvar = prefix }
+}
+
+// A statsSource is a clock whose statistics can be published with WithExpvar.
+type statsSource interface {
+	Clock
+	Diagnostics
+	nextDeadline() (time.Time, bool)
+}
+
+// expvarStats holds the clock whose statistics are published under a prefix.
+type expvarStats struct {
+	c       statsSource
+	rate    rateMeter
+	wakeups rateMeter
+}
+
+// expvarPrefixes maps the prefixes published by WithExpvar to the *atomic.Pointer[expvarStats]
+// read by their variables.
+var expvarPrefixes sync.Map
+
+// publishExpvar publishes the statistics of c under prefix, or makes the variables already
+// published under prefix report c.
+func publishExpvar(prefix string, c statsSource) {
+	v, loaded := expvarPrefixes.LoadOrStore(prefix, new(atomic.Pointer[expvarStats]))
+	p := v.(*atomic.Pointer[expvarStats])
+	p.Store(&expvarStats{c: c})
+	if loaded {
+		return
+	}
+	expvar.Publish(prefix+".active_timers", expvar.Func(func() any {
+		return p.Load().c.Pending()
+	}))
+	expvar.Publish(prefix+".next_deadline", expvar.Func(func() any {
+		if when, ok := p.Load().c.nextDeadline(); ok {
+			return when
+		}
+		return nil
+	}))
+	expvar.Publish(prefix+".fires_per_second", expvar.Func(func() any {
+		s := p.Load()
+		return s.rate.read(s.c.Now(), s.c.Stats().Fired)
+	}))
+	expvar.Publish(prefix+".peak_timers", expvar.Func(func() any {
+		return p.Load().c.Stats().PeakPending
+	}))
+	expvar.Publish(prefix+".wakeups_per_second", expvar.Func(func() any {
+		s := p.Load()
+		return s.wakeups.read(s.c.Now(), s.c.Stats().Wakeups)
+	}))
+	expvar.Publish(prefix+".fires_per_wakeup", expvar.Func(func() any {
+		return p.Load().c.Stats().FiresPerWakeup()
+	}))
+}
+
+// A rateMeter computes the rate of a counter between reads.
+type rateMeter struct {
+	mu   sync.Mutex
+	t    time.Time // Time of the last sample, or zero before the first read.
+	n    uint64    // Counter at the last sample.
+	rate float64   // Rate between the last two samples.
+}
+
+// read returns the rate of the counter, whose value is n at time now, per second.  The rate is
+// only updated when a second has passed since the last update, so that frequent reads do not
+// report the noise of short intervals.
+func (r *rateMeter) read(now time.Time, n uint64) float64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.t.IsZero() {
+		r.t, r.n = now, n
+		return 0
+	}
+	if d := now.Sub(r.t); d >= time.Second {
+		r.rate = float64(n-r.n) / d.Seconds()
+		r.t, r.n = now, n
+	}
+	return r.rate
+}
+
+// nextDeadline returns the deadline of the next timer to fire.  ok is false if no timer is armed.
+func (clk *clock) nextDeadline() (when time.Time, ok bool) {
+	clk.mutex.Lock()
+	defer clk.mutex.Unlock()
+	for t := range clk.rtimers {
+		if !ok || t.when.Before(when) {
+			when, ok = t.when, true
+		}
+	}
+	if t := clk.peekLocked(); t != nil && (!ok || t.when.Before(when)) {
+		when, ok = t.when, true
+	}
+	return when, ok
+}
+
+func (sc *shardedClock) nextDeadline() (when time.Time, ok bool) {
+	for _, shard := range sc.shards {
+		if w, sok := shard.nextDeadline(); sok && (!ok || w.Before(when)) {
+			when, ok = w, true
+		}
+	}
+	return when, ok
+}
@@ -0,0 +1,48 @@
+package kairos
+
+import (
+	"expvar"
+	"testing"
+	"time"
+)
+
+func TestExpvar(t *testing.T) {
+	get := func(name string) string {
+		t.Helper()
+		v := expvar.Get("kairos_test." + name)
+		if v == nil {
+			t.Fatalf("variable %s not published", name)
+		}
+		return v.String()
+	}
+	// A later clock with the same prefix takes over the variables.
+	NewClock(WithExpvar("kairos_test")).Close()
+	fc := NewFakeClock(fakeStart, WithExpvar("kairos_test"))
+	defer fc.Close()
+	if got, want := get("next_deadline"), "null"; got != want {
+		t.Errorf("next_deadline: got %s, want %s", got, want)
+	}
+	tk := fc.NewTicker(100 * time.Millisecond)
+	defer tk.Stop()
+	fc.NewTimer(time.Hour)
+	if got, want := get("active_timers"), "2"; got != want {
+		t.Errorf("active_timers: got %s, want %s", got, want)
+	}
+	if got, want := get("next_deadline"), `"2000-01-01T00:00:00.1Z"`; got != want {
+		t.Errorf("next_deadline: got %s, want %s", got, want)
+	}
+	if got, want := get("fires_per_second"), "0"; got != want {
+		t.Errorf("fires_per_second: got %s, want %s", got, want)
+	}
+	if got, want := get("peak_timers"), "2"; got != want {
+		t.Errorf("peak_timers: got %s, want %s", got, want)
+	}
+	// A fake clock has no timer routine.
+	if got, want := get("fires_per_wakeup"), "0"; got != want {
+		t.Errorf("fires_per_wakeup: got %s, want %s", got, want)
+	}
+	fc.Advance(2 * time.Second)
+	if got, want := get("fires_per_second"), "10"; got != want {
+		t.Errorf("fires_per_second: got %s, want %s", got, want)
+	}
+}
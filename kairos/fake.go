@@ -0,0 +1,297 @@
+package kairos
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// A FakeClock is a [Clock] whose time only moves when told to.  Timers created by a FakeClock fire
+// during calls to [FakeClock.Advance], which makes tests of timer-based code fast and
+// deterministic.  A FakeClock is safe for concurrent use.
+type FakeClock struct {
+	*clock
+	autoIdle time.Duration // Quiet period before auto-advancing, or 0 if disabled.
+
+	current  time.Time     // protected by clock.mutex
+	waiters  []*fakeWaiter // protected by clock.mutex
+	activity uint64        // protected by clock.mutex; incremented whenever a timer is armed or fired.
+	reads    uint64        // protected by clock.mutex; incremented whenever the time is read.
+	autoBusy bool          // protected by clock.mutex; true while the auto-advance goroutine runs.
+}
+
+// WithAutoAdvance makes the [FakeClock] advance by itself to the next pending deadline whenever
+// there has been no timer activity (arming or firing) for the real duration idle.  This lets code
+// that waits on many timers in sequence, such as retry loops with backoff, run to completion
+// without explicit calls to [FakeClock.Advance].  The auto-advance goroutine only runs while timers
+// are pending.  A non-positive idle is treated as one millisecond.  This option only affects
+// clocks created by [NewFakeClock].
+func WithAutoAdvance(idle time.Duration) ClockOption {
+	if idle <= 0 {
+		idle = time.Millisecond
+	}
+	return func(cfg *clockConfig) { cfg.autoIdle = idle }
+}
+
+// WithDeterministicDispatch makes a [FakeClock] dispatch expired timers in a fully reproducible
+// way.  Timers fire in deadline order, and timers with equal deadlines fire in the order they were
+// started (a FakeClock always does this).  In addition, AfterFunc callbacks and the calls of
+// [TickFunc] tickers are run one at a time, synchronously, by the goroutine that advances the
+// clock, in the same order, so their side effects are ordered too.  Callbacks may use the clock.
+// To keep the order reproducible, advance the clock from a single goroutine.  This option only
+// affects clocks created by [NewFakeClock].
+func WithDeterministicDispatch() ClockOption {
+	return func(cfg *clockConfig) { cfg.serial = true }
+}
+
+// A fakeWaiter is a goroutine blocked in [FakeClock.BlockUntilContext].
+type fakeWaiter struct {
+	n    int           // Minimum number of pending timers.
+	done chan struct{} // Closed once at least n timers are pending.
+}
+
+// NewFakeClock returns a [FakeClock] whose current time is start.  [WithSleeper] has no effect on a
+// FakeClock.
+func NewFakeClock(start time.Time, opts ...ClockOption) *FakeClock {
+	cfg := newClockConfig(opts)
+	fc := &FakeClock{autoIdle: cfg.autoIdle, current: start}
+	fc.clock = &clock{
+		serial: cfg.serial,
+		now:    fc.readNow,
+		kick:   fc.fireExpired,
+		armed:  fc.onArmed,
+		policy: cfg.policy,
+		rec:    cfg.rec,
+		timers: &timerHeap{},
+		ties:   cfg.ties,
+	}
+	fc.setLimit(cfg)
+	fc.setDispatch(cfg)
+	fc.leaks = cfg.leaks
+	fc.obs = cfg.obs
+	fc.sites = cfg.sites
+	fc.log = cfg.log
+	fc.setStale(cfg, false)
+	fc.races = cfg.races
+	fc.setLatency(cfg)
+	fc.setSamples(cfg)
+	fc.setAudit(cfg)
+	fc.health = newHealthCheck(cfg)
+	if cfg.strict > 0 {
+		fc.quitC = make(chan struct{})
+		fc.doneC = make(chan struct{})
+		go fc.watchdog(cfg.strict, cfg.strictReport)
+	}
+	if cfg.expvar != "" {
+		publishExpvar(cfg.expvar, fc)
+	}
+	return fc
+}
+
+// NewFrozenClock returns a [FakeClock] frozen at instant t.  Unlike [NewFakeClock], the monotonic
+// clock reading is stripped from t, so every value returned by Now formats and serializes
+// identically from run to run.  Time moves only when explicitly stepped with [FakeClock.Advance],
+// [FakeClock.AdvanceTo], or [FakeClock.Set]; timers never fire spontaneously.
+func NewFrozenClock(t time.Time, opts ...ClockOption) *FakeClock {
+	return NewFakeClock(t.Round(0), opts...)
+}
+
+func (fc *FakeClock) readNow() time.Time {
+	fc.mutex.Lock()
+	defer fc.mutex.Unlock()
+	fc.reads++
+	return fc.current
+}
+
+// Advance moves the fake clock's time forward by d and fires every timer whose deadline is reached,
+// in deadline order.  Each fired timer receives its own deadline (or the clock's time at the start
+// of the call, if later) as the current time, not the time at the end of the advance.  Advance
+// panics if d is negative.
+func (fc *FakeClock) Advance(d time.Duration) {
+	if d < 0 {
+		panic("kairos: FakeClock.Advance called with negative duration")
+	}
+	fc.mutex.Lock()
+	defer fc.mutex.Unlock()
+	fc.advanceLocked(fc.current.Add(d))
+}
+
+// AdvanceTo moves the fake clock's time forward to t, firing timers as described for
+// [FakeClock.Advance].  AdvanceTo panics if t is before the clock's current time.
+func (fc *FakeClock) AdvanceTo(t time.Time) {
+	fc.mutex.Lock()
+	defer fc.mutex.Unlock()
+	if t.Before(fc.current) {
+		panic("kairos: FakeClock.AdvanceTo called with a time in the past")
+	}
+	fc.advanceLocked(t)
+}
+
+// Set changes the fake clock's time to t, which may be before the current time.  If t is later,
+// timers fire as described for [FakeClock.Advance].  If t is earlier, pending timers are left armed
+// at their original deadlines.
+func (fc *FakeClock) Set(t time.Time) {
+	fc.mutex.Lock()
+	defer fc.mutex.Unlock()
+	if t.Before(fc.current) {
+		fc.current = t
+		return
+	}
+	fc.advanceLocked(t)
+}
+
+// fireExpired fires the timers whose deadlines are at or before the current time.
+func (fc *FakeClock) fireExpired() {
+	fc.mutex.Lock()
+	defer fc.mutex.Unlock()
+	fc.advanceLocked(fc.current)
+}
+
+// advanceLocked steps the clock from deadline to deadline up to end, firing timers as it goes.  The
+// mutex must be held; it is temporarily released to run callbacks in deterministic dispatch mode.
+func (fc *FakeClock) advanceLocked(end time.Time) {
+	for {
+		t := fc.timers.Peek()
+		if t == nil || t.when.After(end) {
+			break
+		}
+		if t.when.After(fc.current) {
+			fc.current = t.when
+		}
+		fc.timers.Remove(t)
+		fc.disarmedLocked()
+		fc.activity++
+		if t.f != nil && fc.serial {
+			fc.record(OpFire, t, fc.current, 0, true)
+			f := fc.callbackLocked(t, t.f, t.f)
+			fc.mutex.Unlock()
+			f()
+			fc.mutex.Lock()
+			continue
+		}
+		fc.fireLocked(t, fc.current)
+		fc.runCallsLocked()
+	}
+	if end.After(fc.current) {
+		fc.current = end
+	}
+	if fc.stale != nil {
+		fc.checkStaleLocked(fc.current)
+	}
+}
+
+// BlockUntil blocks until at least n timers are pending (armed but not yet fired or stopped).  Call
+// it before [FakeClock.Advance] to make sure the code under test has armed its timers.
+func (fc *FakeClock) BlockUntil(n int) {
+	_ = fc.BlockUntilContext(context.Background(), n)
+}
+
+// BlockUntilContext is like [FakeClock.BlockUntil] but gives up and returns ctx.Err() when ctx is
+// done.
+func (fc *FakeClock) BlockUntilContext(ctx context.Context, n int) error {
+	fc.mutex.Lock()
+	if fc.timers.Len() >= n {
+		fc.mutex.Unlock()
+		return nil
+	}
+	w := &fakeWaiter{n: n, done: make(chan struct{})}
+	fc.waiters = append(fc.waiters, w)
+	fc.mutex.Unlock()
+
+	select {
+	case <-w.done:
+		return nil
+	case <-ctx.Done():
+		fc.mutex.Lock()
+		defer fc.mutex.Unlock()
+		for i, w2 := range fc.waiters {
+			if w2 == w {
+				fc.waiters = append(fc.waiters[:i], fc.waiters[i+1:]...)
+				return ctx.Err()
+			}
+		}
+		// The condition was satisfied concurrently with ctx being done.
+		return nil
+	}
+}
+
+// A PendingTimer describes a timer armed on a [FakeClock].
+type PendingTimer struct {
+	When   time.Time     // Deadline of the timer.
+	Label  string        // Label set with [Timer.SetLabel] or [Ticker.SetLabel].
+	Func   bool          // True if the timer was created by AfterFunc.
+	Period time.Duration // Interval between ticks if the timer backs a [Ticker], or 0.
+}
+
+// PendingTimers returns the timers that are armed, in the order they will fire.  Together with
+// [Diagnostics.Pending] and [FakeClock.NextDeadline], it lets tests assert what the code under test
+// has armed, such as exactly two timers, one five seconds from now.
+func (fc *FakeClock) PendingTimers() []PendingTimer {
+	fc.mutex.Lock()
+	defer fc.mutex.Unlock()
+	timers := append([]*Timer(nil), fc.timers.AppendTo(nil)...)
+	sort.Slice(timers, func(i, j int) bool { return before(timers[i], timers[j]) })
+	pending := make([]PendingTimer, len(timers))
+	for i, t := range timers {
+		pending[i] = PendingTimer{When: t.when, Label: t.label, Func: t.f != nil, Period: t.period}
+	}
+	return pending
+}
+
+// NextDeadline returns the deadline of the next timer to fire.  ok is false if no timer is armed.
+func (fc *FakeClock) NextDeadline() (when time.Time, ok bool) {
+	fc.mutex.Lock()
+	defer fc.mutex.Unlock()
+	if t := fc.timers.Peek(); t != nil {
+		return t.when, true
+	}
+	return time.Time{}, false
+}
+
+// onArmed is called after a timer is added to the heap.
+func (fc *FakeClock) onArmed() {
+	fc.mutex.Lock()
+	defer fc.mutex.Unlock()
+	fc.activity++
+	fc.wakeWaitersLocked()
+	if fc.autoIdle > 0 && !fc.autoBusy {
+		fc.autoBusy = true
+		go fc.autoAdvance()
+	}
+}
+
+// autoAdvance jumps to the next deadline each time the clock has been quiet for autoIdle.  It
+// returns once no timers are pending.
+func (fc *FakeClock) autoAdvance() {
+	fc.mutex.Lock()
+	defer fc.mutex.Unlock()
+	for {
+		activity := fc.activity
+		fc.mutex.Unlock()
+		time.Sleep(fc.autoIdle)
+		fc.mutex.Lock()
+		if fc.activity != activity {
+			continue
+		}
+		t := fc.timers.Peek()
+		if t == nil {
+			fc.autoBusy = false
+			return
+		}
+		fc.advanceLocked(t.when)
+	}
+}
+
+// wakeWaitersLocked releases the goroutines blocked in BlockUntilContext whose condition is
+// satisfied.  The mutex must be held.
+func (fc *FakeClock) wakeWaitersLocked() {
+	waiters := fc.waiters[:0]
+	for _, w := range fc.waiters {
+		if fc.timers.Len() >= w.n {
+			close(w.done)
+			continue
+		}
+		waiters = append(waiters, w)
+	}
+	fc.waiters = waiters
+}
@@ -0,0 +1,291 @@
+package kairos
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"testing"
+	"time"
+)
+
+var fakeStart = time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// recv returns the value waiting in c, if any.
+func recv[T any](c <-chan T) (T, bool) {
+	select {
+	case v := <-c:
+		return v, true
+	default:
+		var zero T
+		return zero, false
+	}
+}
+
+// diagnostics returns the diagnostics of c, a clock of this package.
+func diagnostics(c Clock) Diagnostics {
+	return c.(Diagnostics)
+}
+
+// idPattern matches the numbers of the timers in diagnostics; see Timer.ID.
+var idPattern = regexp.MustCompile(` #[0-9]+`)
+
+// stripIDs removes the numbers of the timers from a diagnostic message.
+func stripIDs(msg string) string {
+	return idPattern.ReplaceAllString(msg, "")
+}
+
+func TestFakeClockAdvance(t *testing.T) {
+	fc := NewFakeClock(fakeStart)
+	var _ Clock = fc
+	timers := []*Timer{fc.NewTimer(3 * time.Second), fc.NewTimer(time.Second), fc.NewTimer(2 * time.Second)}
+	for _, step := range []struct {
+		advance time.Duration
+		fired   []bool
+	}{
+		{advance: 0, fired: []bool{false, false, false}},
+		{advance: 999 * time.Millisecond, fired: []bool{false, false, false}},
+		{advance: time.Millisecond, fired: []bool{false, true, false}},
+		{advance: 5 * time.Second, fired: []bool{true, false, true}},
+	} {
+		fc.Advance(step.advance)
+		for i, timer := range timers {
+			got, ok := recv(timer.C)
+			if ok != step.fired[i] {
+				t.Fatalf("timer %d: fired = %v, want %v", i, ok, step.fired[i])
+			}
+			// Each timer sees its own deadline, not the time at the end of the advance.
+			if want := fakeStart.Add(time.Duration([]int{3, 1, 2}[i]) * time.Second); ok && !got.Equal(want) {
+				t.Errorf("timer %d: got time %v, want %v", i, got, want)
+			}
+		}
+	}
+	if got, want := fc.Now(), fakeStart.Add(6*time.Second); !got.Equal(want) {
+		t.Errorf("got Now() %v, want %v", got, want)
+	}
+}
+
+func TestFakeClockImmediate(t *testing.T) {
+	fc := NewFakeClock(fakeStart)
+	for _, d := range []time.Duration{0, -time.Second} {
+		timer := fc.NewTimer(d)
+		if got, ok := recv(timer.C); !ok || !got.Equal(fakeStart) {
+			t.Errorf("NewTimer(%v): got (%v, %v), want (%v, true)", d, got, ok, fakeStart)
+		}
+	}
+}
+
+func TestFakeClockStopReset(t *testing.T) {
+	fc := NewFakeClock(fakeStart)
+	timer := fc.NewTimer(time.Second)
+	if !timer.Stop() {
+		t.Errorf("Stop: was active is false")
+	}
+	fc.Advance(2 * time.Second)
+	if _, ok := recv(timer.C); ok {
+		t.Errorf("stopped timer fired")
+	}
+	if timer.Reset(time.Second) {
+		t.Errorf("Reset: was active is true")
+	}
+	fc.Advance(time.Second)
+	if got, ok := recv(timer.C); !ok || !got.Equal(fakeStart.Add(3*time.Second)) {
+		t.Errorf("reset timer: got (%v, %v), want (%v, true)", got, ok, fakeStart.Add(3*time.Second))
+	}
+}
+
+func TestFakeClockBlockUntil(t *testing.T) {
+	fc := NewFakeClock(fakeStart)
+	armed := make(chan *Timer)
+	go func() {
+		time.Sleep(100 * time.Millisecond) // Give BlockUntil a chance to actually block.
+		fc.NewTimer(time.Hour)
+		armed <- fc.NewTimer(time.Second)
+	}()
+	fc.BlockUntil(2)
+	fc.Advance(time.Second)
+	if _, ok := recv((<-armed).C); !ok {
+		t.Errorf("timer armed before BlockUntil returned did not fire")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	t.Cleanup(cancel)
+	if err := fc.BlockUntilContext(ctx, 2); err != context.DeadlineExceeded {
+		t.Errorf("BlockUntilContext: got error %v, want %v", err, context.DeadlineExceeded)
+	}
+	if err := fc.BlockUntilContext(context.Background(), 1); err != nil {
+		t.Errorf("BlockUntilContext: got error %v, want nil", err)
+	}
+}
+
+func TestFakeClockAdvanceTo(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("time zone database unavailable: %v", err)
+	}
+	for _, tc := range []struct {
+		desc  string
+		start time.Time
+		when  time.Time
+	}{
+		{"midnight", time.Date(2023, 5, 17, 23, 59, 59, 0, time.UTC), time.Date(2023, 5, 18, 0, 0, 0, 0, time.UTC)},
+		{"year rollover", time.Date(2023, 12, 31, 12, 0, 0, 0, time.UTC), time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+		// 2023-03-26 02:00 CET jumps to 03:00 CEST, so the deadline is only one real hour away.
+		{"DST boundary", time.Date(2023, 3, 26, 1, 30, 0, 0, berlin), time.Date(2023, 3, 26, 3, 30, 0, 0, berlin)},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			fc := NewFakeClock(tc.start)
+			timer := fc.NewTimer(tc.when.Sub(tc.start))
+			fc.AdvanceTo(tc.when.Add(-time.Nanosecond))
+			if _, ok := recv(timer.C); ok {
+				t.Fatalf("timer fired early")
+			}
+			fc.AdvanceTo(tc.when.Add(time.Hour))
+			if got, ok := recv(timer.C); !ok || !got.Equal(tc.when) {
+				t.Errorf("got (%v, %v), want (%v, true)", got, ok, tc.when)
+			}
+			if got, want := fc.Now(), tc.when.Add(time.Hour); !got.Equal(want) {
+				t.Errorf("got Now() %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestFakeClockAutoAdvance(t *testing.T) {
+	fc := NewFakeClock(fakeStart, WithAutoAdvance(time.Millisecond))
+	// Simulate a retry loop with exponential backoff that would take over an hour in real time.
+	d := time.Second
+	var total time.Duration
+	for i := 0; i < 12; i++ {
+		<-fc.NewTimer(d).C
+		total += d
+		d *= 2
+	}
+	if got, want := fc.Now(), fakeStart.Add(total); !got.Equal(want) {
+		t.Errorf("got Now() %v, want %v", got, want)
+	}
+}
+
+func TestFrozenClock(t *testing.T) {
+	fc := NewFrozenClock(time.Now())
+	first := fc.Now()
+	if got := first.String(); got != first.Round(0).String() {
+		t.Errorf("frozen time has a monotonic clock reading: %v", got)
+	}
+	timer := fc.NewTimer(time.Second)
+	time.Sleep(10 * time.Millisecond)
+	if got := fc.Now(); !got.Equal(first) {
+		t.Errorf("time moved without being stepped; got %v, want %v", got, first)
+	}
+	if _, ok := recv(timer.C); ok {
+		t.Errorf("timer fired without the clock being stepped")
+	}
+
+	fc.Set(first.Add(-time.Hour))
+	if got, want := fc.Now(), first.Add(-time.Hour); !got.Equal(want) {
+		t.Errorf("after Set: got Now() %v, want %v", got, want)
+	}
+	fc.Set(first.Add(time.Hour))
+	if got, ok := recv(timer.C); !ok || !got.Equal(first.Add(time.Second)) {
+		t.Errorf("after Set: got (%v, %v), want (%v, true)", got, ok, first.Add(time.Second))
+	}
+}
+
+func TestFakeClockFacade(t *testing.T) {
+	fc := NewFakeClock(fakeStart)
+	if got := fc.Since(fakeStart.Add(-time.Minute)); got != time.Minute {
+		t.Errorf("got Since %v, want %v", got, time.Minute)
+	}
+	if got := fc.Until(fakeStart.Add(time.Minute)); got != time.Minute {
+		t.Errorf("got Until %v, want %v", got, time.Minute)
+	}
+	after := fc.After(time.Minute)
+	called := make(chan time.Time, 1)
+	fc.AfterFunc(time.Minute, func() { called <- fc.Now() })
+	if fc.AfterFunc(time.Minute, func() { t.Errorf("stopped AfterFunc ran") }).Stop() == false {
+		t.Errorf("AfterFunc Stop: was active is false")
+	}
+	slept := make(chan struct{})
+	go func() {
+		fc.Sleep(time.Minute)
+		close(slept)
+	}()
+	fc.BlockUntil(3)
+	fc.Advance(time.Minute)
+	if got, ok := recv(after); !ok || !got.Equal(fakeStart.Add(time.Minute)) {
+		t.Errorf("After: got (%v, %v), want (%v, true)", got, ok, fakeStart.Add(time.Minute))
+	}
+	select {
+	case got := <-called:
+		if !got.Equal(fakeStart.Add(time.Minute)) {
+			t.Errorf("AfterFunc: got %v, want %v", got, fakeStart.Add(time.Minute))
+		}
+	case <-time.After(time.Second):
+		t.Errorf("AfterFunc callback did not run")
+	}
+	<-slept
+}
+
+func TestFakeClockDeterministicDispatch(t *testing.T) {
+	fc := NewFakeClock(fakeStart, WithDeterministicDispatch())
+	var got []int
+	for i := 0; i < 100; i++ {
+		i := i
+		d := time.Second
+		if i%10 == 0 {
+			d = 2 * time.Second
+		}
+		timer := fc.AfterFunc(time.Hour, func() {
+			got = append(got, i)
+			if i == 5 {
+				// Callbacks can use the clock; a timer started now runs after the others.
+				fc.AfterFunc(0, func() { got = append(got, -1) })
+			}
+		})
+		timer.Reset(d)
+	}
+	fc.Advance(2 * time.Second)
+	var want []int
+	for i := 0; i < 100; i++ {
+		if i%10 != 0 {
+			want = append(want, i)
+		}
+	}
+	want = append(want, -1)
+	for i := 0; i < 100; i += 10 {
+		want = append(want, i)
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("got callback order %v, want %v", got, want)
+	}
+}
+
+func TestFakeClockIntrospection(t *testing.T) {
+	fc := NewFakeClock(fakeStart)
+	if _, ok := fc.NextDeadline(); ok {
+		t.Errorf("NextDeadline of idle clock: ok is true")
+	}
+	fc.NewTimer(5 * time.Second).SetLabel("request timeout")
+	fc.AfterFunc(time.Second, func() {}).SetLabel("retry")
+	fc.NewTicker(2 * time.Second).SetLabel("heartbeat")
+	fc.NewTimer(time.Hour).Stop()
+
+	want := []PendingTimer{
+		{When: fakeStart.Add(time.Second), Label: "retry", Func: true},
+		{When: fakeStart.Add(2 * time.Second), Label: "heartbeat", Period: 2 * time.Second},
+		{When: fakeStart.Add(5 * time.Second), Label: "request timeout"},
+	}
+	if got := fc.PendingTimers(); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("got PendingTimers() %v, want %v", got, want)
+	}
+	if got, ok := fc.NextDeadline(); !ok || !got.Equal(want[0].When) {
+		t.Errorf("got NextDeadline() (%v, %v), want (%v, true)", got, ok, want[0].When)
+	}
+	fc.Advance(2 * time.Second)
+	want = []PendingTimer{
+		{When: fakeStart.Add(4 * time.Second), Label: "heartbeat", Period: 2 * time.Second},
+		{When: fakeStart.Add(5 * time.Second), Label: "request timeout"},
+	}
+	if got := fc.PendingTimers(); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("after Advance: got PendingTimers() %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,77 @@
+package kairos
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// Errors joined in the error returned by [Diagnostics.Healthy].
+var (
+	// ErrRunnerStopped reports that the timer routine was stopped by StopRunner or by closing the
+	// clock.
+	ErrRunnerStopped = errors.New("kairos: timer routine stopped")
+	// ErrRunnerStalled reports that the timer routine is stuck; see [WithWatchdog].
+	ErrRunnerStalled = errors.New("kairos: timer routine stalled")
+	// ErrTimersOverdue reports that timers are overdue by more than the limit of
+	// [WithMaxHealthyLag].
+	ErrTimersOverdue = errors.New("kairos: timers overdue")
+	// ErrCallbackPanics reports that [TickFunc] callbacks panicked since the previous check.
+	ErrCallbackPanics = errors.New("kairos: callbacks panicked")
+)
+
+// defaultMaxLag is the largest lag of a healthy clock without WithMaxHealthyLag.
+const defaultMaxLag = time.Second
+
+// WithMaxHealthyLag sets how long the earliest armed timer may be overdue, as reported by
+// [RunnerHealth.Lag], before [Diagnostics.Healthy] reports the clock unhealthy.  The default is one
+// second.
+func WithMaxHealthyLag(d time.Duration) ClockOption {
+	return func(cfg *clockConfig) { cfg.maxLag = max(d, 0) }
+}
+
+// A healthCheck is the state of the checks of Diagnostics.Healthy.
+type healthCheck struct {
+	maxLag time.Duration
+	panics atomic.Uint64 // Number of callback panics at the previous check.
+}
+
+// newHealthCheck returns the state of the checks of a clock configured by cfg.
+func newHealthCheck(cfg clockConfig) *healthCheck {
+	c := &healthCheck{maxLag: defaultMaxLag}
+	if cfg.maxLag > 0 {
+		c.maxLag = cfg.maxLag
+	}
+	return c
+}
+
+// check returns the problems of a clock whose routine is in state h and whose timers were operated
+// on as counted by s, or nil if none.
+func (c *healthCheck) check(h RunnerHealth, s Stats) error {
+	var errs []error
+	if h.Stopped {
+		errs = append(errs, ErrRunnerStopped)
+	}
+	if h.Stalled > 0 {
+		errs = append(errs, fmt.Errorf("%w for %v", ErrRunnerStalled, h.Stalled))
+	}
+	if h.Lag > c.maxLag {
+		errs = append(errs, fmt.Errorf("%w by up to %v", ErrTimersOverdue, h.Lag))
+	}
+	if prev := c.panics.Swap(s.CallbackPanics); s.CallbackPanics > prev {
+		errs = append(errs, fmt.Errorf("%w %d times since the previous check", ErrCallbackPanics,
+			s.CallbackPanics-prev))
+	}
+	return errors.Join(errs...)
+}
+
+// Healthy checks the health of the clock.
+func (clk *clock) Healthy() error {
+	return clk.health.check(clk.Health(), clk.Stats())
+}
+
+// Healthy checks the combined health of the shards.
+func (sc *shardedClock) Healthy() error {
+	return sc.health.check(sc.Health(), sc.Stats())
+}
@@ -0,0 +1,100 @@
+package kairos
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestHealthy(t *testing.T) {
+	fc := NewFakeClock(fakeStart, WithDeterministicDispatch())
+	defer fc.Close()
+	if err := fc.Healthy(); err != nil {
+		t.Errorf("got error %v from a new clock, want nil", err)
+	}
+	tk := fc.TickFunc(time.Second, func(time.Time) { panic("boom") })
+	fc.Advance(2 * time.Second)
+	tk.Stop()
+	if err := fc.Healthy(); !errors.Is(err, ErrCallbackPanics) {
+		t.Errorf("got error %v after panics, want %v", err, ErrCallbackPanics)
+	}
+	if err := fc.Healthy(); err != nil {
+		t.Errorf("got error %v on the next check, want nil", err)
+	}
+	fc.Close()
+	if err := fc.Healthy(); !errors.Is(err, ErrRunnerStopped) {
+		t.Errorf("got error %v after Close, want %v", err, ErrRunnerStopped)
+	}
+}
+
+func TestHealthCheck(t *testing.T) {
+	for _, tc := range []struct {
+		desc string
+		h    RunnerHealth
+		want []error
+	}{
+		{"idle", RunnerHealth{Running: true}, nil},
+		{"late", RunnerHealth{Running: true, Lag: time.Second}, nil},
+		{"overdue", RunnerHealth{Running: true, Lag: 2 * time.Second}, []error{ErrTimersOverdue}},
+		{"stopped", RunnerHealth{Stopped: true}, []error{ErrRunnerStopped}},
+		{"stalled", RunnerHealth{Running: true, Stalled: time.Second}, []error{ErrRunnerStalled}},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			err := newHealthCheck(clockConfig{}).check(tc.h, Stats{})
+			if tc.want == nil && err != nil {
+				t.Errorf("got error %v, want nil", err)
+			}
+			for _, want := range tc.want {
+				if !errors.Is(err, want) {
+					t.Errorf("got error %v, want %v", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestHealthyRunner(t *testing.T) {
+	obs := blockingObserver{make(chan struct{})}
+	stalls := make(chan time.Duration, 10)
+	c := NewClock(WithObserver(obs), WithWatchdog(10*time.Millisecond, func(d time.Duration) {
+		stalls <- d
+	}), WithMaxHealthyLag(5*time.Millisecond))
+	defer c.Close()
+	if err := diagnostics(c).Healthy(); err != nil {
+		t.Errorf("got error %v from a new clock, want nil", err)
+	}
+	timer := c.NewTimer(time.Millisecond)
+	c.NewTimer(2 * time.Millisecond)
+	<-stalls
+	if err := diagnostics(c).Healthy(); !errors.Is(err, ErrRunnerStalled) {
+		t.Errorf("got error %v while stuck, want %v", err, ErrRunnerStalled)
+	}
+	close(obs.release)
+	<-timer.C
+	for diagnostics(c).Healthy() != nil {
+		time.Sleep(time.Millisecond)
+	}
+	if err := diagnostics(c).StopRunner(context.Background()); err != nil {
+		t.Fatalf("StopRunner: got error %v, want nil", err)
+	}
+	if err := diagnostics(c).Healthy(); !errors.Is(err, ErrRunnerStopped) {
+		t.Errorf("got error %v after StopRunner, want %v", err, ErrRunnerStopped)
+	}
+}
+
+func TestShardedHealthy(t *testing.T) {
+	c := NewClock(WithShards(2))
+	defer c.Close()
+	tk := c.TickFunc(time.Millisecond, func(time.Time) { panic("boom") })
+	for diagnostics(c).Stats().CallbackPanics == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	tk.Stop()
+	if err := diagnostics(c).Healthy(); !errors.Is(err, ErrCallbackPanics) {
+		t.Errorf("got error %v after panics, want %v", err, ErrCallbackPanics)
+	}
+	if err := diagnostics(c).Healthy(); err != nil {
+		t.Errorf("got error %v on the next check, want nil", err)
+	}
+}
@@ -0,0 +1,10 @@
+module github.com/rhansen/go-kairos/kairos/k8sadapter
+
+go 1.23
+
+require (
+	github.com/rhansen/go-kairos v0.0.0-00010101000000-000000000000
+	k8s.io/utils v0.0.0-20260507154919-ff6756f316d2
+)
+
+replace github.com/rhansen/go-kairos => ../..
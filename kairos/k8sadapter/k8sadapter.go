@@ -0,0 +1,60 @@
+// Package k8sadapter adapts kairos clocks to the interfaces of [k8s.io/utils/clock], so that
+// controllers built on client-go machinery can be driven by a [kairos.FakeClock] in tests.
+//
+// [k8s.io/utils/clock]: https://pkg.go.dev/k8s.io/utils/clock
+package k8sadapter
+
+import (
+	"time"
+
+	"github.com/rhansen/go-kairos/kairos"
+	"k8s.io/utils/clock"
+)
+
+// FromKairos returns a [clock.WithTickerAndDelayedExecution] backed by c.  Timers, tickers, and
+// AfterFunc callbacks created through the returned clock are driven by c.
+func FromKairos(c kairos.Clock) clock.WithTickerAndDelayedExecution {
+	return kairosClock{c}
+}
+
+type kairosClock struct{ c kairos.Clock }
+
+var _ clock.WithTickerAndDelayedExecution = kairosClock{}
+
+func (k kairosClock) Now() time.Time                         { return k.c.Now() }
+func (k kairosClock) Since(t time.Time) time.Duration        { return k.c.Since(t) }
+func (k kairosClock) After(d time.Duration) <-chan time.Time { return k.c.After(d) }
+func (k kairosClock) Sleep(d time.Duration)                  { k.c.Sleep(d) }
+
+func (k kairosClock) NewTimer(d time.Duration) clock.Timer {
+	return timer{k.c.NewTimer(d)}
+}
+
+// Tick is like [time.Tick]: the underlying ticker can never be stopped.
+func (k kairosClock) Tick(d time.Duration) <-chan time.Time {
+	if d <= 0 {
+		return nil
+	}
+	return k.c.NewTicker(d).C
+}
+
+func (k kairosClock) NewTicker(d time.Duration) clock.Ticker {
+	return ticker{k.c.NewTicker(d)}
+}
+
+func (k kairosClock) AfterFunc(d time.Duration, f func()) clock.Timer {
+	return timer{k.c.AfterFunc(d, f)}
+}
+
+// timer adapts a [kairos.Timer] to [clock.Timer].
+type timer struct{ t *kairos.Timer }
+
+func (t timer) C() <-chan time.Time        { return t.t.C }
+func (t timer) Stop() bool                 { return t.t.Stop() }
+func (t timer) Reset(d time.Duration) bool { return t.t.Reset(d) }
+
+// ticker adapts a [kairos.Ticker] to [clock.Ticker].
+type ticker struct{ t *kairos.Ticker }
+
+func (tk ticker) C() <-chan time.Time { return tk.t.C }
+func (tk ticker) Stop()               { tk.t.Stop() }
@@ -0,0 +1,47 @@
+package k8sadapter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rhansen/go-kairos/kairos"
+)
+
+func waitFor(t *testing.T, c <-chan time.Time) time.Time {
+	t.Helper()
+	select {
+	case got := <-c:
+		return got
+	case <-time.After(time.Second):
+		t.Fatalf("channel did not receive a value")
+		return time.Time{}
+	}
+}
+
+func TestFromKairos(t *testing.T) {
+	start := time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+	fc := kairos.NewFakeClock(start)
+	c := FromKairos(fc)
+	timer := c.NewTimer(time.Second)
+	ticker := c.NewTicker(time.Second)
+	defer ticker.Stop()
+	called := make(chan time.Time, 1)
+	c.AfterFunc(time.Second, func() { called <- fc.Now() })
+
+	fc.Advance(time.Second)
+	want := start.Add(time.Second)
+	for desc, ch := range map[string]<-chan time.Time{"timer": timer.C(), "ticker": ticker.C(), "AfterFunc": called} {
+		if got := waitFor(t, ch); !got.Equal(want) {
+			t.Errorf("%s: got %v, want %v", desc, got, want)
+		}
+	}
+	if timer.Reset(time.Second) {
+		t.Errorf("Reset of fired timer: was active is true")
+	}
+	if !timer.Stop() {
+		t.Errorf("Stop of reset timer: was active is false")
+	}
+	if got := c.Since(start); got != time.Second {
+		t.Errorf("got Since %v, want %v", got, time.Second)
+	}
+}
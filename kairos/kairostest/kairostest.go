@@ -0,0 +1,41 @@
+// Package kairostest provides helpers for tests of code built on package kairos.
+package kairostest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rhansen/go-kairos/kairos"
+)
+
+// callbackGrace is how long VerifyNoLeakedTimers waits for the callbacks still running; shortened
+// by tests.
+var callbackGrace = time.Second
+
+// VerifyNoLeakedTimers registers a cleanup function with t that fails the test if any timer created
+// by c is still armed when the test finishes, or if AfterFunc or TickFunc callbacks are still
+// running a second later.  A timer left armed by a test usually means that the code under test
+// forgot to stop it, and a callback that outlives the test can act on the state of later tests;
+// both can make later tests flaky.  See [kairos.Stats.InFlight].  The test fails right away if c
+// does not implement [kairos.Diagnostics], as the clocks of package kairos do.
+func VerifyNoLeakedTimers(t testing.TB, c kairos.Clock) {
+	t.Helper()
+	d, ok := c.(kairos.Diagnostics)
+	if !ok {
+		t.Fatalf("kairostest: %T does not implement kairos.Diagnostics", c)
+	}
+	t.Cleanup(func() {
+		t.Helper()
+		if n := d.Pending(); n != 0 {
+			t.Errorf("%d timer(s) still armed at the end of the test", n)
+		}
+		// The callbacks of the timers that fired at the end of the test may still be finishing.
+		deadline := time.Now().Add(callbackGrace)
+		for d.Stats().InFlight != 0 && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+		}
+		if n := d.Stats().InFlight; n != 0 {
+			t.Errorf("%d callback(s) still running at the end of the test", n)
+		}
+	})
+}
@@ -0,0 +1,110 @@
+package kairostest
+
+import (
+	"fmt"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/rhansen/go-kairos/kairos"
+)
+
+// recordingTB captures errors and cleanup functions instead of acting on them.
+type recordingTB struct {
+	testing.TB
+	errors   []string
+	cleanups []func()
+}
+
+func (tb *recordingTB) Helper()          {}
+func (tb *recordingTB) Cleanup(f func()) { tb.cleanups = append(tb.cleanups, f) }
+func (tb *recordingTB) Errorf(format string, args ...any) {
+	tb.errors = append(tb.errors, fmt.Sprintf(format, args...))
+}
+
+func (tb *recordingTB) Fatalf(format string, args ...any) {
+	tb.Errorf(format, args...)
+	runtime.Goexit()
+}
+
+func (tb *recordingTB) runCleanups() {
+	for i := len(tb.cleanups) - 1; i >= 0; i-- {
+		tb.cleanups[i]()
+	}
+}
+
+func TestVerifyNoLeakedTimers(t *testing.T) {
+	for _, tc := range []struct {
+		desc    string
+		stop    bool
+		wantErr bool
+	}{
+		{"stopped", true, false},
+		{"leaked", false, true},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			fc := kairos.NewFakeClock(time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC))
+			tb := &recordingTB{TB: t}
+			VerifyNoLeakedTimers(tb, fc)
+			fired := fc.NewTimer(time.Second)
+			timer := fc.NewTimer(time.Hour)
+			fc.Advance(time.Second)
+			<-fired.C
+			if tc.stop {
+				timer.Stop()
+			}
+			tb.runCleanups()
+			if gotErr := len(tb.errors) > 0; gotErr != tc.wantErr {
+				t.Errorf("got errors %q, want error = %v", tb.errors, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestVerifyNoLeakedTimersCallbacks(t *testing.T) {
+	defer func(d time.Duration) { callbackGrace = d }(callbackGrace)
+	callbackGrace = 10 * time.Millisecond
+	for _, tc := range []struct {
+		desc    string
+		block   bool // Whether the callback is still running at cleanup.
+		wantErr bool
+	}{
+		{"returned", false, false},
+		{"running", true, true},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			fc := kairos.NewFakeClock(time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC))
+			tb := &recordingTB{TB: t}
+			VerifyNoLeakedTimers(tb, fc)
+			release, started := make(chan struct{}), make(chan struct{})
+			fc.AfterFunc(time.Second, func() {
+				close(started)
+				if tc.block {
+					<-release
+				}
+			})
+			fc.Advance(time.Second)
+			<-started
+			tb.runCleanups()
+			close(release)
+			if gotErr := len(tb.errors) > 0; gotErr != tc.wantErr {
+				t.Errorf("got errors %q, want error = %v", tb.errors, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestVerifyNoLeakedTimersWithoutDiagnostics(t *testing.T) {
+	fc := kairos.NewFakeClock(time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC))
+	tb := &recordingTB{TB: t}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		// A test double that only implements kairos.Clock.
+		VerifyNoLeakedTimers(tb, struct{ kairos.Clock }{fc})
+	}()
+	<-done
+	if len(tb.errors) != 1 || len(tb.cleanups) != 0 {
+		t.Errorf("got errors %q and %d cleanups, want 1 error and none", tb.errors, len(tb.cleanups))
+	}
+}
@@ -0,0 +1,288 @@
+package kairos
+
+import (
+	"math"
+	"time"
+)
+
+// WithFireLatency makes the clock track how late its timers fire: each timer remembers when its
+// last expiration was due and when the clock fired it, reported by [Timer.LastFiring] and
+// [Ticker.LastFiring], and the clock keeps a histogram of the delays, reported by
+// [Diagnostics.FireLatency].  The delay covers the slack of [WithSlack] and the resolution of
+// [WithResolution], the time the timer routine took to wake up, which is up to the Go scheduler
+// and the operating system, and the timers fired before it in the same batch.  What happens after
+// the clock fires a timer, until the receiver takes the value from the channel or the callback
+// runs, is not included: compare the time received with the current time to measure it.
+func WithFireLatency() ClockOption {
+	return func(cfg *clockConfig) { cfg.latency = true }
+}
+
+// WithOnLateFire makes the clock call f when it fires a timer more than threshold after its
+// deadline, with the timer and how late it fired, as a signal that the host is overloaded or the
+// timer routine starved.  For a ticker, f receives the timer backing the ticker, whose label is the
+// ticker's.  f runs in its own goroutine, and is not subject to [WithMaxConcurrentCallbacks], so
+// that the warning is not held up by the callbacks it warns about; [Clock.Shutdown] waits for it.
+// The timers of a [FakeClock] fire at their deadlines and are never late.
+func WithOnLateFire(threshold time.Duration, f func(t *Timer, lateBy time.Duration)) ClockOption {
+	return func(cfg *clockConfig) { cfg.lateFire = &lateFire{threshold: max(threshold, 0), f: f} }
+}
+
+type lateFire struct {
+	threshold time.Duration
+	f         func(t *Timer, lateBy time.Duration)
+}
+
+// checkLateLocked calls the late-fire handler, if any, if t fired late at time now.  The mutex
+// must be held.
+func (clk *clock) checkLateLocked(t *Timer, now time.Time) {
+	l := clk.lateFire
+	if l == nil {
+		return
+	}
+	if late := now.Sub(t.when); late > l.threshold {
+		clk.funcs.Add(1)
+		go func() {
+			defer clk.funcs.Done()
+			l.f(t, late)
+		}()
+	}
+}
+
+// A Firing is an expiration of a timer tracked by [WithFireLatency].
+type Firing struct {
+	Scheduled time.Time // Deadline of the timer.
+	Fired     time.Time // Time at which the clock fired the timer.
+}
+
+// Late returns how late the timer fired.
+func (f Firing) Late() time.Duration { return f.Fired.Sub(f.Scheduled) }
+
+// LastFiring returns the last expiration of the timer.  ok is false if the timer has not fired
+// since it was created, or if its clock does not track the fire latency; see [WithFireLatency].
+func (t *Timer) LastFiring() (f Firing, ok bool) {
+	t = t.impl()
+	if t.clk == nil {
+		return Firing{}, false
+	}
+	t.clk.mutex.Lock()
+	defer t.clk.mutex.Unlock()
+	return t.firing, !t.firing.Fired.IsZero()
+}
+
+// Firings returns a channel that delivers the expirations of the timer with their deadlines, so
+// that the receiver can tell how late each was without keeping the deadline aside.  Once Firings
+// has been called, the timer delivers its expirations on that channel instead of C; an expiration
+// waiting in C is moved to it.  Reset drains it, as it does C.  Firings returns nil for a timer
+// created by AfterFunc or InitTimer, or backing a [Ticker], whose ticks carry their scheduled time
+// on [Ticker.Ticks].
+func (t *Timer) Firings() <-chan Firing {
+	t = t.impl()
+	if t.clk == nil {
+		panic("timer: Firings called on uninitialized Timer")
+	}
+	t.clk.mutex.Lock()
+	defer t.clk.mutex.Unlock()
+	if t.c == nil || t.tk != nil {
+		return nil
+	}
+	if t.fc == nil {
+		t.fc = make(chan Firing, 1)
+		select {
+		case now := <-t.C:
+			t.fc <- Firing{Scheduled: t.when, Fired: now}
+		default:
+		}
+	}
+	return t.fc
+}
+
+// drainFiringsLocked empties the channel returned by Firings, if any.  The clock's mutex must be
+// held.
+func (t *Timer) drainFiringsLocked() {
+	if t.fc != nil {
+		select {
+		case <-t.fc:
+		default:
+		}
+	}
+}
+
+// LastFiring returns the last tick of the ticker.  See [Timer.LastFiring].
+func (tk *Ticker) LastFiring() (f Firing, ok bool) {
+	tk = tk.impl()
+	if tk.t == nil {
+		return Firing{}, false
+	}
+	return tk.t.LastFiring()
+}
+
+// A LatencyHistogram is the distribution of the delays with which a clock fired its timers, from
+// their deadlines.  See [WithFireLatency].
+type LatencyHistogram struct {
+	// Bounds are the upper bounds of the buckets, in increasing order.  The last bucket has no
+	// upper bound.
+	Bounds []time.Duration
+	// Counts are the numbers of expirations whose delays fell in each bucket.  Counts has one more
+	// element than Bounds.
+	Counts []uint64
+	Count  uint64        // Number of expirations.
+	Sum    time.Duration // Sum of the delays.
+	Max    time.Duration // Largest delay.
+}
+
+// latencyBounds are the bounds of the buckets of LatencyHistogram: powers of 4 from a microsecond
+// to about 4 seconds.
+var latencyBounds = func() []time.Duration {
+	var bounds []time.Duration
+	for d := time.Microsecond; d < 5*time.Second; d *= 4 {
+		bounds = append(bounds, d)
+	}
+	return bounds
+}()
+
+// Mean returns the average delay, or 0 if no timer fired.
+func (h LatencyHistogram) Mean() time.Duration {
+	if h.Count == 0 {
+		return 0
+	}
+	return h.Sum / time.Duration(h.Count)
+}
+
+// Quantile returns an upper bound of the q-quantile of the delays: the upper bound of the bucket
+// that holds it, or Max if that bucket has none or Max is smaller.  It returns 0 if no timer fired.
+func (h LatencyHistogram) Quantile(q float64) time.Duration {
+	if h.Count == 0 {
+		return 0
+	}
+	rank := uint64(math.Ceil(q * float64(h.Count)))
+	var n uint64
+	for i, c := range h.Counts {
+		if n += c; n >= rank && i < len(h.Bounds) {
+			return min(h.Bounds[i], h.Max)
+		}
+	}
+	return h.Max
+}
+
+// add adds the counts of o to h, which must have the same bounds or none.
+func (h *LatencyHistogram) add(o LatencyHistogram) {
+	if h.Counts == nil {
+		h.Bounds, h.Counts = o.Bounds, make([]uint64, len(o.Counts))
+	}
+	for i, c := range o.Counts {
+		h.Counts[i] += c
+	}
+	h.Count += o.Count
+	h.Sum += o.Sum
+	h.Max = max(h.Max, o.Max)
+}
+
+// observeFiringLocked records that t fired at time now.  The mutex must be held.
+func (clk *clock) observeFiringLocked(t *Timer, now time.Time) {
+	t.firing = Firing{Scheduled: t.when, Fired: now}
+	clk.latency.observe(max(now.Sub(t.when), 0))
+}
+
+// newLatencyHistogram returns an empty histogram with the bounds latencyBounds.
+func newLatencyHistogram() *LatencyHistogram {
+	return &LatencyHistogram{Bounds: latencyBounds, Counts: make([]uint64, len(latencyBounds)+1)}
+}
+
+// observe adds a delay to h.
+func (h *LatencyHistogram) observe(late time.Duration) {
+	i := 0
+	for i < len(h.Bounds) && late > h.Bounds[i] {
+		i++
+	}
+	h.Counts[i]++
+	h.Count++
+	h.Sum += late
+	h.Max = max(h.Max, late)
+}
+
+// FireLatency returns the histogram of the delays with which the clock fired its timers, or an
+// empty histogram if the clock does not track them.
+func (clk *clock) FireLatency() LatencyHistogram {
+	clk.mutex.Lock()
+	defer clk.mutex.Unlock()
+	if clk.latency == nil {
+		return LatencyHistogram{}
+	}
+	h := *clk.latency
+	h.Counts = append([]uint64(nil), h.Counts...)
+	return h
+}
+
+// FireLatency returns the sum of the histograms of the shards.
+func (sc *shardedClock) FireLatency() LatencyHistogram {
+	var h LatencyHistogram
+	for _, shard := range sc.shards {
+		if sh := shard.FireLatency(); sh.Counts != nil {
+			h.add(sh)
+		}
+	}
+	return h
+}
+
+// WithLabelStats makes the clock count the expirations of its timers and the delays with which it
+// fired them by timer label, reported by [Diagnostics.LabelStats], so that the timers of different
+// roles can be told apart on a dashboard without a clock for each.  The timers without a label are
+// counted under "".  The clock keeps a histogram for each label it sees, so labels should come from
+// a small set, not embed request IDs or the like.
+func WithLabelStats() ClockOption {
+	return func(cfg *clockConfig) { cfg.labelStats = true }
+}
+
+// observeLabelLocked records that t fired at time now under its label.  The mutex must be held.
+func (clk *clock) observeLabelLocked(t *Timer, now time.Time) {
+	h := clk.labelStats[t.label]
+	if h == nil {
+		h = newLatencyHistogram()
+		clk.labelStats[t.label] = h
+	}
+	h.observe(max(now.Sub(t.when), 0))
+}
+
+// LabelStats returns the histograms of the delays with which the clock fired its timers by label,
+// or nil if the clock does not track them.
+func (clk *clock) LabelStats() map[string]LatencyHistogram {
+	clk.mutex.Lock()
+	defer clk.mutex.Unlock()
+	if clk.labelStats == nil {
+		return nil
+	}
+	stats := make(map[string]LatencyHistogram, len(clk.labelStats))
+	for label, h := range clk.labelStats {
+		c := *h
+		c.Counts = append([]uint64(nil), h.Counts...)
+		stats[label] = c
+	}
+	return stats
+}
+
+// LabelStats returns the sums of the histograms of the shards by label.
+func (sc *shardedClock) LabelStats() map[string]LatencyHistogram {
+	var stats map[string]LatencyHistogram
+	for _, shard := range sc.shards {
+		for label, sh := range shard.LabelStats() {
+			if stats == nil {
+				stats = map[string]LatencyHistogram{}
+			}
+			h := stats[label]
+			h.add(sh)
+			stats[label] = h
+		}
+	}
+	return stats
+}
+
+// setLatency applies the latency tracking options of cfg, including WithLabelStats, to clk.
+func (clk *clock) setLatency(cfg clockConfig) {
+	clk.lateFire = cfg.lateFire
+	if cfg.labelStats {
+		clk.labelStats = map[string]*LatencyHistogram{}
+	}
+	if cfg.latency {
+		clk.latency = newLatencyHistogram()
+	}
+}
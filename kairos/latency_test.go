@@ -0,0 +1,222 @@
+package kairos
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestFireLatency(t *testing.T) {
+	c := NewClockFromFunc(func() time.Time { return fakeStart }, WithManualExpiry(), WithFireLatency())
+	defer c.Close()
+	var timers []*Timer
+	for _, d := range []time.Duration{-time.Millisecond, 0, 3 * time.Microsecond, 10 * time.Second} {
+		timers = append(timers, c.NewTimerAt(fakeStart.Add(-d)))
+	}
+	if _, ok := timers[0].LastFiring(); ok {
+		t.Errorf("LastFiring of an unfired timer: got ok, want not ok")
+	}
+	diagnostics(c).PopExpired(fakeStart, 0)
+	f, ok := timers[3].LastFiring()
+	if want := (Firing{Scheduled: fakeStart.Add(-10 * time.Second), Fired: fakeStart}); !ok || f != want {
+		t.Errorf("got last firing %+v, %v, want %+v, true", f, ok, want)
+	}
+	if got := f.Late(); got != 10*time.Second {
+		t.Errorf("got lateness %v, want 10s", got)
+	}
+
+	h := diagnostics(c).FireLatency()
+	if h.Count != 3 || h.Sum != 10*time.Second+3*time.Microsecond || h.Max != 10*time.Second {
+		t.Errorf("got count %d, sum %v, max %v, want 3, 10.000003s, 10s", h.Count, h.Sum, h.Max)
+	}
+	for _, tc := range []struct {
+		q    float64
+		want time.Duration
+	}{
+		{0.3, time.Microsecond},
+		{0.5, 4 * time.Microsecond},
+		{1, 10 * time.Second},
+	} {
+		if got := h.Quantile(tc.q); got != tc.want {
+			t.Errorf("Quantile(%v): got %v, want %v", tc.q, got, tc.want)
+		}
+	}
+	if got, want := h.Mean(), (10*time.Second+3*time.Microsecond)/3; got != want {
+		t.Errorf("got mean %v, want %v", got, want)
+	}
+}
+
+func TestFireLatencyDisabled(t *testing.T) {
+	fc := NewFakeClock(fakeStart)
+	timer := fc.NewTimer(time.Second)
+	fc.Advance(time.Second)
+	if _, ok := timer.LastFiring(); ok {
+		t.Errorf("LastFiring without WithFireLatency: got ok, want not ok")
+	}
+	if h := fc.FireLatency(); h.Count != 0 || h.Counts != nil {
+		t.Errorf("got histogram %+v, want none", h)
+	}
+}
+
+func TestShardedFireLatency(t *testing.T) {
+	c := NewClock(WithShards(2), WithFireLatency())
+	defer c.Close()
+	tk := c.NewTicker(time.Millisecond)
+	defer tk.Stop()
+	for i := 0; i < 4; i++ {
+		c.After(time.Millisecond)
+	}
+	<-c.After(10 * time.Millisecond)
+	if f, ok := tk.LastFiring(); !ok || f.Late() < 0 {
+		t.Errorf("got last tick %+v, %v, want a firing", f, ok)
+	}
+	h := diagnostics(c).FireLatency()
+	var n uint64
+	for _, c := range h.Counts {
+		n += c
+	}
+	if h.Count < 5 || n != h.Count || len(h.Counts) != len(h.Bounds)+1 {
+		t.Errorf("got %d expirations in %d buckets, with %d counted, want at least 5", h.Count, len(h.Counts), n)
+	}
+}
+
+func TestLabelStats(t *testing.T) {
+	c := NewClockFromFunc(func() time.Time { return fakeStart }, WithManualExpiry(), WithLabelStats())
+	defer c.Close()
+	for _, tc := range []struct {
+		label string
+		late  time.Duration
+	}{
+		{"retry-backoff", time.Millisecond},
+		{"retry-backoff", 3 * time.Millisecond},
+		{"http-idle-timeout", 0},
+		{"", time.Second},
+	} {
+		c.NewTimerAt(fakeStart.Add(-tc.late)).SetLabel(tc.label)
+	}
+	c.NewTimerAt(fakeStart.Add(time.Hour)).SetLabel("unfired")
+	diagnostics(c).PopExpired(fakeStart, 0)
+
+	stats := diagnostics(c).LabelStats()
+	for _, tc := range []struct {
+		label    string
+		count    uint64
+		sum, max time.Duration
+	}{
+		{"retry-backoff", 2, 4 * time.Millisecond, 3 * time.Millisecond},
+		{"http-idle-timeout", 1, 0, 0},
+		{"", 1, time.Second, time.Second},
+	} {
+		h := stats[tc.label]
+		if h.Count != tc.count || h.Sum != tc.sum || h.Max != tc.max {
+			t.Errorf("%q: got count %d, sum %v, max %v, want %d, %v, %v", tc.label, h.Count, h.Sum, h.Max,
+				tc.count, tc.sum, tc.max)
+		}
+	}
+	if len(stats) != 3 {
+		t.Errorf("got %d labels, want 3", len(stats))
+	}
+	if h := diagnostics(c).FireLatency(); h.Count != 0 {
+		t.Errorf("got %d expirations without WithFireLatency, want 0", h.Count)
+	}
+	if stats := NewFakeClock(fakeStart).LabelStats(); stats != nil {
+		t.Errorf("LabelStats without WithLabelStats: got %v, want nil", stats)
+	}
+}
+
+func TestShardedLabelStats(t *testing.T) {
+	c := NewClock(WithShards(2), WithLabelStats())
+	defer c.Close()
+	for i := 0; i < 4; i++ {
+		f := c.AfterFunc(time.Hour, func() {})
+		f.SetLabel("a")
+		f.Reset(time.Millisecond)
+	}
+	timer := c.NewTimer(time.Hour)
+	timer.SetLabel("b")
+	timer.Reset(time.Millisecond)
+	<-timer.C
+	<-c.After(10 * time.Millisecond)
+	stats := diagnostics(c).LabelStats()
+	if got := stats["a"].Count; got != 4 {
+		t.Errorf("got %d expirations labeled a, want 4", got)
+	}
+	if got := stats["b"].Count; got != 1 {
+		t.Errorf("got %d expirations labeled b, want 1", got)
+	}
+}
+
+func TestOnLateFire(t *testing.T) {
+	type late struct {
+		label string
+		by    time.Duration
+	}
+	lates := make(chan late, 10)
+	c := NewClockFromFunc(func() time.Time { return fakeStart }, WithManualExpiry(),
+		WithOnLateFire(time.Second, func(t *Timer, lateBy time.Duration) { lates <- late{t.Label(), lateBy} }))
+	for i, d := range []time.Duration{0, time.Second, 2 * time.Second} {
+		timer := c.NewTimerAt(fakeStart.Add(-d))
+		timer.SetLabel(fmt.Sprint("timer ", i))
+	}
+	diagnostics(c).PopExpired(fakeStart, 0)
+	if err := c.Close(); err != nil { // Waits for the handler.
+		t.Fatalf("Close: got error %v, want nil", err)
+	}
+	close(lates)
+	var got []late
+	for l := range lates {
+		got = append(got, l)
+	}
+	if want := []late{{"timer 2", 2 * time.Second}}; !slices.Equal(got, want) {
+		t.Errorf("got late fires %v, want %v", got, want)
+	}
+}
+
+func TestFirings(t *testing.T) {
+	fc := NewFakeClock(fakeStart)
+	defer fc.Close()
+	if got := fc.AfterFunc(time.Second, func() {}).Firings(); got != nil {
+		t.Errorf("AfterFunc timer: got channel %v, want nil", got)
+	}
+	timer := fc.NewTimer(time.Second)
+	moved := fc.NewTimer(time.Second)
+	fc.Advance(time.Second)
+	firings := timer.Firings()
+	timer.Reset(time.Second) // Drains the firing.
+	fc.Advance(2 * time.Second)
+	want := Firing{Scheduled: fakeStart.Add(2 * time.Second), Fired: fakeStart.Add(2 * time.Second)}
+	if got, ok := recv(firings); !ok || got != want {
+		t.Errorf("got firing %+v, %v, want %+v", got, ok, want)
+	}
+	if _, ok := recv(timer.C); ok {
+		t.Errorf("got a value on C, want the firings channel only")
+	}
+	// The expiration waiting in C moves to the firings channel.
+	want = Firing{Scheduled: fakeStart.Add(time.Second), Fired: fakeStart.Add(time.Second)}
+	if got, ok := recv(moved.Firings()); !ok || got != want {
+		t.Errorf("moved firing: got %+v, %v, want %+v", got, ok, want)
+	}
+
+	c := NewClock()
+	defer c.Close()
+	real := c.NewTimer(time.Millisecond)
+	start := time.Now()
+	f := <-real.Firings()
+	if f.Scheduled.Before(start) || f.Late() < 0 {
+		t.Errorf("got firing %+v, want one scheduled after %v and not early", f, start)
+	}
+}
+
+func TestTickScheduled(t *testing.T) {
+	fc := NewFakeClock(fakeStart)
+	defer fc.Close()
+	tk := fc.NewTicker(time.Second)
+	defer tk.Stop()
+	ticks := tk.Ticks()
+	fc.Advance(time.Second)
+	want := fakeStart.Add(time.Second)
+	if got, ok := recv(ticks); !ok || !got.Scheduled.Equal(want) || !got.Time.Equal(want) {
+		t.Errorf("got tick %+v, %v, want one scheduled and delivered at %v", got, ok, want)
+	}
+}
@@ -0,0 +1,129 @@
+package kairos
+
+import (
+	"fmt"
+	"log"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// WithLeakDetection makes the clock reap the timers and tickers that are dropped while armed.  A
+// timer created by NewTimer, NewTimerAt, NewStoppedTimer, or AcquireTimer that is never stopped
+// keeps its deadline, and a ticker created by NewTicker or NewFuncTicker that is never stopped
+// keeps ticking, however long ago its owner dropped the last reference to it: the clock holds it
+// until it fires, and a ticker forever.  With this option, the clock hands out timers and tickers
+// that the garbage collector can reclaim while they are armed.  When one is reclaimed armed, the
+// clock stops it and calls report with a message describing it, including the stack of the
+// goroutine that created it if stacks is true.  If report is nil, the message is written with
+// [log.Print].  Recording the stacks makes creating timers and tickers slower and allocate.
+//
+// Timers created by AfterFunc and tickers created by TickFunc, which are routinely dropped, are
+// never reaped, nor are the timers behind After and Sleep.  Reaping happens when the garbage
+// collector gets around to it, so it is a way to find and contain leaks, not to manage timers.
+// The option has no effect together with [WithManualExpiry], since PopExpired returns the timers
+// that the clock holds.
+func WithLeakDetection(stacks bool, report func(msg string)) ClockOption {
+	return func(cfg *clockConfig) { cfg.leaks = &leakDetection{stacks: stacks, report: report} }
+}
+
+type leakDetection struct {
+	stacks bool
+	report func(msg string)
+}
+
+// trackTimer returns t, or with WithLeakDetection, a handle for t whose reclamation by the garbage
+// collector stops t.  The clock holds t, but nothing holds the handle but the caller.
+func (clk *clock) trackTimer(t *Timer) *Timer {
+	if clk.leaks == nil {
+		return t
+	}
+	if clk.leaks.stacks && t.stack == nil {
+		t.stack = callers()
+	}
+	h := &Timer{C: t.C, node: t}
+	runtime.SetFinalizer(h, func(h *Timer) { clk.reap(h.node) })
+	return h
+}
+
+// trackTicker is like trackTimer for tickers.
+func (clk *clock) trackTicker(tk *Ticker) *Ticker {
+	if clk.leaks == nil {
+		return tk
+	}
+	if clk.leaks.stacks && tk.t.stack == nil {
+		tk.t.stack = callers()
+	}
+	h := &Ticker{C: tk.C, inner: tk}
+	runtime.SetFinalizer(h, func(h *Ticker) { clk.reap(h.inner.t) })
+	return h
+}
+
+// impl returns the timer that the clock arms for t: t itself, or the timer behind a handle.
+func (t *Timer) impl() *Timer {
+	if t.node != nil {
+		return t.node
+	}
+	return t
+}
+
+// impl returns the ticker that the clock runs for tk: tk itself, or the ticker behind a handle.
+func (tk *Ticker) impl() *Ticker {
+	if tk.inner != nil {
+		return tk.inner
+	}
+	return tk
+}
+
+// reap stops t, whose handle was reclaimed, and reports it if it was armed.
+func (clk *clock) reap(t *Timer) {
+	clk.mutex.Lock()
+	when, label, reset := t.when, t.label, t.resetStack
+	clk.mutex.Unlock()
+	armed := clk.delTimer(t)
+	if t.tk != nil {
+		t.tk.Stop() // Also discards the undelivered ticks.
+	}
+	if !armed {
+		return
+	}
+	msg := leakMessage(t, when, label, reset)
+	if clk.leaks.report == nil {
+		if clk.log != nil {
+			clk.log.Warn(msg)
+			return
+		}
+		log.Print(msg)
+		return
+	}
+	clk.leaks.report(msg)
+}
+
+// leakMessage describes a timer reaped by WithLeakDetection, last reset with stack reset.
+func leakMessage(t *Timer, when time.Time, label string, reset []uintptr) string {
+	var b strings.Builder
+	if t.tk != nil {
+		fmt.Fprintf(&b, "kairos: ticker #%d with period %v", t.id, t.period)
+	} else {
+		fmt.Fprintf(&b, "kairos: timer #%d", t.id)
+	}
+	if label != "" {
+		fmt.Fprintf(&b, " labeled %q", label)
+	}
+	fmt.Fprintf(&b, " was dropped while armed until %v; stopped it", when)
+	for _, s := range []struct {
+		heading string
+		stack   []uintptr
+	}{
+		{"created at", t.stack},
+		{"last reset at", reset},
+	} {
+		if frames := creationFrames(s.stack); len(frames) > 0 {
+			fmt.Fprintf(&b, "\n%s:", s.heading)
+			for _, f := range frames {
+				fmt.Fprintf(&b, "\n%s\n\t%s:%d", f.Function, f.File, f.Line)
+			}
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,135 @@
+package kairos
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// collect runs the garbage collector until a message is reported on msgs, or the deadline passes.
+func collect(msgs <-chan string) (string, bool) {
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		runtime.GC()
+		select {
+		case msg := <-msgs:
+			return msg, true
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	return "", false
+}
+
+func TestLeakDetection(t *testing.T) {
+	for _, tc := range []struct {
+		desc   string
+		opts   []ClockOption
+		leak   func(c Clock)
+		stacks bool
+		want   []string // Substrings of the report, or nil if none is expected.
+		keep   int      // Number of timers left pending if none is reported.
+	}{
+		{
+			desc: "timer",
+			leak: func(c Clock) { c.NewTimer(time.Hour).SetLabel("idle timeout") },
+			want: []string{"kairos: timer labeled \"idle timeout\" was dropped while armed"},
+		},
+		{
+			desc:   "timer with stack",
+			leak:   func(c Clock) { c.NewTimerAt(c.Now().Add(time.Hour)) },
+			stacks: true,
+			want:   []string{"created at:", "TestLeakDetection"},
+		},
+		{
+			desc: "ticker",
+			leak: func(c Clock) { c.NewTicker(time.Second) },
+			want: []string{"kairos: ticker with period 1s was dropped"},
+		},
+		{
+			desc: "runtime timers",
+			opts: []ClockOption{WithRuntimeTimers()},
+			leak: func(c Clock) { c.NewTimer(time.Hour) },
+			want: []string{"kairos: timer was dropped"},
+		},
+		{
+			desc: "stopped timer",
+			leak: func(c Clock) { c.NewTimer(time.Hour).Stop() },
+		},
+		{
+			desc: "AfterFunc",
+			leak: func(c Clock) { c.AfterFunc(time.Hour, func() {}) },
+			keep: 1,
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			msgs := make(chan string, 1)
+			report := func(msg string) { msgs <- msg }
+			c := NewClock(append(tc.opts, WithLeakDetection(tc.stacks, report))...)
+			defer c.Close()
+			tc.leak(c)
+			if tc.want == nil {
+				runtime.GC()
+				runtime.GC()
+				if got := diagnostics(c).Pending(); got != tc.keep {
+					t.Errorf("got %d pending timers, want %d", got, tc.keep)
+				}
+				select {
+				case msg := <-msgs:
+					t.Errorf("got report %q, want none", msg)
+				case <-time.After(50 * time.Millisecond):
+				}
+				return
+			}
+			msg, ok := collect(msgs)
+			if !ok {
+				t.Fatalf("leaked timer not reported")
+			}
+			for _, want := range tc.want {
+				if !strings.Contains(stripIDs(msg), want) {
+					t.Errorf("got report %q, want it to contain %q", msg, want)
+				}
+			}
+			if got := diagnostics(c).Pending(); got != 0 {
+				t.Errorf("got %d pending timers after reaping, want 0", got)
+			}
+		})
+	}
+}
+
+func TestLeakDetectionHandles(t *testing.T) {
+	fc := NewFakeClock(fakeStart, WithLeakDetection(false, func(msg string) { t.Errorf("unexpected report: %s", msg) }))
+	defer fc.Close()
+	timer := fc.NewTimer(time.Second)
+	timer.SetLabel("handle")
+	tk := fc.NewTicker(time.Second)
+	fc.Advance(time.Second)
+	if _, ok := recv(timer.C); !ok {
+		t.Errorf("timer did not fire")
+	}
+	if _, ok := recv(tk.C); !ok {
+		t.Errorf("ticker did not tick")
+	}
+	if got := timer.Label(); got != "handle" {
+		t.Errorf("got label %q, want %q", got, "handle")
+	}
+	timer.Reset(time.Second)
+	if !timer.Stop() {
+		t.Errorf("Stop of a reset timer: got false, want true")
+	}
+	tk.Stop()
+	ReleaseTimer(timer)
+	if !fc.AcquireTimer(time.Second).Stop() {
+		t.Errorf("Stop of an acquired timer: got false, want true")
+	}
+	// After and Sleep drop their timers by design.
+	go fc.Sleep(time.Second)
+	c := fc.After(time.Second)
+	fc.BlockUntil(2)
+	runtime.GC()
+	runtime.GC()
+	fc.Advance(time.Second)
+	if _, ok := recv(c); !ok {
+		t.Errorf("After did not fire")
+	}
+}
@@ -0,0 +1,84 @@
+package kairos
+
+import (
+	"errors"
+	"sync"
+)
+
+// ErrTimerLimit is returned by [Timer.TryReset] when the clock's limit of armed timers is reached
+// and its [LimitPolicy] is [RejectAtLimit].
+var ErrTimerLimit = errors.New("kairos: limit of armed timers reached")
+
+// A LimitPolicy determines what happens when a timer is started on a clock whose limit of armed
+// timers, set with [WithTimerLimit], is reached.
+type LimitPolicy int
+
+const (
+	// BlockAtLimit makes NewTimer, Reset, and the other functions that start a timer wait until
+	// another timer of the clock fires or is stopped, or the clock is closed.
+	BlockAtLimit LimitPolicy = iota
+	// RejectAtLimit leaves the timer stopped: Reset returns false, [Timer.TryReset] returns
+	// [ErrTimerLimit], and NewTimer returns a stopped timer.
+	RejectAtLimit
+)
+
+// WithTimerLimit limits the number of timers, including those backing tickers, that can be armed
+// on the clock at the same time to n, so that a runaway caller cannot exhaust memory with timers.
+// Restarting a timer that is already armed, and the periodic restart of a ticker, are not limited.
+// When the limit is reached, starting another timer blocks or fails according to p.  With
+// [WithShards], each shard gets an equal part of the limit, rounded up.  A non-positive n means no
+// limit, the default.
+func WithTimerLimit(n int, p LimitPolicy) ClockOption {
+	return func(cfg *clockConfig) { cfg.limit, cfg.limitPolicy = n, p }
+}
+
+// A timerLimit is the state of a clock's limit of armed timers.
+type timerLimit struct {
+	n       int
+	policy  LimitPolicy
+	freed   *sync.Cond // Signaled when a timer is disarmed; its L is the clock's mutex.
+	waiters int        // Number of goroutines waiting on freed.
+}
+
+// setLimit applies the limit of armed timers of cfg to clk.
+func (clk *clock) setLimit(cfg clockConfig) {
+	if cfg.limit > 0 {
+		clk.limit = &timerLimit{n: cfg.limit, policy: cfg.limitPolicy, freed: sync.NewCond(&clk.mutex)}
+	}
+}
+
+// armedLocked returns the number of armed timers.  The mutex must be held.
+func (clk *clock) armedLocked() int {
+	return clk.timers.Len() + len(clk.rtimers)
+}
+
+// reserveLocked checks that there is room for a timer that is about to be armed.  If the limit is
+// reached, it fails or, according to the limit policy, waits until a timer is disarmed and returns
+// retry, since the caller must then compute the timer's deadline again.  The mutex must be held; it
+// is temporarily released while waiting.
+func (clk *clock) reserveLocked() (retry bool, err error) {
+	l := clk.limit
+	if l == nil || clk.armedLocked() < l.n {
+		return false, nil
+	}
+	if l.policy == RejectAtLimit {
+		return false, ErrTimerLimit
+	}
+	for clk.armedLocked() >= l.n && !clk.closed {
+		l.waiters++
+		l.freed.Wait()
+		l.waiters--
+	}
+	if clk.closed {
+		return false, ErrClosed
+	}
+	return true, nil
+}
+
+// disarmedLocked wakes a goroutine waiting to arm a timer, after a timer was disarmed.  The mutex
+// must be held.
+func (clk *clock) disarmedLocked() {
+	if l := clk.limit; l != nil && l.waiters > 0 {
+		l.freed.Signal()
+	}
+}
@@ -0,0 +1,86 @@
+package kairos
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTimerLimitReject(t *testing.T) {
+	for _, tc := range []struct {
+		desc string
+		c    Clock
+	}{
+		{"heap", NewClock(WithTimerLimit(2, RejectAtLimit))},
+		{"runtime timers", NewClock(WithRuntimeTimers(), WithTimerLimit(2, RejectAtLimit))},
+		{"manual", NewClock(WithManualExpiry(), WithTimerLimit(2, RejectAtLimit))},
+		{"fake", NewFakeClock(fakeStart, WithTimerLimit(2, RejectAtLimit))},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			defer tc.c.Close()
+			a := tc.c.NewTimer(time.Hour)
+			tk := tc.c.NewTicker(time.Hour)
+			defer tk.Stop()
+			b := tc.c.NewTimer(time.Hour)
+			if got := diagnostics(tc.c).Pending(); got != 2 {
+				t.Errorf("got %d pending timers, want 2", got)
+			}
+			if b.Stop() {
+				t.Errorf("timer started beyond the limit: Stop: was active is true")
+			}
+			if _, err := b.TryReset(time.Hour); !errors.Is(err, ErrTimerLimit) {
+				t.Errorf("TryReset beyond the limit: got error %v, want %v", err, ErrTimerLimit)
+			}
+			if !a.Reset(2 * time.Hour) {
+				t.Errorf("Reset of an armed timer at the limit: was active is false")
+			}
+			a.Stop()
+			if active, err := b.TryReset(time.Hour); active || err != nil {
+				t.Errorf("TryReset after Stop freed a slot: got %v, %v, want false, nil", active, err)
+			}
+			if got := diagnostics(tc.c).Pending(); got != 2 {
+				t.Errorf("got %d pending timers, want 2", got)
+			}
+		})
+	}
+}
+
+func TestTimerLimitBlock(t *testing.T) {
+	fc := NewFakeClock(fakeStart, WithTimerLimit(1, BlockAtLimit))
+	a := fc.NewTimer(time.Hour)
+	started := make(chan *Timer)
+	go func() { started <- fc.NewTimer(time.Hour) }()
+	select {
+	case <-started:
+		t.Fatalf("NewTimer did not block at the limit")
+	case <-time.After(10 * time.Millisecond):
+	}
+	fc.Advance(time.Hour)
+	waitFired(t, a)
+	var b *Timer
+	select {
+	case b = <-started:
+	case <-time.After(time.Second):
+		t.Fatalf("NewTimer still blocked after a timer fired")
+	}
+	if !b.Stop() {
+		t.Errorf("timer started after waiting: Stop: was active is false")
+	}
+
+	b.Reset(time.Hour)
+	errC := make(chan error)
+	go func() {
+		_, err := a.TryReset(time.Hour)
+		errC <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	fc.Close()
+	select {
+	case err := <-errC:
+		if !errors.Is(err, ErrClosed) {
+			t.Errorf("TryReset blocked until Close: got error %v, want %v", err, ErrClosed)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("TryReset still blocked after Close")
+	}
+}
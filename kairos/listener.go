@@ -0,0 +1,94 @@
+package kairos
+
+import "time"
+
+// OpCreate is the creation of a timer or ticker, including the timers behind After and Sleep.  It
+// is reported to the listeners of [Diagnostics.AddListener], but not recorded by a [Recorder].
+const OpCreate Op = "create"
+
+// A ClockEvent is a timer operation reported to a [ClockListener].
+type ClockEvent struct {
+	Op Op
+	// Timer is the timer operated on, or the timer backing the ticker.  It identifies the timer: the
+	// listener must not call its methods other than [Timer.ID].
+	Timer  *Timer
+	Label  string        // Label of the timer at the time of the operation.
+	Func   bool          // True if the timer was created by AfterFunc or InitTimer.
+	Period time.Duration // Interval between ticks if the timer backs a [Ticker], or 0.
+	// Time is the clock's time when the operation happened.
+	Time time.Time
+	// Deadline is the deadline of the timer, for OpReset and OpFire events.
+	Deadline time.Time
+	// Duration is the duration passed to Reset, for OpReset events.
+	Duration time.Duration
+	// Active is the value returned by Reset or Stop, for OpReset and OpStop events.
+	Active bool
+}
+
+// Late returns how late the clock fired the timer after its deadline, for OpFire events.
+func (ev ClockEvent) Late() time.Duration {
+	if ev.Op != OpFire {
+		return 0
+	}
+	return max(ev.Time.Sub(ev.Deadline), 0)
+}
+
+// A ClockListener receives a structured event for every operation on the timers of the clocks it
+// is added to with [Diagnostics.AddListener], to build tracing, replay, or assertion tooling on top
+// of the package.  Like an [Observer], it may be called concurrently, and with the clock's internal
+// lock held, so it must be fast and must not call the clock or its timers: a listener that needs to
+// do more should hand the events over to another goroutine.
+type ClockListener interface {
+	TimerEvent(ev ClockEvent)
+}
+
+// A ListenerFunc is a function used as a [ClockListener].
+type ListenerFunc func(ev ClockEvent)
+
+// TimerEvent calls f(ev).
+func (f ListenerFunc) TimerEvent(ev ClockEvent) { f(ev) }
+
+// AddListener makes the clock report every subsequent timer operation to l, in addition to the
+// listeners already added.  Listeners cannot be removed.
+func (clk *clock) AddListener(l ClockListener) {
+	clk.mutex.Lock()
+	defer clk.mutex.Unlock()
+	var ls []ClockListener
+	if old := clk.listeners.Load(); old != nil {
+		ls = append(ls, *old...)
+	}
+	ls = append(ls, l)
+	clk.listeners.Store(&ls)
+}
+
+// AddListener adds l to every shard.
+func (sc *shardedClock) AddListener(l ClockListener) {
+	for _, shard := range sc.shards {
+		shard.AddListener(l)
+	}
+}
+
+// notify reports an operation on t to the listeners, if any.
+func (clk *clock) notify(op Op, t *Timer, now time.Time, d time.Duration, active bool) {
+	ls := clk.listeners.Load()
+	if ls == nil {
+		return
+	}
+	ev := ClockEvent{Op: op, Timer: t, Label: t.label, Func: t.f != nil, Period: t.period, Time: now}
+	switch op {
+	case OpReset:
+		ev.Deadline, ev.Duration, ev.Active = t.when, d, active
+	case OpStop:
+		ev.Active = active
+	case OpFire:
+		ev.Deadline = t.when
+	}
+	for _, l := range *ls {
+		l.TimerEvent(ev)
+	}
+}
+
+// listening reports whether the clock has listeners, which need the time of every operation.
+func (clk *clock) listening() bool {
+	return clk.listeners.Load() != nil
+}
@@ -0,0 +1,64 @@
+package kairos
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAddListener(t *testing.T) {
+	fc := NewFakeClock(fakeStart)
+	defer fc.Close()
+	before := fc.NewStoppedTimer()
+	var events []ClockEvent
+	fc.AddListener(ListenerFunc(func(ev ClockEvent) { events = append(events, ev) }))
+
+	timer := fc.NewTimer(time.Second)
+	timer.SetLabel("retry")
+	fc.Advance(time.Second)
+	timer.Reset(time.Minute)
+	timer.Stop()
+	before.Stop()
+	tk := fc.NewTicker(time.Second)
+	tk.Stop()
+
+	t1 := fakeStart.Add(time.Second)
+	want := []ClockEvent{
+		{Op: OpCreate, Timer: timer, Time: fakeStart},
+		{Op: OpReset, Timer: timer, Time: fakeStart, Deadline: t1, Duration: time.Second},
+		{Op: OpFire, Timer: timer, Label: "retry", Time: t1, Deadline: t1},
+		{Op: OpReset, Timer: timer, Label: "retry", Time: t1, Deadline: t1.Add(time.Minute),
+			Duration: time.Minute},
+		{Op: OpStop, Timer: timer, Label: "retry", Time: t1, Active: true},
+		{Op: OpStop, Timer: before, Time: t1},
+		{Op: OpCreate, Timer: tk.t, Period: time.Second, Time: t1},
+		{Op: OpReset, Timer: tk.t, Period: time.Second, Time: t1, Deadline: t1.Add(time.Second),
+			Duration: time.Second},
+		{Op: OpStop, Timer: tk.t, Period: time.Second, Time: t1, Active: true},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("got %d events, want %d: %+v", len(events), len(want), events)
+	}
+	for i, ev := range events {
+		if ev != want[i] {
+			t.Errorf("event %d: got %+v, want %+v", i, ev, want[i])
+		}
+	}
+	if got := events[2].Late(); got != 0 {
+		t.Errorf("got lateness %v, want 0", got)
+	}
+}
+
+func TestShardedAddListener(t *testing.T) {
+	c := NewClock(WithShards(2))
+	defer c.Close()
+	ops := make(chan Op, 100)
+	diagnostics(c).AddListener(ListenerFunc(func(ev ClockEvent) { ops <- ev.Op }))
+	for i := 0; i < 4; i++ {
+		c.NewStoppedTimer()
+	}
+	for i := 0; i < 4; i++ {
+		if op := <-ops; op != OpCreate {
+			t.Errorf("got op %q, want %q", op, OpCreate)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package kairos
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// lateWarning is how late a timer must fire for WithLogger to log it as an anomaly.
+const lateWarning = 100 * time.Millisecond
+
+// WithLogger makes the clock log to h, for diagnostics: at debug level, every arming, firing, and
+// stopping of its timers, and at warn level, the anomalies, namely the timers that fire more than
+// 100 milliseconds late, the panics of AfterFunc and [TickFunc] callbacks, and the timers reaped
+// by [WithLeakDetection] without a report function.  A clock without a logger does not log, at no
+// cost.
+func WithLogger(h slog.Handler) ClockOption {
+	return func(cfg *clockConfig) { cfg.log = slog.New(h) }
+}
+
+// timerAttrs returns the attributes describing t in the logs.  The mutex must be held.
+func timerAttrs(t *Timer) []slog.Attr {
+	attrs := make([]slog.Attr, 0, 7)
+	attrs = append(attrs, slog.Uint64("id", t.id))
+	switch {
+	case t.tk != nil:
+		attrs = append(attrs, slog.String("kind", "ticker"), slog.Duration("period", t.period))
+	case t.f != nil:
+		attrs = append(attrs, slog.String("kind", "func"))
+	default:
+		attrs = append(attrs, slog.String("kind", "timer"))
+	}
+	if t.label != "" {
+		attrs = append(attrs, slog.String("label", t.label))
+	}
+	return attrs
+}
+
+// logLocked logs a timer operation, as passed to record.  The mutex must be held.
+func (clk *clock) logLocked(op Op, t *Timer, now time.Time, d time.Duration, active bool) {
+	ctx := context.Background()
+	level := slog.LevelDebug
+	var late time.Duration
+	if op == OpFire {
+		if late = now.Sub(t.when); late > lateWarning {
+			level = slog.LevelWarn
+		}
+	}
+	if !clk.log.Enabled(ctx, level) {
+		return
+	}
+	attrs := timerAttrs(t)
+	var msg string
+	switch op {
+	case OpReset:
+		msg = "timer armed"
+		attrs = append(attrs, slog.Duration("duration", d), slog.Time("deadline", t.when),
+			slog.Bool("was_active", active))
+	case OpStop:
+		msg = "timer stopped"
+		attrs = append(attrs, slog.Bool("was_active", active))
+	case OpFire:
+		msg = "timer fired"
+		if level == slog.LevelWarn {
+			msg = "timer fired late"
+		}
+		attrs = append(attrs, slog.Time("deadline", t.when), slog.Duration("late", max(late, 0)))
+	}
+	clk.log.LogAttrs(ctx, level, msg, attrs...)
+}
+
+// logPanic logs the panic of the callback of t with value v.  The mutex must not be held.
+func (clk *clock) logPanic(t *Timer, v any) {
+	clk.mutex.Lock()
+	attrs := timerAttrs(t)
+	clk.mutex.Unlock()
+	attrs = append(attrs, slog.Any("panic", v))
+	clk.log.LogAttrs(context.Background(), slog.LevelWarn, "timer callback panicked", attrs...)
+}
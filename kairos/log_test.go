@@ -0,0 +1,68 @@
+package kairos
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestLogger returns a handler that writes the records at level and above to b, without their
+// times.
+func newTestLogger(b *strings.Builder, level slog.Level) slog.Handler {
+	return slog.NewTextHandler(b, &slog.HandlerOptions{
+		Level: level,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if len(groups) == 0 && a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	})
+}
+
+func TestLogger(t *testing.T) {
+	var b strings.Builder
+	// Deterministic dispatch runs the callback during Advance.
+	fc := NewFakeClock(fakeStart, WithDeterministicDispatch(),
+		WithLogger(newTestLogger(&b, slog.LevelDebug)))
+	defer fc.Close()
+	timer := fc.NewTimer(time.Second)
+	timer.SetLabel("request")
+	timer.Stop()
+	tk := fc.TickFunc(time.Second, func(time.Time) { panic("boom") })
+	tk.SetLabel("poll")
+	fc.Advance(time.Second)
+	tk.Stop()
+
+	want := []string{
+		`level=DEBUG msg="timer armed" id=%[1]d kind=timer duration=1s deadline=2000-01-01T00:00:01.000Z was_active=false`,
+		`level=DEBUG msg="timer stopped" id=%[1]d kind=timer label=request was_active=true`,
+		`level=DEBUG msg="timer armed" id=%[2]d kind=ticker period=1s duration=1s deadline=2000-01-01T00:00:01.000Z was_active=false`,
+		`level=DEBUG msg="timer fired" id=%[2]d kind=ticker period=1s label=poll deadline=2000-01-01T00:00:01.000Z late=0s`,
+		`level=WARN msg="timer callback panicked" id=%[2]d kind=ticker period=1s label=poll panic=boom`,
+		`level=DEBUG msg="timer stopped" id=%[2]d kind=ticker period=1s label=poll was_active=true`,
+	}
+	for _, w := range want {
+		w = fmt.Sprintf(w, timer.ID(), tk.ID())
+		if !strings.Contains(b.String(), w+"\n") {
+			t.Errorf("got logs:\n%s\nwant them to contain:\n%s", b.String(), w)
+		}
+	}
+}
+
+func TestLoggerLateFire(t *testing.T) {
+	var b strings.Builder
+	c := NewClockFromFunc(func() time.Time { return fakeStart }, WithManualExpiry(),
+		WithLogger(newTestLogger(&b, slog.LevelWarn)))
+	defer c.Close()
+	timer := c.NewTimer(time.Second)
+	c.NewTimer(2 * time.Second)
+	diagnostics(c).PopExpired(fakeStart.Add(2*time.Second), 0)
+	want := fmt.Sprintf(`level=WARN msg="timer fired late" id=%d kind=timer deadline=2000-01-01T00:00:01.000Z late=1s`,
+		timer.ID()) + "\n"
+	if got := b.String(); got != want {
+		t.Errorf("got logs %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,104 @@
+package kairos
+
+import "time"
+
+// An Observer is notified of the operations on the timers of the clocks it is attached to with
+// [WithObserver], to export metrics about them; the prommetrics package provides one for
+// Prometheus.  Its methods may be called concurrently, and with the clock's internal lock held, so
+// they must be fast and must not call the clock or its timers.
+type Observer interface {
+	// TimerCreated is called when a timer or ticker is created, including the timers behind After
+	// and Sleep.
+	TimerCreated()
+	// TimerReset is called when a timer is started or restarted, with the value returned by Reset.
+	TimerReset(wasActive bool)
+	// TimerStopped is called when a timer is stopped, with the value returned by Stop.
+	TimerStopped(wasActive bool)
+	// TimerFired is called when a timer expires or a ticker ticks, with how late the clock fired it
+	// after its deadline.
+	TimerFired(late time.Duration)
+}
+
+// WithObserver makes the clock notify o of every timer operation.
+func WithObserver(o Observer) ClockOption {
+	return func(cfg *clockConfig) { cfg.obs = o }
+}
+
+// created numbers t, records its creation site, if creation sites are captured, and notifies the
+// observer and the listeners, if any.
+func (clk *clock) created(t *Timer) {
+	t.id = timerIDs.Add(1)
+	clk.ops.created.Add(1)
+	if clk.sites {
+		t.stack = callers()
+	}
+	if clk.obs != nil {
+		clk.obs.TimerCreated()
+	}
+	if clk.listening() {
+		clk.notify(OpCreate, t, clk.now(), 0, false)
+	}
+}
+
+// record counts a timer operation and records it to the recorder, the logger, the audit log, the
+// listeners, and the observer, if any.  The mutex must be held for OpFire.
+func (clk *clock) record(op Op, t *Timer, now time.Time, d time.Duration, active bool) {
+	clk.rec.record(op, t, now, d, active)
+	switch op {
+	case OpReset:
+		clk.ops.reset.Add(1)
+		if !active {
+			clk.ops.armedLocked(clk.armedLocked())
+		}
+		if clk.sites {
+			t.resetStack = callers()
+		}
+	case OpStop:
+		if active {
+			clk.ops.stopped.Add(1)
+		}
+	case OpFire:
+		clk.ops.fired.Add(1)
+		if clk.latency != nil {
+			clk.observeFiringLocked(t, now)
+		}
+		if clk.labelStats != nil {
+			clk.observeLabelLocked(t, now)
+		}
+		clk.checkLateLocked(t, now)
+		if t.ctxs != nil {
+			t.cancelContextsLocked()
+		}
+	}
+	if clk.samples != nil {
+		clk.sampleLocked(op, t, now, d, active)
+	}
+	if op != OpStop && (clk.stale != nil || clk.samples != nil) {
+		t.armedAt, t.staleReported = now, false
+	}
+	if clk.log != nil {
+		clk.logLocked(op, t, now, d, active)
+	}
+	if clk.audit != nil {
+		clk.audit.add(op, t, now, d, active)
+	}
+	clk.notify(op, t, now, d, active)
+	if clk.obs == nil {
+		return
+	}
+	switch op {
+	case OpReset:
+		clk.obs.TimerReset(active)
+	case OpStop:
+		clk.obs.TimerStopped(active)
+	case OpFire:
+		clk.obs.TimerFired(max(now.Sub(t.when), 0))
+	}
+}
+
+// stopTimed reports whether stopping a timer must read the clock's time, which only the consumers
+// of the record of the operation need.
+func (clk *clock) stopTimed() bool {
+	return clk.rec != nil || clk.races != nil || clk.samples != nil || clk.audit != nil ||
+		clk.listening()
+}
@@ -0,0 +1,39 @@
+package kairos
+
+import (
+	"testing"
+	"time"
+)
+
+type countingObserver struct {
+	created, reset, stopped, fired int
+	late                           []time.Duration
+}
+
+func (o *countingObserver) TimerCreated()     { o.created++ }
+func (o *countingObserver) TimerReset(bool)   { o.reset++ }
+func (o *countingObserver) TimerStopped(bool) { o.stopped++ }
+func (o *countingObserver) TimerFired(late time.Duration) {
+	o.fired++
+	o.late = append(o.late, late)
+}
+
+func TestObserver(t *testing.T) {
+	var o countingObserver
+	c := NewClockFromFunc(func() time.Time { return fakeStart }, WithManualExpiry(), WithObserver(&o))
+	defer c.Close()
+	c.NewTimer(time.Second)
+	c.NewTimer(2 * time.Second).Stop()
+	c.AfterFunc(3*time.Second, func() {}).Reset(time.Second)
+	diagnostics(c).PopExpired(fakeStart.Add(1500*time.Millisecond), 0)
+	want := countingObserver{created: 3, reset: 4, stopped: 1, fired: 2}
+	if o.created != want.created || o.reset != want.reset || o.stopped != want.stopped || o.fired != want.fired {
+		t.Errorf("got %d created, %d reset, %d stopped, %d fired, want %d, %d, %d, %d",
+			o.created, o.reset, o.stopped, o.fired, want.created, want.reset, want.stopped, want.fired)
+	}
+	for i, late := range o.late {
+		if late != 500*time.Millisecond {
+			t.Errorf("fire %d: got lateness %v, want 500ms", i, late)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package kairos
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// An OffsetClock is a [Clock] whose time is a base clock's time shifted by an adjustable offset,
+// which is useful to simulate clock skew between components.  Timer deadlines are expressed in the
+// OffsetClock's time, so changing the offset behaves like stepping a wall clock: increasing it
+// makes pending timers fire sooner, and decreasing it makes them fire later.
+type OffsetClock struct {
+	*clock
+	offset atomic.Int64
+}
+
+// NewOffsetClock returns an [OffsetClock] whose time is base's time plus offset.  [WithSleeper] has
+// no effect on an offset clock.
+func NewOffsetClock(base Clock, offset time.Duration, opts ...ClockOption) *OffsetClock {
+	oc := &OffsetClock{}
+	oc.offset.Store(int64(offset))
+	now := func() time.Time { return base.Now().Add(oc.Offset()) }
+	cfg := newClockConfig(opts)
+	cfg.sleeper = NewClockSleeper(base)
+	oc.clock = newClockWith(now, cfg)
+	return oc
+}
+
+// Offset returns the clock's current offset from its base clock.
+func (oc *OffsetClock) Offset() time.Duration {
+	return time.Duration(oc.offset.Load())
+}
+
+// SetOffset changes the clock's offset from its base clock and reschedules pending timers
+// accordingly.
+func (oc *OffsetClock) SetOffset(offset time.Duration) {
+	oc.offset.Store(int64(offset))
+	oc.kick()
+}
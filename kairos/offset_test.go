@@ -0,0 +1,36 @@
+package kairos
+
+import (
+	"testing"
+	"time"
+)
+
+func TestOffsetClock(t *testing.T) {
+	fc := NewFakeClock(fakeStart)
+	oc := NewOffsetClock(fc, time.Hour)
+	if got, want := oc.Now(), fakeStart.Add(time.Hour); !got.Equal(want) {
+		t.Errorf("got Now() %v, want %v", got, want)
+	}
+	timer := oc.NewTimer(10 * time.Second)
+	fc.Advance(5 * time.Second)
+	time.Sleep(10 * time.Millisecond)
+	if _, ok := recv(timer.C); ok {
+		t.Fatalf("timer fired early")
+	}
+	// Skewing the clock forward past the deadline fires the timer without any base time passing.
+	oc.SetOffset(time.Hour + 5*time.Second)
+	if got, want := waitFired(t, timer), fakeStart.Add(time.Hour+10*time.Second); !got.Equal(want) {
+		t.Errorf("got fire time %v, want %v", got, want)
+	}
+
+	// Skewing the clock backward delays the timer.
+	timer.Reset(time.Second)
+	oc.SetOffset(time.Hour)
+	fc.Advance(time.Second)
+	time.Sleep(10 * time.Millisecond)
+	if _, ok := recv(timer.C); ok {
+		t.Fatalf("timer fired before the skewed deadline")
+	}
+	fc.Advance(5 * time.Second)
+	waitFired(t, timer)
+}
@@ -0,0 +1,125 @@
+// Package oteltrace traces delayed work with [OpenTelemetry]: the callbacks of kairos timers and
+// the runs of cron jobs each execute in a span whose parent is the span that was current when the
+// work was scheduled, so that the work shows up in distributed traces under the request that
+// armed it, however late it runs.
+//
+//	timer := oteltrace.AfterFunc(ctx, c, time.Minute, "flush", func(ctx context.Context) {
+//		flush(ctx) // Traced as a child of the span of ctx.
+//	})
+//
+// [OpenTelemetry]: https://opentelemetry.io
+package oteltrace
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rhansen/go-kairos/kairos"
+	"github.com/rhansen/go-kairos/kairos/cron"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// scopeName is the instrumentation scope of the tracer.
+const scopeName = "github.com/rhansen/go-kairos/kairos/oteltrace"
+
+// An Option configures the spans of AfterFunc and Job.
+type Option func(*config)
+
+type config struct {
+	tp    trace.TracerProvider
+	link  bool
+	attrs []attribute.KeyValue
+}
+
+// WithTracerProvider sets the provider of the tracer that starts the spans.  The default is the
+// global provider of [otel.GetTracerProvider].
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(cfg *config) { cfg.tp = tp }
+}
+
+// WithLink makes each run start a new trace with a link to the span that was current when the
+// work was scheduled, rather than a child of that span.  Use it for work that runs long after the
+// request that scheduled it, or repeatedly, such as cron jobs, so as not to stretch the trace of
+// the request.
+func WithLink() Option {
+	return func(cfg *config) { cfg.link = true }
+}
+
+// WithAttributes adds attributes to every span.
+func WithAttributes(attrs ...attribute.KeyValue) Option {
+	return func(cfg *config) { cfg.attrs = append(cfg.attrs, attrs...) }
+}
+
+// A spanner starts the spans of the runs of some work scheduled with a context.
+type spanner struct {
+	tracer trace.Tracer
+	name   string
+	parent trace.SpanContext // Span current when the work was scheduled.
+	opts   []trace.SpanStartOption
+}
+
+func newSpanner(ctx context.Context, name string, opts []Option) *spanner {
+	cfg := config{tp: otel.GetTracerProvider()}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	s := &spanner{
+		tracer: cfg.tp.Tracer(scopeName),
+		name:   name,
+		parent: trace.SpanContextFromContext(ctx),
+		opts:   []trace.SpanStartOption{trace.WithAttributes(cfg.attrs...)},
+	}
+	if cfg.link {
+		s.opts = append(s.opts, trace.WithNewRoot(), trace.WithLinks(trace.Link{SpanContext: s.parent}))
+	}
+	return s
+}
+
+// run runs f in a new span, whose context derives from ctx, and records the error it returns or
+// the panic it raises, which is propagated.
+func (s *spanner) run(ctx context.Context, f func(ctx context.Context) error) (err error) {
+	if s.parent.IsValid() {
+		ctx = trace.ContextWithSpanContext(ctx, s.parent)
+	}
+	ctx, span := s.tracer.Start(ctx, s.name, s.opts...)
+	defer func() {
+		if v := recover(); v != nil {
+			span.SetStatus(codes.Error, fmt.Sprint("panic: ", v))
+			span.End()
+			panic(v)
+		}
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}()
+	return f(ctx)
+}
+
+// AfterFunc is like c.AfterFunc, but calls f in a span named name, a child of the span of ctx.  The
+// context passed to f has the values of ctx, but is not canceled with it, since the call usually
+// outlives the operation that scheduled it.
+func AfterFunc(ctx context.Context, c kairos.Clock, d time.Duration, name string,
+	f func(ctx context.Context), opts ...Option) *kairos.Timer {
+	s := newSpanner(ctx, name, opts)
+	ctx = context.WithoutCancel(ctx)
+	return c.AfterFunc(d, func() {
+		s.run(ctx, func(ctx context.Context) error {
+			f(ctx)
+			return nil
+		})
+	})
+}
+
+// Job returns a [cron.Job] that runs job in a span named name, a child of the span of ctx, for
+// each run.  The error returned by job, or its panic, is recorded on the span.  The context of the
+// run is the one given by the scheduler, with the span; ctx only provides the parent span.
+func Job(ctx context.Context, name string, job cron.Job, opts ...Option) cron.Job {
+	s := newSpanner(ctx, name, opts)
+	return func(ctx context.Context) error { return s.run(ctx, job) }
+}
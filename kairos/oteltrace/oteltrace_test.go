@@ -0,0 +1,119 @@
+package oteltrace
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rhansen/go-kairos/kairos"
+	"github.com/rhansen/go-kairos/kairos/cron"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var start = time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// newTracer returns a provider whose ended spans are recorded, and the context of a parent span.
+func newTracer(t *testing.T) (
+	*sdktrace.TracerProvider, *tracetest.SpanRecorder, context.Context, trace.Span) {
+	rec := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec))
+	t.Cleanup(func() { tp.Shutdown(context.Background()) })
+	ctx, parent := tp.Tracer("test").Start(context.Background(), "request")
+	return tp, rec, ctx, parent
+}
+
+func TestAfterFunc(t *testing.T) {
+	for _, tc := range []struct {
+		desc string
+		opts []Option
+		link bool
+	}{
+		{"child", nil, false},
+		{"link", []Option{WithLink()}, true},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			tp, rec, ctx, parent := newTracer(t)
+			fc := kairos.NewFakeClock(start, kairos.WithDeterministicDispatch())
+			defer fc.Close()
+			ctx, cancel := context.WithCancel(ctx)
+			var called bool
+			opts := append(tc.opts, WithTracerProvider(tp), WithAttributes(attribute.String("k", "v")))
+			AfterFunc(ctx, fc, time.Second, "flush", func(ctx context.Context) {
+				called = true
+				if err := ctx.Err(); err != nil {
+					t.Errorf("callback context: got error %v, want nil", err)
+				}
+				if !trace.SpanContextFromContext(ctx).IsValid() {
+					t.Errorf("callback context has no span")
+				}
+			}, opts...)
+			cancel()
+			parent.End()
+			fc.Advance(time.Second)
+			if !called {
+				t.Fatalf("callback not called")
+			}
+
+			spans := rec.Ended()
+			if len(spans) != 2 {
+				t.Fatalf("got %d spans, want 2", len(spans))
+			}
+			span := spans[1]
+			if got := span.Name(); got != "flush" {
+				t.Errorf("got span name %q, want %q", got, "flush")
+			}
+			if got := span.Attributes(); len(got) != 1 || got[0] != attribute.String("k", "v") {
+				t.Errorf("got attributes %v, want k=v", got)
+			}
+			parentSC := parent.SpanContext()
+			if got := span.Parent().Equal(parentSC); got == tc.link {
+				t.Errorf("span is a child of the arming span: got %v, want %v", got, !tc.link)
+			}
+			linked := len(span.Links()) == 1 && span.Links()[0].SpanContext.Equal(parentSC)
+			if linked != tc.link {
+				t.Errorf("span is linked to the arming span: got %v, want %v", linked, tc.link)
+			}
+		})
+	}
+}
+
+func TestJob(t *testing.T) {
+	tp, rec, ctx, parent := newTracer(t)
+	fc := kairos.NewFakeClock(start, kairos.WithDeterministicDispatch())
+	defer fc.Close()
+	s := cron.New(fc, cron.WithLocation(time.UTC))
+	defer s.Close()
+	errFailed := errors.New("failed")
+	runs := 0
+	job := Job(ctx, "cleanup", func(ctx context.Context) error {
+		runs++
+		if runs == 2 {
+			return errFailed
+		}
+		return nil
+	}, WithTracerProvider(tp))
+	if _, err := s.Add("* * * * *", job); err != nil {
+		t.Fatal(err)
+	}
+	parent.End()
+	fc.Advance(2 * time.Minute)
+
+	spans := rec.Ended()[1:]
+	if len(spans) != 2 {
+		t.Fatalf("got %d job spans, want 2", len(spans))
+	}
+	for i, want := range []codes.Code{codes.Unset, codes.Error} {
+		span := spans[i]
+		if got := span.Parent().SpanID(); got != parent.SpanContext().SpanID() {
+			t.Errorf("run %d: got parent span %v, want %v", i, got, parent.SpanContext().SpanID())
+		}
+		if got := span.Status().Code; got != want {
+			t.Errorf("run %d: got status %v, want %v", i, got, want)
+		}
+	}
+}
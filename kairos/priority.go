@@ -0,0 +1,166 @@
+package kairos
+
+import "time"
+
+// A Priority determines the order in which timers that expire at the same time are dispatched.
+// When many timers expire at once, such as after the machine resumes from sleep, the timer routine
+// fires the expired timers of higher priority first, whatever their deadlines, so that heartbeats
+// are not delayed behind thousands of cache cleanups; combined with [WithMaxBatch], the timers of
+// lower priority are the ones held back.  Timers of the same priority are fired in deadline order.
+type Priority int8
+
+const (
+	// LowPriority is for timers whose expirations can wait, such as cache cleanups.
+	LowPriority Priority = -1
+	// NormalPriority is the priority of timers by default.
+	NormalPriority Priority = 0
+	// HighPriority is for timers whose expirations must not wait, such as heartbeats.
+	HighPriority Priority = 1
+)
+
+// numPriorities is the number of priority levels.
+const numPriorities = int(HighPriority-LowPriority) + 1
+
+// SetPriority sets the priority with which the timer is dispatched when it expires together with
+// other timers.  It takes effect right away, even if the timer is armed.  Priorities have no effect
+// on clocks using [WithRuntimeTimers], whose timers fire on their own.  SetPriority panics if p is
+// not one of the defined priorities.
+func (t *Timer) SetPriority(p Priority) {
+	t = t.impl()
+	if t.clk == nil {
+		panic("timer: SetPriority called on uninitialized Timer")
+	}
+	if p < LowPriority || p > HighPriority {
+		panic("kairos: invalid Priority")
+	}
+	clk := t.clk
+	clk.mutex.Lock()
+	defer clk.mutex.Unlock()
+	if p != NormalPriority {
+		clk.prioritizeLocked()
+	}
+	armed := clk.timers.Remove(t)
+	t.prio = p
+	if armed {
+		clk.timers.Insert(t)
+	}
+}
+
+// Priority returns the priority set by [Timer.SetPriority], or NormalPriority if none.
+func (t *Timer) Priority() Priority {
+	t = t.impl()
+	if t.clk == nil {
+		return NormalPriority
+	}
+	t.clk.mutex.Lock()
+	defer t.clk.mutex.Unlock()
+	return t.prio
+}
+
+// WithPriority sets the priority of the ticker's timer.  See [Timer.SetPriority].
+func WithPriority(p Priority) TickerOption {
+	if p < LowPriority || p > HighPriority {
+		panic("kairos: invalid Priority")
+	}
+	return func(cfg *tickerConfig) { cfg.prio = p }
+}
+
+// prioritizeLocked makes the clock keep its armed timers in a priorityQueue, once a timer gets a
+// priority other than NormalPriority.  Until then, the clock spares itself the cost of the
+// priority levels.  The mutex must be held.
+func (clk *clock) prioritizeLocked() {
+	if _, ok := clk.timers.(*priorityQueue); ok || clk.rtimers != nil {
+		return
+	}
+	newQueue := clk.newQueue
+	if newQueue == nil {
+		newQueue = func() timerQueue { return &timerHeap{} }
+	}
+	q := &priorityQueue{}
+	for i := range q.levels {
+		q.levels[i] = newQueue()
+	}
+	// Every timer armed so far has the normal priority.
+	q.levels[NormalPriority-LowPriority] = clk.timers
+	clk.timers = q
+}
+
+// popExpiredLocked removes and returns the expired timer that the clock fires next: the timer of
+// highest priority that is due at or before now, or nil if none is.  The mutex must be held.
+func (clk *clock) popExpiredLocked(now time.Time) *Timer {
+	q, ok := clk.timers.(*priorityQueue)
+	if !ok {
+		t := clk.peekLocked()
+		if t == nil || clk.timers.Due(t).After(now) {
+			return nil
+		}
+		clk.timers.Remove(t)
+		return t
+	}
+	for i := len(q.levels) - 1; i >= 0; i-- {
+		level := q.levels[i]
+		if t := peekQueue(level); t != nil && !level.Due(t).After(now) {
+			level.Remove(t)
+			return t
+		}
+	}
+	return nil
+}
+
+// A priorityQueue is a timerQueue made of one queue per priority level.  Peek returns the timer due
+// first, of higher priority first among those due at the same time.
+type priorityQueue struct {
+	levels [numPriorities]timerQueue // Queues by priority, from the lowest.
+}
+
+func (q *priorityQueue) level(t *Timer) timerQueue { return q.levels[t.prio-LowPriority] }
+
+func (q *priorityQueue) Insert(t *Timer)        { q.level(t).Insert(t) }
+func (q *priorityQueue) Remove(t *Timer) bool   { return q.level(t).Remove(t) }
+func (q *priorityQueue) Has(t *Timer) bool      { return q.level(t).Has(t) }
+func (q *priorityQueue) Due(t *Timer) time.Time { return q.level(t).Due(t) }
+func (q *priorityQueue) AppendTo(dst []*Timer) []*Timer {
+	for _, level := range q.levels {
+		dst = level.AppendTo(dst)
+	}
+	return dst
+}
+
+func (q *priorityQueue) Len() int {
+	n := 0
+	for _, level := range q.levels {
+		n += level.Len()
+	}
+	return n
+}
+
+func (q *priorityQueue) Peek() *Timer {
+	var first *Timer
+	var due time.Time
+	for i := len(q.levels) - 1; i >= 0; i-- {
+		// The earliest timer of a level is only known once its postponed timers have moved.
+		if t := peekQueue(q.levels[i]); t != nil && (first == nil || q.levels[i].Due(t).Before(due)) {
+			first, due = t, q.levels[i].Due(t)
+		}
+	}
+	return first
+}
+
+func (q *priorityQueue) Counts() timerCounts {
+	var c timerCounts
+	for _, level := range q.levels {
+		lc := level.Counts()
+		c.chans += lc.chans
+		c.tickers += lc.tickers
+	}
+	return c
+}
+
+func (q *priorityQueue) Size() (capacity int, bytes int64) {
+	for _, level := range q.levels {
+		c, b := level.Size()
+		capacity += c
+		bytes += b
+	}
+	return capacity, bytes
+}
@@ -0,0 +1,81 @@
+package kairos
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestPriority(t *testing.T) {
+	for _, b := range []Backend{HeapBackend(), WheelBackend(time.Millisecond)} {
+		t.Run(b.String(), func(t *testing.T) {
+			c := NewClockFromFunc(func() time.Time { return fakeStart }, WithBackend(b), WithManualExpiry())
+			defer c.Close()
+			index := map[*Timer]int{}
+			for i, tc := range []struct {
+				d    time.Duration
+				prio Priority
+			}{
+				{1 * time.Second, LowPriority},
+				{2 * time.Second, NormalPriority},
+				{3 * time.Second, HighPriority},
+				{4 * time.Second, LowPriority},
+				{5 * time.Second, HighPriority},
+				{6 * time.Second, NormalPriority},
+			} {
+				timer := c.NewTimer(tc.d)
+				timer.SetPriority(tc.prio) // Moves the armed timer.
+				if got := timer.Priority(); got != tc.prio {
+					t.Errorf("timer %d: got priority %v, want %v", i, got, tc.prio)
+				}
+				index[timer] = i
+			}
+			late := c.NewTimer(time.Hour)
+			late.SetPriority(HighPriority)
+
+			var got []int
+			for _, timer := range diagnostics(c).PopExpired(fakeStart.Add(6*time.Second), 0) {
+				got = append(got, index[timer])
+			}
+			if want := []int{2, 4, 1, 5, 0, 3}; !slices.Equal(got, want) {
+				t.Errorf("timers expired in order %v, want %v", got, want)
+			}
+			if got := diagnostics(c).Pending(); got != 1 {
+				t.Errorf("got %d pending timers, want 1", got)
+			}
+		})
+	}
+}
+
+func TestTickerPriority(t *testing.T) {
+	fc := NewFakeClock(fakeStart)
+	tk := fc.NewTicker(time.Second, WithPriority(HighPriority))
+	defer tk.Stop()
+	if got := tk.t.Priority(); got != HighPriority {
+		t.Errorf("got priority %v, want %v", got, HighPriority)
+	}
+	fc.Advance(time.Second)
+	if _, ok := recv(tk.C); !ok {
+		t.Errorf("ticker did not tick")
+	}
+}
+
+func TestSetPriorityPanics(t *testing.T) {
+	for _, tc := range []struct {
+		desc string
+		f    func()
+	}{
+		{"uninitialized", func() { new(Timer).SetPriority(HighPriority) }},
+		{"invalid", func() { NewClock().NewTimer(time.Hour).SetPriority(HighPriority + 1) }},
+		{"invalid ticker option", func() { WithPriority(LowPriority - 1) }},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("got no panic, want one")
+				}
+			}()
+			tc.f()
+		})
+	}
+}
@@ -0,0 +1,202 @@
+// Package prommetrics exports the metrics of kairos clocks to [Prometheus]: the number of armed
+// timers, the numbers of timers created, reset, stopped, and fired, how late the timers fire, in
+// all and by timer label, and the wakeups of the timer routines.
+//
+//	m := prommetrics.New()
+//	c := kairos.NewClock(kairos.WithObserver(m))
+//	m.Track(c)
+//	prometheus.MustRegister(m)
+//
+// [Prometheus]: https://prometheus.io
+package prommetrics
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rhansen/go-kairos/kairos"
+)
+
+// A Collector is a [kairos.Observer] that counts the timer operations of the clocks it observes,
+// and a [prometheus.Collector] that exports the counts, for registration with a
+// [prometheus.Registerer].  One Collector can observe several clocks, whose metrics are added up.
+type Collector struct {
+	created prometheus.Counter
+	reset   prometheus.Counter
+	stopped prometheus.Counter
+	fired   prometheus.Counter
+	late    prometheus.Histogram
+	active  *prometheus.Desc
+	byLabel *prometheus.Desc
+	wakeups *prometheus.Desc
+	batched *prometheus.Desc
+
+	mu     sync.Mutex
+	clocks []kairos.Diagnostics // Clocks whose armed timers are counted.
+}
+
+// An Option configures a [Collector].
+type Option func(*config)
+
+type config struct {
+	namespace string
+	labels    prometheus.Labels
+	buckets   []float64
+}
+
+// WithNamespace sets the prefix of the metric names, "kairos" by default.
+func WithNamespace(ns string) Option {
+	return func(cfg *config) { cfg.namespace = ns }
+}
+
+// WithConstLabels adds labels with fixed values to every metric, for example to tell the clocks of
+// different subsystems apart with one Collector each.
+func WithConstLabels(labels prometheus.Labels) Option {
+	return func(cfg *config) { cfg.labels = labels }
+}
+
+// WithLatencyBuckets sets the upper bounds, in seconds, of the buckets of the histogram of the fire
+// latency.  The default buckets range from 10 microseconds to about 2.6 seconds.
+func WithLatencyBuckets(buckets []float64) Option {
+	return func(cfg *config) { cfg.buckets = buckets }
+}
+
+// New returns a new Collector.  Pass it to [kairos.WithObserver] when creating the clocks to
+// observe, and to [Collector.Track] to count their armed timers.
+func New(opts ...Option) *Collector {
+	cfg := config{namespace: "kairos", buckets: prometheus.ExponentialBuckets(1e-5, 4, 10)}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	counter := func(name, help string) prometheus.Counter {
+		return prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace:   cfg.namespace,
+			Name:        name,
+			Help:        help,
+			ConstLabels: cfg.labels,
+		})
+	}
+	return &Collector{
+		created: counter("timers_created_total", "Number of timers and tickers created."),
+		reset:   counter("timers_reset_total", "Number of timer starts and restarts."),
+		stopped: counter("timers_stopped_total", "Number of timers stopped while armed."),
+		fired:   counter("timers_fired_total", "Number of timer expirations and ticker ticks."),
+		late: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace:   cfg.namespace,
+			Name:        "timer_fire_latency_seconds",
+			Help:        "Delay between the deadline of a timer and the time the clock fired it.",
+			ConstLabels: cfg.labels,
+			Buckets:     cfg.buckets,
+		}),
+		active: prometheus.NewDesc(prometheus.BuildFQName(cfg.namespace, "", "timers_active"),
+			"Number of armed timers, including those backing tickers.", nil, cfg.labels),
+		byLabel: prometheus.NewDesc(
+			prometheus.BuildFQName(cfg.namespace, "", "timer_fire_latency_by_label_seconds"),
+			"Delay between the deadline of a timer and the time the clock fired it, by timer label.",
+			[]string{"label"}, cfg.labels),
+		wakeups: prometheus.NewDesc(prometheus.BuildFQName(cfg.namespace, "", "routine_wakeups_total"),
+			"Number of wakeups of the timer routines.", nil, cfg.labels),
+		batched: prometheus.NewDesc(prometheus.BuildFQName(cfg.namespace, "", "routine_fired_total"),
+			"Number of timers fired by the timer routines; per wakeup, the size of their batches.",
+			nil, cfg.labels),
+	}
+}
+
+// Track adds the armed timers of c to the count of active timers, the wakeups of its timer routine
+// and the timers fired by it to their counts, and, if c was created with [kairos.WithLabelStats],
+// its expirations to the histograms of the fire latency by label, whose buckets are those of
+// [kairos.LatencyHistogram].  The other metrics only cover the clocks created
+// with [kairos.WithObserver](m).  Track panics if c does not implement [kairos.Diagnostics], as
+// the clocks of package kairos do.
+func (m *Collector) Track(c kairos.Clock) {
+	d, ok := c.(kairos.Diagnostics)
+	if !ok {
+		panic(fmt.Sprintf("prommetrics: %T does not implement kairos.Diagnostics", c))
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.clocks = append(m.clocks, d)
+}
+
+var (
+	_ kairos.Observer      = (*Collector)(nil)
+	_ prometheus.Collector = (*Collector)(nil)
+)
+
+func (m *Collector) TimerCreated()   { m.created.Inc() }
+func (m *Collector) TimerReset(bool) { m.reset.Inc() }
+func (m *Collector) TimerFired(late time.Duration) {
+	m.fired.Inc()
+	m.late.Observe(late.Seconds())
+}
+
+func (m *Collector) TimerStopped(wasActive bool) {
+	if wasActive {
+		m.stopped.Inc()
+	}
+}
+
+// Describe implements [prometheus.Collector].
+func (m *Collector) Describe(ch chan<- *prometheus.Desc) {
+	m.created.Describe(ch)
+	m.reset.Describe(ch)
+	m.stopped.Describe(ch)
+	m.fired.Describe(ch)
+	m.late.Describe(ch)
+	ch <- m.active
+	ch <- m.byLabel
+	ch <- m.wakeups
+	ch <- m.batched
+}
+
+// Collect implements [prometheus.Collector].
+func (m *Collector) Collect(ch chan<- prometheus.Metric) {
+	m.created.Collect(ch)
+	m.reset.Collect(ch)
+	m.stopped.Collect(ch)
+	m.fired.Collect(ch)
+	m.late.Collect(ch)
+	m.mu.Lock()
+	active := 0
+	var wakeups, batched uint64
+	byLabel := map[string]*kairos.LatencyHistogram{}
+	for _, c := range m.clocks {
+		active += c.Pending()
+		s := c.Stats()
+		wakeups += s.Wakeups
+		batched += s.RoutineFired
+		for label, h := range c.LabelStats() {
+			if byLabel[label] == nil {
+				byLabel[label] = &kairos.LatencyHistogram{}
+			}
+			addHistogram(byLabel[label], h)
+		}
+	}
+	m.mu.Unlock()
+	ch <- prometheus.MustNewConstMetric(m.active, prometheus.GaugeValue, float64(active))
+	ch <- prometheus.MustNewConstMetric(m.wakeups, prometheus.CounterValue, float64(wakeups))
+	ch <- prometheus.MustNewConstMetric(m.batched, prometheus.CounterValue, float64(batched))
+	for label, h := range byLabel {
+		buckets := make(map[float64]uint64, len(h.Bounds))
+		var n uint64
+		for i, b := range h.Bounds {
+			n += h.Counts[i]
+			buckets[b.Seconds()] = n
+		}
+		ch <- prometheus.MustNewConstHistogram(m.byLabel, h.Count, h.Sum.Seconds(), buckets, label)
+	}
+}
+
+// addHistogram adds the counts of o to h, which must have the same bounds or none.
+func addHistogram(h *kairos.LatencyHistogram, o kairos.LatencyHistogram) {
+	if h.Counts == nil {
+		h.Bounds, h.Counts = o.Bounds, make([]uint64, len(o.Counts))
+	}
+	for i, c := range o.Counts {
+		h.Counts[i] += c
+	}
+	h.Count += o.Count
+	h.Sum += o.Sum
+}
@@ -0,0 +1,125 @@
+package prommetrics
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/rhansen/go-kairos/kairos"
+)
+
+func TestCollector(t *testing.T) {
+	start := time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+	m := New(WithNamespace("test"))
+	fc := kairos.NewFakeClock(start, kairos.WithObserver(m))
+	defer fc.Close()
+	m.Track(fc)
+	reg := prometheus.NewPedanticRegistry()
+	reg.MustRegister(m)
+
+	timer := fc.NewTimer(time.Second)
+	stopped := fc.NewTimer(time.Second)
+	stopped.Stop()
+	stopped.Stop()
+	ticker := fc.NewTicker(time.Second)
+	defer ticker.Stop()
+	timer.Reset(2 * time.Second)
+	if err := testutil.GatherAndCompare(reg, strings.NewReader(`
+# HELP test_timers_active Number of armed timers, including those backing tickers.
+# TYPE test_timers_active gauge
+test_timers_active 2
+`), "test_timers_active"); err != nil {
+		t.Error(err)
+	}
+	fc.Advance(2 * time.Second)
+
+	for _, tc := range []struct {
+		name string
+		c    prometheus.Collector
+		want float64
+	}{
+		{"created", m.created, 3},
+		{"reset", m.reset, 4},
+		{"stopped", m.stopped, 1},
+		{"fired", m.fired, 3},
+	} {
+		if got := testutil.ToFloat64(tc.c); got != tc.want {
+			t.Errorf("%s: got %v, want %v", tc.name, got, tc.want)
+		}
+	}
+	if got := testutil.CollectAndCount(reg, "test_timer_fire_latency_seconds"); got != 1 {
+		t.Errorf("got %d latency histograms, want 1", got)
+	}
+	if err := testutil.GatherAndCompare(reg, strings.NewReader(`
+# HELP test_timers_active Number of armed timers, including those backing tickers.
+# TYPE test_timers_active gauge
+test_timers_active 1
+`), "test_timers_active"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCollectorByLabel(t *testing.T) {
+	start := time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+	m := New(WithNamespace("test"))
+	fc := kairos.NewFakeClock(start, kairos.WithLabelStats())
+	defer fc.Close()
+	m.Track(fc)
+	reg := prometheus.NewPedanticRegistry()
+	reg.MustRegister(m)
+
+	for _, label := range []string{"retry-backoff", "retry-backoff", "http-idle-timeout"} {
+		fc.NewTimer(time.Second).SetLabel(label)
+	}
+	fc.Advance(time.Second)
+	if got := testutil.CollectAndCount(reg, "test_timer_fire_latency_by_label_seconds"); got != 2 {
+		t.Errorf("got %d latency histograms by label, want 2", got)
+	}
+	mfs, err := reg.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	counts := map[string]uint64{}
+	for _, mf := range mfs {
+		if mf.GetName() != "test_timer_fire_latency_by_label_seconds" {
+			continue
+		}
+		for _, metric := range mf.GetMetric() {
+			counts[metric.GetLabel()[0].GetValue()] = metric.GetHistogram().GetSampleCount()
+		}
+	}
+	if counts["retry-backoff"] != 2 || counts["http-idle-timeout"] != 1 {
+		t.Errorf("got counts %v, want 2 retry-backoff and 1 http-idle-timeout", counts)
+	}
+}
+
+func TestCollectorWakeups(t *testing.T) {
+	start := time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+	m := New(WithNamespace("test"))
+	fc := kairos.NewFakeClock(start)
+	defer fc.Close()
+	c := kairos.NewClockFromFunc(fc.Now, kairos.WithSleeper(kairos.NewClockSleeper(fc)))
+	defer c.Close()
+	m.Track(c)
+	reg := prometheus.NewPedanticRegistry()
+	reg.MustRegister(m)
+
+	timers := []*kairos.Timer{c.NewTimer(time.Second), c.NewTimer(time.Second)}
+	fc.BlockUntil(1)
+	fc.Advance(time.Second)
+	for _, timer := range timers {
+		<-timer.C
+	}
+	if err := testutil.GatherAndCompare(reg, strings.NewReader(`
+# HELP test_routine_fired_total Number of timers fired by the timer routines; per wakeup, the size of their batches.
+# TYPE test_routine_fired_total counter
+test_routine_fired_total 2
+`), "test_routine_fired_total"); err != nil {
+		t.Error(err)
+	}
+	if got := testutil.CollectAndCount(reg, "test_routine_wakeups_total"); got != 1 {
+		t.Errorf("got %d wakeup counters, want 1", got)
+	}
+}
@@ -0,0 +1,68 @@
+package kairos
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// WithRaceDiagnostics makes the clock watch for interleavings of Stop and Reset with the
+// expirations of timers that are suspicious in code written for the timers of the standard
+// library, to help migrate it.  The clock calls report with a message describing each one, with
+// the label of the timer and the code that called Stop or Reset:
+//
+//   - Stop called on a timer whose expiration waits unread in C.  Unlike the timers of Go 1.23
+//     and later, whose Stop discards it, a later receive gets this stale expiration.
+//   - Stop or Reset called on a timer whose deadline passed but which had not fired yet, racing
+//     with its expiration.  The call wins, so the timer does not fire for that deadline, and Stop
+//     reports it active: code that infers from Stop's result whether the deadline passed is wrong.
+//
+// Only the timers created with a channel are watched.  report runs in its own goroutine;
+// [Clock.Shutdown] waits for it.  If report is nil, the messages are written with [log.Print].
+func WithRaceDiagnostics(report func(msg string)) ClockOption {
+	return func(cfg *clockConfig) {
+		if report == nil {
+			report = func(msg string) { log.Print(msg) }
+		}
+		cfg.races = report
+	}
+}
+
+// checkRaceLocked reports the suspicious interleavings, as described for WithRaceDiagnostics, of
+// the operation op on t at time now, which found t armed if active.  It must be called before the
+// operation changes the deadline of t or drains its channel.  The mutex must be held.
+func (clk *clock) checkRaceLocked(op Op, t *Timer, now time.Time, active bool) {
+	if clk.races == nil || t.c == nil || t.tk != nil {
+		return
+	}
+	var problem string
+	switch {
+	case op == OpStop && !active && len(t.C) > 0:
+		problem = "stopped with its expiration unread in C; a later receive gets that stale expiration"
+	case active && !now.Before(t.when):
+		verb := "stopped"
+		if op == OpReset {
+			verb = "reset"
+		}
+		problem = fmt.Sprintf("%s %v after its deadline, before it fired; "+
+			"it will not fire for that deadline", verb, now.Sub(t.when))
+	default:
+		return
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "kairos: timer #%d", t.id)
+	if t.label != "" {
+		fmt.Fprintf(&b, " labeled %q", t.label)
+	}
+	fmt.Fprintf(&b, " %s", problem)
+	if frames := creationFrames(callers()); len(frames) > 0 {
+		fmt.Fprintf(&b, "\ncalled from %s\n\t%s:%d", frames[0].Function, frames[0].File, frames[0].Line)
+	}
+	report, msg := clk.races, b.String()
+	clk.funcs.Add(1)
+	go func() {
+		defer clk.funcs.Done()
+		report(msg)
+	}()
+}
@@ -0,0 +1,79 @@
+package kairos
+
+import (
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRaceDiagnostics(t *testing.T) {
+	var offset atomic.Int64
+	now := func() time.Time { return fakeStart.Add(time.Duration(offset.Load())) }
+	for _, tc := range []struct {
+		desc string
+		fake bool // Whether f needs a FakeClock rather than a clock with manual expiry.
+		f    func(c Clock)
+		want string // Expected report, or "" if none.
+	}{
+		{"stop before deadline", false, func(c Clock) {
+			c.NewTimer(time.Second).Stop()
+		}, ""},
+		{"stop overdue", false, func(c Clock) {
+			timer := c.NewTimer(time.Second)
+			timer.SetLabel("request")
+			offset.Store(int64(3 * time.Second))
+			timer.Stop()
+		}, `kairos: timer labeled "request" stopped 2s after its deadline, before it fired`},
+		{"reset overdue", false, func(c Clock) {
+			timer := c.NewTimer(time.Second)
+			offset.Store(int64(3 * time.Second))
+			timer.Reset(time.Second)
+		}, "kairos: timer reset 2s after its deadline, before it fired"},
+		{"overdue func", false, func(c Clock) {
+			timer := c.AfterFunc(time.Second, func() {})
+			offset.Store(int64(3 * time.Second))
+			timer.Stop()
+		}, ""},
+		{"stop after fire", true, func(c Clock) {
+			timer := c.NewTimer(time.Second)
+			c.(*FakeClock).Advance(time.Second)
+			timer.Stop()
+		}, "kairos: timer stopped with its expiration unread in C"},
+		{"stop after receive", true, func(c Clock) {
+			timer := c.NewTimer(time.Second)
+			c.(*FakeClock).Advance(time.Second)
+			<-timer.C
+			timer.Stop()
+		}, ""},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			offset.Store(0)
+			reports := make(chan string, 10)
+			diag := WithRaceDiagnostics(func(msg string) { reports <- msg })
+			var c Clock
+			if tc.fake {
+				c = NewFakeClock(fakeStart, diag)
+			} else {
+				c = NewClockFromFunc(now, WithManualExpiry(), diag)
+			}
+			tc.f(c)
+			c.Close()
+			close(reports)
+			var got []string
+			for msg := range reports {
+				got = append(got, msg)
+			}
+			switch {
+			case tc.want == "" && len(got) > 0:
+				t.Errorf("got reports %q, want none", got)
+			case tc.want != "" && (len(got) != 1 || !strings.HasPrefix(stripIDs(got[0]), tc.want)):
+				t.Errorf("got reports %q, want one starting with %q", got, tc.want)
+			case tc.want != "" && !strings.Contains(got[0], "TestRaceDiagnostics"):
+				t.Errorf("got report %q, want it to name the caller", got[0])
+			case tc.want != "" && !idPattern.MatchString(got[0]):
+				t.Errorf("got report %q, want it to number the timer", got[0])
+			}
+		})
+	}
+}
@@ -0,0 +1,213 @@
+package kairos
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// An Op identifies the kind of a recorded timer operation.
+type Op string
+
+// Timer operations recorded by a [Recorder].
+const (
+	OpReset Op = "reset" // The timer was started or restarted, including by NewTimer and AfterFunc.
+	OpStop  Op = "stop"  // Stop was called on the timer.
+	OpFire  Op = "fire"  // The timer expired.
+)
+
+// An Event is a timer operation recorded by a [Recorder].
+type Event struct {
+	Op Op `json:"op"`
+	// Timer identifies the timer.  Timers are numbered from 1 in the order they first appear.
+	Timer uint64 `json:"timer"`
+	// Func is true if the timer was created by AfterFunc.
+	Func bool `json:"func,omitempty"`
+	// Time is the clock's time when the operation happened.
+	Time time.Time `json:"time"`
+	// Duration is the duration passed to Reset, for OpReset events.
+	Duration time.Duration `json:"duration,omitempty"`
+	// Period is the interval between ticks, for OpReset events of timers backing a [Ticker].
+	Period time.Duration `json:"period,omitempty"`
+	// Active is the value returned by Reset or Stop, for OpReset and OpStop events.
+	Active bool `json:"active,omitempty"`
+}
+
+// A Trace is a sequence of timer operations in the order they happened.  It can be serialized with
+// encoding/json.
+type Trace []Event
+
+// A Recorder records the operations on the timers of the clocks it is attached to with
+// [WithRecorder], for example to investigate a timing-sensitive incident after the fact with a
+// [Replayer].  A Recorder is safe for concurrent use.
+type Recorder struct {
+	mu     sync.Mutex // protects:
+	events Trace
+	ids    map[*Timer]uint64
+}
+
+// NewRecorder returns an empty [Recorder].
+func NewRecorder() *Recorder {
+	return &Recorder{ids: map[*Timer]uint64{}}
+}
+
+// WithRecorder makes the clock record every timer operation to r.
+func WithRecorder(r *Recorder) ClockOption {
+	return func(cfg *clockConfig) { cfg.rec = r }
+}
+
+// Trace returns a copy of the operations recorded so far.
+func (r *Recorder) Trace() Trace {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append(Trace(nil), r.events...)
+}
+
+// record appends an event to the trace.  It does nothing if r is nil.
+func (r *Recorder) record(op Op, t *Timer, now time.Time, d time.Duration, active bool) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	id, ok := r.ids[t]
+	if !ok {
+		id = uint64(len(r.ids) + 1)
+		r.ids[t] = id
+	}
+	ev := Event{Op: op, Timer: id, Func: t.f != nil, Time: now, Duration: d}
+	if op != OpFire {
+		ev.Active = active
+	}
+	if op == OpReset {
+		ev.Period = t.period
+	}
+	r.events = append(r.events, ev)
+}
+
+// A Replayer re-executes a [Trace] against a [FakeClock] in deterministic dispatch mode, one event
+// at a time, checking that the timers behave as recorded.  Between steps, the state of the clock
+// and of the replayed timers can be inspected.
+type Replayer struct {
+	clock  *FakeClock
+	trace  Trace
+	next   int
+	timers map[uint64]*Timer
+	fired  map[uint64]int // Number of fires of each timer not yet matched by an OpFire event.
+}
+
+// NewReplayer returns a [Replayer] for trace.  The fake clock starts at the time of the first
+// event.
+func NewReplayer(trace Trace) *Replayer {
+	var start time.Time
+	if len(trace) > 0 {
+		start = trace[0].Time
+	}
+	return &Replayer{
+		clock:  NewFakeClock(start, WithDeterministicDispatch()),
+		trace:  trace,
+		timers: map[uint64]*Timer{},
+		fired:  map[uint64]int{},
+	}
+}
+
+// Clock returns the fake clock driving the replayed timers.
+func (r *Replayer) Clock() *FakeClock { return r.clock }
+
+// Timer returns the replayed timer with the given ID, or nil if it has not appeared yet.
+func (r *Replayer) Timer(id uint64) *Timer { return r.timers[id] }
+
+// Step executes the next event of the trace and returns it.  It returns [io.EOF] at the end of the
+// trace, and an error if the replayed timers diverge from the recording.  Timers are allowed to fire
+// earlier in the replay than in the recording, which accounts for the delivery latency of real
+// clocks.
+func (r *Replayer) Step() (Event, error) {
+	if r.next >= len(r.trace) {
+		return Event{}, io.EOF
+	}
+	ev := r.trace[r.next]
+	r.next++
+	fail := func(format string, args ...any) (Event, error) {
+		return ev, fmt.Errorf("kairos: replay diverged at event %d (%s timer %d): %s",
+			r.next-1, ev.Op, ev.Timer, fmt.Sprintf(format, args...))
+	}
+	if ev.Time.After(r.clock.Now()) {
+		r.clock.AdvanceTo(ev.Time)
+	}
+	r.collect()
+	t := r.timers[ev.Timer]
+	switch ev.Op {
+	case OpReset:
+		if t == nil {
+			t = r.newTimer(ev)
+		}
+		if t.tk != nil {
+			t.period = ev.Period
+		}
+		if got := t.Reset(ev.Duration); got != ev.Active {
+			return fail("Reset returned %v, recorded %v", got, ev.Active)
+		}
+	case OpStop:
+		if t == nil {
+			t = r.newTimer(ev)
+		}
+		if got := t.Stop(); got != ev.Active {
+			return fail("Stop returned %v, recorded %v", got, ev.Active)
+		}
+	case OpFire:
+		if t == nil {
+			return fail("timer fired before being started")
+		}
+		if r.fired[ev.Timer] == 0 {
+			return fail("timer has not fired by %v", ev.Time)
+		}
+		r.fired[ev.Timer]--
+	default:
+		return fail("unknown operation")
+	}
+	return ev, nil
+}
+
+// Run executes the rest of the trace, stopping at the first divergence.
+func (r *Replayer) Run() error {
+	for {
+		if _, err := r.Step(); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+// newTimer creates the replayed counterpart of the timer of ev.
+func (r *Replayer) newTimer(ev Event) *Timer {
+	t := r.clock.NewStoppedTimer()
+	if ev.Period > 0 {
+		tk := &Ticker{C: t.C, c: t.c}
+		tk.t = t
+		t.tk, t.period = tk, ev.Period
+	}
+	if ev.Func {
+		id := ev.Timer
+		t = &Timer{clk: r.clock.clock, f: func() { r.fired[id]++ }}
+	}
+	r.timers[ev.Timer] = t
+	return t
+}
+
+// collect notes the channel timers that have fired.  (AfterFunc timers note it themselves.)
+func (r *Replayer) collect() {
+	for id, t := range r.timers {
+		if t.C == nil {
+			continue
+		}
+		select {
+		case <-t.C:
+			r.fired[id]++
+		default:
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package kairos
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRecordReplay(t *testing.T) {
+	rec := NewRecorder()
+	fc := NewFakeClock(fakeStart, WithRecorder(rec))
+	a := fc.NewTimer(time.Second)
+	b := fc.NewTimer(2 * time.Second)
+	called := 0
+	fc.AfterFunc(time.Second, func() { called++ })
+	fc.Advance(time.Second)
+	<-a.C
+	b.Stop()
+	a.Reset(time.Minute)
+	fc.Advance(time.Minute)
+
+	trace := rec.Trace()
+	var ops []string
+	for _, ev := range trace {
+		ops = append(ops, string(ev.Op))
+	}
+	if got, want := strings.Join(ops, " "), "reset reset reset fire fire stop reset fire"; got != want {
+		t.Errorf("got ops %q, want %q", got, want)
+	}
+
+	// Round-trip through JSON, as for a trace captured in production.
+	data, err := json.Marshal(trace)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded Trace
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewReplayer(decoded).Run(); err != nil {
+		t.Errorf("replay failed: %v", err)
+	}
+
+	// A trace that disagrees with the timers' behavior is reported.
+	decoded[5].Active = !decoded[5].Active
+	if err := NewReplayer(decoded).Run(); err == nil || !strings.Contains(err.Error(), "event 5") {
+		t.Errorf("got error %v, want divergence at event 5", err)
+	}
+}
+
+func TestRecordReplayReal(t *testing.T) {
+	rec := NewRecorder()
+	c := NewClock(WithRecorder(rec))
+	t.Cleanup(func() { c.Close() })
+	timer := c.NewTimer(10 * time.Millisecond)
+	<-timer.C
+	timer.Reset(time.Hour)
+	timer.Stop()
+	r := NewReplayer(rec.Trace())
+	if err := r.Run(); err != nil {
+		t.Errorf("replay failed: %v", err)
+	}
+	if r.Timer(1) == nil {
+		t.Errorf("replayed timer 1 does not exist")
+	}
+}
+
+func TestRecordReplayTicker(t *testing.T) {
+	rec := NewRecorder()
+	fc := NewFakeClock(fakeStart, WithRecorder(rec))
+	tk := fc.NewTicker(time.Second)
+	for i := 0; i < 3; i++ {
+		fc.Advance(time.Second)
+		<-tk.C
+	}
+	tk.Reset(time.Minute)
+	fc.Advance(time.Minute)
+	tk.Stop()
+	trace := rec.Trace()
+	if got, want := trace[0].Period, time.Second; got != want {
+		t.Errorf("got period %v, want %v", got, want)
+	}
+	if err := NewReplayer(trace).Run(); err != nil {
+		t.Errorf("replay failed: %v", err)
+	}
+}
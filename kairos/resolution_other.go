@@ -0,0 +1,6 @@
+//go:build !wasm
+
+package kairos
+
+// defaultResolution is the resolution of the clocks that sleep in real time; see WithResolution.
+const defaultResolution = 0
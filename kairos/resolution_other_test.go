@@ -0,0 +1,6 @@
+//go:build !wasm
+
+package kairos
+
+// marginScale scales the margin of timing tests.
+const marginScale = 1
@@ -0,0 +1,11 @@
+package kairos
+
+import "time"
+
+// defaultResolution is the resolution of the clocks that sleep in real time; see WithResolution.
+// The hosts of js/wasm and wasip1 programs, such as browsers, Node.js and WASI runtimes, deliver
+// timer events with millisecond granularity at best, and browsers clamp the delays of nested
+// timeouts further.  Waking the timer routine for each sub-millisecond deadline would cost a round
+// trip through the host's event loop each, so the deadlines of the same millisecond are fired
+// together.
+const defaultResolution = time.Millisecond
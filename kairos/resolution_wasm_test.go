@@ -0,0 +1,64 @@
+package kairos
+
+import (
+	"testing"
+	"time"
+)
+
+// marginScale scales the margin of timing tests: the hosts of js/wasm and wasip1 programs run
+// them on a single thread, which the host's event loop, garbage collector and compiler can stall
+// for a hundred milliseconds or more.
+const marginScale = 5
+
+func TestWasmDefaultResolution(t *testing.T) {
+	for _, tc := range []struct {
+		desc string
+		opts []ClockOption
+		want time.Duration
+	}{
+		{"default", nil, time.Millisecond},
+		{"explicit", []ClockOption{WithResolution(10 * time.Millisecond)}, 10 * time.Millisecond},
+		{"simulated time", []ClockOption{WithSleeper(NewClockSleeper(NewFakeClock(fakeStart)))}, 0},
+		{"sharded", []ClockOption{WithShards(2)}, time.Millisecond},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			c := NewClock(tc.opts...)
+			defer c.Close()
+			shards := []*clock{}
+			switch c := c.(type) {
+			case *clock:
+				shards = append(shards, c)
+			case *shardedClock:
+				shards = c.shards
+			}
+			for _, shard := range shards {
+				if shard.res != tc.want {
+					t.Errorf("got resolution %v, want %v", shard.res, tc.want)
+				}
+			}
+		})
+	}
+}
+
+func TestWasmTimers(t *testing.T) {
+	c := NewClock()
+	defer c.Close()
+	start := time.Now()
+	var timers []*Timer
+	for _, d := range []time.Duration{100 * time.Microsecond, 500 * time.Microsecond, 3 * time.Millisecond} {
+		timers = append(timers, c.NewTimer(d))
+	}
+	for _, timer := range timers {
+		got := waitFired(t, timer)
+		if d := got.Sub(start); d < timer.when.Sub(start) || d >= timer.when.Sub(start)+margin {
+			t.Errorf("timer fired after %v, want %v", d, timer.when.Sub(start))
+		}
+	}
+	done := make(chan struct{})
+	c.AfterFunc(time.Millisecond, func() { close(done) })
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("AfterFunc callback did not run")
+	}
+}
@@ -0,0 +1,107 @@
+package kairos
+
+import (
+	"context"
+	"time"
+)
+
+// RunnerHealth reports the state of a clock's timer routine, the goroutine that fires the timers of
+// a clock created by [NewClock] or [NewClockFromFunc], so that the embedders of a clock can
+// supervise it like their other components.  The routine starts when the first timer is started
+// and, with [WithIdleShutdown], exits while no timer is armed; [Diagnostics.StopRunner] stops it
+// until [Diagnostics.StartRunner] is called, for example while the process is being drained, and
+// timers armed meanwhile fire late, when the routine is restarted.  Clocks with [WithRuntimeTimers]
+// or [WithManualExpiry] and fake clocks have no timer routine: StartRunner and StopRunner do
+// nothing for them and their health reports no routine running and no lag.
+type RunnerHealth struct {
+	// Running reports whether the timer routine is running.
+	Running bool
+	// Stopped reports whether the routine was stopped by StopRunner or by closing the clock.
+	Stopped bool
+	// Pending is the number of armed timers.
+	Pending int
+	// Lag is how long ago the earliest armed timer was due, or zero if no timer is overdue.  It
+	// stays below the slack of [WithSlack] and the resolution of [WithResolution] while the
+	// routine keeps up; a lag that grows means the routine is stopped, stuck, or falling behind.
+	Lag time.Duration
+	// Stalled is how long the timer routine has been stuck firing a batch of timers, if longer
+	// than the threshold of [WithWatchdog], or zero.  While the routine is stuck holding the
+	// clock's internal lock, Pending and Lag are not reported.
+	Stalled time.Duration
+}
+
+// StartRunner restarts the timer routine stopped by StopRunner, if timers are armed, or lets the
+// next timer started restart it.  Timers that expired while the routine was stopped fire right
+// away.  StartRunner returns [ErrClosed] if the clock is closed.
+func (clk *clock) StartRunner() error {
+	clk.mutex.Lock()
+	if clk.closed {
+		clk.mutex.Unlock()
+		return ErrClosed
+	}
+	if clk.sleeper == nil {
+		clk.mutex.Unlock()
+		return nil
+	}
+	clk.held = false
+	if clk.timers.Len() > 0 {
+		clk.startRoutineLocked()
+	}
+	clk.mutex.Unlock()
+	clk.kick()
+	return nil
+}
+
+// StopRunner stops the timer routine until StartRunner is called, then waits until the routine has
+// exited or ctx is done.  Armed timers stay armed, and timers can still be started and stopped,
+// but none fires meanwhile.  StopRunner returns [ErrClosed] if the clock is closed.
+func (clk *clock) StopRunner(ctx context.Context) error {
+	clk.mutex.Lock()
+	if clk.closed {
+		clk.mutex.Unlock()
+		return ErrClosed
+	}
+	if clk.sleeper == nil {
+		clk.mutex.Unlock()
+		return nil
+	}
+	clk.held = true
+	running, stopC, doneC := clk.running, clk.stopC, clk.doneC
+	clk.running = false
+	clk.mutex.Unlock()
+	if !running {
+		return nil
+	}
+	close(stopC)
+	select {
+	case <-doneC:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Health reports the state of the timer routine.
+func (clk *clock) Health() RunnerHealth {
+	now := clk.now()
+	stalled := clk.watchdog.stalled()
+	if stalled == 0 {
+		clk.mutex.Lock()
+	} else if !clk.mutex.TryLock() {
+		// The routine is likely stuck holding the lock.
+		return RunnerHealth{Running: true, Stalled: stalled}
+	}
+	defer clk.mutex.Unlock()
+	h := RunnerHealth{
+		Running: clk.running,
+		Stopped: clk.held || clk.closed,
+		Pending: clk.armedLocked(),
+		Stalled: stalled,
+	}
+	if clk.sleeper != nil {
+		if t := clk.peekLocked(); t != nil {
+			h.Lag = max(now.Sub(clk.timers.Due(t)), 0)
+		}
+	}
+	return h
+}
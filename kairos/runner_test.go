@@ -0,0 +1,103 @@
+package kairos
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRunner(t *testing.T) {
+	fc := NewFakeClock(fakeStart)
+	c := NewClockFromFunc(fc.Now, WithSleeper(NewClockSleeper(fc)))
+	defer c.Close()
+	check := func(desc string, want RunnerHealth) {
+		t.Helper()
+		if got := diagnostics(c).Health(); got != want {
+			t.Errorf("%s: got health %+v, want %+v", desc, got, want)
+		}
+	}
+	check("new clock", RunnerHealth{})
+	timer := c.NewTimer(time.Second)
+	fc.BlockUntil(1)
+	check("armed", RunnerHealth{Running: true, Pending: 1})
+
+	if err := diagnostics(c).StopRunner(context.Background()); err != nil {
+		t.Fatalf("StopRunner: got error %v, want nil", err)
+	}
+	fc.Advance(3 * time.Second)
+	if _, ok := recv(timer.C); ok {
+		t.Errorf("timer fired while the runner was stopped")
+	}
+	late := c.NewTimer(time.Second)
+	check("stopped", RunnerHealth{Stopped: true, Pending: 2, Lag: 2 * time.Second})
+
+	if err := diagnostics(c).StartRunner(); err != nil {
+		t.Fatalf("StartRunner: got error %v, want nil", err)
+	}
+	if got, want := waitFired(t, timer), fc.Now(); !got.Equal(want) {
+		t.Errorf("got fire time %v, want %v", got, want)
+	}
+	fc.BlockUntil(1)
+	check("restarted", RunnerHealth{Running: true, Pending: 1})
+	fc.Advance(time.Second)
+	waitFired(t, late)
+
+	c.Close()
+	check("closed", RunnerHealth{Stopped: true})
+	if err := diagnostics(c).StartRunner(); err != ErrClosed {
+		t.Errorf("StartRunner of a closed clock: got error %v, want %v", err, ErrClosed)
+	}
+	if err := diagnostics(c).StopRunner(context.Background()); err != ErrClosed {
+		t.Errorf("StopRunner of a closed clock: got error %v, want %v", err, ErrClosed)
+	}
+}
+
+func TestRunnerWithoutRoutine(t *testing.T) {
+	for _, tc := range []struct {
+		desc string
+		c    Clock
+	}{
+		{"fake", NewFakeClock(fakeStart)},
+		{"runtime timers", NewClock(WithRuntimeTimers())},
+		{"manual expiry", NewClock(WithManualExpiry())},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			defer tc.c.Close()
+			tc.c.NewTimer(time.Hour)
+			if err := diagnostics(tc.c).StopRunner(context.Background()); err != nil {
+				t.Errorf("StopRunner: got error %v, want nil", err)
+			}
+			if err := diagnostics(tc.c).StartRunner(); err != nil {
+				t.Errorf("StartRunner: got error %v, want nil", err)
+			}
+			if got, want := diagnostics(tc.c).Health(), (RunnerHealth{Pending: 1}); got != want {
+				t.Errorf("got health %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestShardedRunner(t *testing.T) {
+	c := NewClock(WithShards(2))
+	defer c.Close()
+	c.NewTimer(time.Hour)
+	c.NewTimer(time.Hour)
+	if got, want := diagnostics(c).Health(), (RunnerHealth{Running: true, Pending: 2}); got != want {
+		t.Errorf("got health %+v, want %+v", got, want)
+	}
+	if err := diagnostics(c).StopRunner(context.Background()); err != nil {
+		t.Errorf("StopRunner: got error %v, want nil", err)
+	}
+	if got, want := diagnostics(c).Health(), (RunnerHealth{Stopped: true, Pending: 2}); got != want {
+		t.Errorf("got health %+v, want %+v", got, want)
+	}
+	start := time.Now()
+	timer := c.NewTimer(10 * time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	if err := diagnostics(c).StartRunner(); err != nil {
+		t.Errorf("StartRunner: got error %v, want nil", err)
+	}
+	if got := waitFired(t, timer).Sub(start); got < 20*time.Millisecond || got >= 20*time.Millisecond+margin {
+		t.Errorf("timer fired after %v, want 20ms, when the runner was restarted", got)
+	}
+}
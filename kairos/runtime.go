@@ -0,0 +1,179 @@
+package kairos
+
+import (
+	"sort"
+	"time"
+)
+
+// WithRuntimeTimers makes the clock delegate each timer to a standard library runtime timer instead
+// of managing its own heap and background goroutine.  Such a clock cooperates with
+// [testing/synctest]: create it inside the bubble (and install it with [SetClock] if the code under
+// test uses the package-level functions), and its timers fire when the bubble's fake time
+// advances.  The clock's time source must advance at the rate of the runtime timers, so this
+// option is meant for [NewClock]; [WithSleeper] has no effect on such a clock.
+func WithRuntimeTimers() ClockOption {
+	return func(cfg *clockConfig) { cfg.runtime = true }
+}
+
+func newRuntimeClock(now func() time.Time, cfg clockConfig) *clock {
+	clk := &clock{
+		now:     now,
+		kick:    func() {},
+		policy:  cfg.policy,
+		rec:     cfg.rec,
+		timers:  &timerHeap{},
+		rtimers: map[*Timer]struct{}{},
+		ties:    cfg.ties,
+	}
+	clk.setLimit(cfg)
+	clk.setDispatch(cfg)
+	clk.leaks = cfg.leaks
+	clk.obs = cfg.obs
+	clk.sites = cfg.sites
+	clk.log = cfg.log
+	clk.setStale(cfg, true)
+	clk.races = cfg.races
+	clk.setLatency(cfg)
+	clk.setSamples(cfg)
+	clk.setAudit(cfg)
+	clk.health = newHealthCheck(cfg)
+	return clk
+}
+
+// delRuntimeTimer is the counterpart of delTimer for clocks that delegate to runtime timers.
+func (clk *clock) delRuntimeTimer(t *Timer) bool {
+	var now time.Time
+	if clk.stopTimed() {
+		now = clk.now()
+	}
+	clk.mutex.Lock()
+	defer clk.mutex.Unlock()
+	armed := clk.disarmRuntimeLocked(t)
+	clk.checkRaceLocked(OpStop, t, now, armed)
+	if t.rt != nil {
+		t.rt.Stop()
+	}
+	if armed {
+		clk.disarmedLocked()
+	}
+	clk.record(OpStop, t, now, 0, armed)
+	return armed
+}
+
+// startRuntimeTimer is the counterpart of startTimer for clocks that delegate to runtime timers.
+func (clk *clock) startRuntimeTimer(t *Timer, d time.Duration, when time.Time) (bool, error) {
+	now := clk.now()
+	restart := when.IsZero()
+	if restart {
+		when = t.deadline(now, d)
+	}
+	clk.mutex.Lock()
+	defer clk.mutex.Unlock()
+	armed := clk.disarmRuntimeLocked(t)
+	clk.checkRaceLocked(OpReset, t, now, armed)
+	select {
+	case <-t.C:
+	default:
+	}
+	t.drainFiringsLocked()
+	if t.tk != nil {
+		t.tk.drainLocked()
+	}
+	if clk.closed {
+		return armed, ErrClosed
+	}
+	if !armed {
+		if retry, err := clk.reserveLocked(); err != nil {
+			return false, err
+		} else if retry {
+			clk.mutex.Unlock()
+			defer clk.mutex.Lock() // For the deferred Unlock.
+			if restart {
+				when = time.Time{}
+			}
+			return clk.startRuntimeTimer(t, d, when)
+		}
+	}
+	t.when = when
+	if t.tk != nil && restart {
+		t.tk.anchor, t.tk.n, t.tk.tickSeq = now, 1, 1
+	}
+	clk.armRuntimeLocked(t)
+	clk.record(OpReset, t, now, d, armed)
+	if t.rt == nil {
+		t.rt = time.AfterFunc(when.Sub(now), func() { clk.fireRuntimeTimer(t) })
+	} else {
+		t.rt.Reset(when.Sub(now))
+	}
+	return armed, nil
+}
+
+// armRuntimeLocked records that t is armed.  The mutex must be held.
+func (clk *clock) armRuntimeLocked(t *Timer) {
+	clk.rtimers[t] = struct{}{}
+	clk.rcounts.add(t, 1)
+}
+
+// disarmRuntimeLocked records that t is no longer armed, and reports whether it was.  The mutex
+// must be held.
+func (clk *clock) disarmRuntimeLocked(t *Timer) bool {
+	if _, armed := clk.rtimers[t]; !armed {
+		return false
+	}
+	delete(clk.rtimers, t)
+	clk.rcounts.add(t, -1)
+	return true
+}
+
+// fireRuntimeTimer is called by t's runtime timer.
+func (clk *clock) fireRuntimeTimer(t *Timer) {
+	now := clk.now()
+	clk.mutex.Lock()
+	defer clk.mutex.Unlock()
+	// The runtime timer might have fired concurrently with a Stop or Reset call.
+	if _, armed := clk.rtimers[t]; !armed {
+		return
+	}
+	// The deadline of an aligned ticker follows the wall clock, which might have stepped back.
+	if now.Before(t.when) {
+		t.rt.Reset(t.when.Sub(now))
+		return
+	}
+	clk.disarmRuntimeLocked(t)
+	clk.disarmedLocked()
+	clk.fireLocked(t, now)
+}
+
+// popRuntimeTimerLocked disarms and returns the armed timer with the earliest deadline at or before
+// now, or nil if there is none.  The mutex must be held.
+func (clk *clock) popRuntimeTimerLocked(now time.Time) *Timer {
+	var first *Timer
+	for t := range clk.rtimers {
+		if t.when.After(now) {
+			continue
+		}
+		if first == nil || t.when.Before(first.when) || t.when.Equal(first.when) && t.seq < first.seq {
+			first = t
+		}
+	}
+	if first != nil {
+		first.rt.Stop()
+		clk.disarmRuntimeLocked(first)
+		clk.disarmedLocked()
+	}
+	return first
+}
+
+// removeRuntimeTimersLocked disarms all timers and returns them in deadline order.  The mutex must
+// be held.
+func (clk *clock) removeRuntimeTimersLocked() []*Timer {
+	pending := make([]*Timer, 0, len(clk.rtimers))
+	for t := range clk.rtimers {
+		t.rt.Stop()
+		pending = append(pending, t)
+	}
+	clk.rtimers = map[*Timer]struct{}{}
+	clk.rcounts = timerCounts{}
+	sort.Slice(pending, func(i, j int) bool { return pending[i].when.Before(pending[j].when) })
+	return pending
+}
@@ -0,0 +1,31 @@
+package kairos
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRuntimeTimers(t *testing.T) {
+	c := NewClock(WithRuntimeTimers())
+	const want = 100 * time.Millisecond
+	start := time.Now()
+	timer := c.NewTimer(time.Hour)
+	if got := diagnostics(c).Pending(); got != 1 {
+		t.Errorf("got %d pending timers, want 1", got)
+	}
+	if !timer.Reset(want) {
+		t.Errorf("Reset: was active is false")
+	}
+	waitFired(t, timer)
+	if got := time.Since(start); got < want || got >= want+margin {
+		t.Errorf("timer fired at wrong time; got duration %v, want %v", got, want)
+	}
+	if timer.Stop() {
+		t.Errorf("Stop of fired timer: was active is true")
+	}
+	stopped := c.AfterFunc(0, func() {})
+	stopped.Stop()
+	if got := diagnostics(c).Pending(); got != 0 {
+		t.Errorf("got %d pending timers, want 0", got)
+	}
+}
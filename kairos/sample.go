@@ -0,0 +1,98 @@
+package kairos
+
+import (
+	"cmp"
+	"math/rand/v2"
+	"slices"
+	"time"
+)
+
+// WithDurationSampling makes the clock keep uniform random samples of up to size durations
+// requested of its timers and of how long its timers stayed armed, reported in [Stats.Requested]
+// and [Stats.Lifetimes], to tune the choice of backend, such as the tick of [WheelBackend], and the
+// slack of [WithSlack] to the actual workload: timeouts that are mostly stopped long before their
+// deadlines favor a timing wheel, for example.  The samples are reservoirs: every duration seen
+// since the clock was created has the same chance to be in them, however many there were.
+// WithDurationSampling panics if size is not positive.
+func WithDurationSampling(size int) ClockOption {
+	if size <= 0 {
+		panic("kairos: non-positive size for WithDurationSampling")
+	}
+	return func(cfg *clockConfig) { cfg.sampleSize = size }
+}
+
+// A DurationSample is a uniform random sample of durations.  See [WithDurationSampling].
+type DurationSample struct {
+	Values []time.Duration // Sampled durations, in no particular order.
+	Count  uint64          // Number of durations seen, of which Values is a sample.
+}
+
+// Quantile returns the q-quantile of the sampled durations, or 0 if there are none.
+func (s DurationSample) Quantile(q float64) time.Duration {
+	if len(s.Values) == 0 {
+		return 0
+	}
+	v := slices.Clone(s.Values)
+	slices.Sort(v)
+	i := int(q * float64(len(v)))
+	return v[min(max(i, 0), len(v)-1)]
+}
+
+// mergeSamples returns the union of the samples s and o of different durations, either of which
+// may be nil.  The union is uniform if the samples are in proportion to their counts, as those of
+// evenly loaded shards are.
+func mergeSamples(s, o *DurationSample) *DurationSample {
+	if s == nil || o == nil {
+		return cmp.Or(s, o)
+	}
+	values := append(slices.Clone(s.Values), o.Values...)
+	return &DurationSample{Values: values, Count: s.Count + o.Count}
+}
+
+// A reservoir is a DurationSample of bounded size maintained with Vitter's algorithm R.
+type reservoir struct {
+	size int
+	s    DurationSample
+}
+
+// add offers d to the sample.
+func (r *reservoir) add(d time.Duration) {
+	r.s.Count++
+	if len(r.s.Values) < r.size {
+		r.s.Values = append(r.s.Values, d)
+	} else if i := rand.Uint64N(r.s.Count); i < uint64(r.size) {
+		r.s.Values[i] = d
+	}
+}
+
+// sample returns a copy of the sample.
+func (r *reservoir) sample() *DurationSample {
+	return &DurationSample{Values: slices.Clone(r.s.Values), Count: r.s.Count}
+}
+
+// durationSamples are the samples of a clock with WithDurationSampling.  They are protected by the
+// clock's mutex.
+type durationSamples struct {
+	requested, lifetimes reservoir
+}
+
+// setSamples applies the sampling option of cfg to clk.
+func (clk *clock) setSamples(cfg clockConfig) {
+	if cfg.sampleSize > 0 {
+		clk.samples = &durationSamples{
+			requested: reservoir{size: cfg.sampleSize},
+			lifetimes: reservoir{size: cfg.sampleSize},
+		}
+	}
+}
+
+// sampleLocked samples the duration requested of t by a reset, and the time for which t was armed
+// if the operation disarmed it, at time now.  The mutex must be held.
+func (clk *clock) sampleLocked(op Op, t *Timer, now time.Time, d time.Duration, active bool) {
+	if op == OpReset {
+		clk.samples.requested.add(d)
+	}
+	if active && !t.armedAt.IsZero() {
+		clk.samples.lifetimes.add(now.Sub(t.armedAt))
+	}
+}
@@ -0,0 +1,45 @@
+package kairos
+
+import (
+	"math"
+	"time"
+)
+
+// NewScaledClock returns a [Clock] whose time passes factor times as fast as base's time.  With a
+// factor of 100, a timer created with a duration of 100 seconds fires after one second of base
+// time, and Now advances by 100 seconds per second of base time.  The scaled clock's time starts at
+// base's current time.  NewScaledClock panics if factor is not positive.  [WithSleeper] has no
+// effect on a scaled clock.
+func NewScaledClock(base Clock, factor float64, opts ...ClockOption) Clock {
+	if !(factor > 0) {
+		panic("kairos: NewScaledClock called with non-positive factor")
+	}
+	start := base.Now()
+	now := func() time.Time {
+		return start.Add(scaleDuration(base.Now().Sub(start), factor))
+	}
+	cfg := newClockConfig(opts)
+	cfg.sleeper = scaledSleeper{NewClockSleeper(base), factor}
+	return newClockWith(now, cfg)
+}
+
+// scaleDuration returns d multiplied by factor, saturating instead of overflowing.  The result is
+// rounded up so that a timer routine sleeping for a scaled duration never wakes early.
+func scaleDuration(d time.Duration, factor float64) time.Duration {
+	f := math.Ceil(float64(d) * factor)
+	switch {
+	case f >= math.MaxInt64:
+		return math.MaxInt64
+	case f <= math.MinInt64:
+		return math.MinInt64
+	}
+	return time.Duration(f)
+}
+
+// scaledSleeper converts the scaled clock's durations into base clock durations.
+type scaledSleeper struct {
+	Sleeper
+	factor float64
+}
+
+func (s scaledSleeper) Reset(d time.Duration) { s.Sleeper.Reset(scaleDuration(d, 1/s.factor)) }
@@ -0,0 +1,50 @@
+package kairos
+
+import (
+	"testing"
+	"time"
+)
+
+// waitFired waits up to a second of real time for timer to fire, for clocks whose timer routine
+// delivers asynchronously.
+func waitFired(t *testing.T, timer *Timer) time.Time {
+	t.Helper()
+	select {
+	case got := <-timer.C:
+		return got
+	case <-time.After(time.Second):
+		t.Fatalf("timer did not fire")
+		return time.Time{}
+	}
+}
+
+func TestScaledClock(t *testing.T) {
+	for _, factor := range []float64{100, 0.5} {
+		fc := NewFakeClock(fakeStart)
+		sc := NewScaledClock(fc, factor)
+		d := time.Duration(factor * float64(time.Second)) // One second of base time.
+		timer := sc.NewTimer(d)
+		fc.Advance(999 * time.Millisecond)
+		if got, want := sc.Now(), fakeStart.Add(scaleDuration(999*time.Millisecond, factor)); !got.Equal(want) {
+			t.Errorf("factor %v: got Now() %v, want %v", factor, got, want)
+		}
+		time.Sleep(10 * time.Millisecond)
+		if _, ok := recv(timer.C); ok {
+			t.Errorf("factor %v: timer fired early", factor)
+		}
+		fc.Advance(time.Millisecond)
+		if got, want := waitFired(t, timer), fakeStart.Add(d); !got.Equal(want) {
+			t.Errorf("factor %v: got fire time %v, want %v", factor, got, want)
+		}
+	}
+}
+
+func TestScaledClockReal(t *testing.T) {
+	sc := NewScaledClock(realClock, 100)
+	const want = 10 * time.Second // 100ms of real time.
+	start := sc.Now()
+	waitFired(t, sc.NewTimer(want))
+	if got := sc.Now().Sub(start); got < want || got >= want+100*margin {
+		t.Errorf("timer fired at wrong time; got scaled duration %v, want %v", got, want)
+	}
+}
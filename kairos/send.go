@@ -0,0 +1,36 @@
+package kairos
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// ErrSendTimeout is returned by [SendTimeout] when the send does not complete in time.  It wraps
+// [context.DeadlineExceeded], so it is reported as a timeout.
+var ErrSendTimeout = fmt.Errorf("kairos: send timed out: %w", context.DeadlineExceeded)
+
+// SendTimeout sends v on ch, waiting at most d according to c.  It returns nil once v is sent,
+// [ErrSendTimeout] if d elapsed first, or the error of ctx if ctx is done first.  A send that can
+// proceed right away always does, even if d is not positive.  The timer is taken from c with
+// [Clock.AcquireTimer] and released afterward, so SendTimeout does not allocate in steady state.
+func SendTimeout[T any](ctx context.Context, c Clock, ch chan<- T, v T, d time.Duration) error {
+	select {
+	case ch <- v:
+		return nil
+	default:
+	}
+	if d <= 0 {
+		return ErrSendTimeout
+	}
+	t := c.AcquireTimer(d)
+	defer ReleaseTimer(t)
+	select {
+	case ch <- v:
+		return nil
+	case <-t.C:
+		return ErrSendTimeout
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,49 @@
+package kairos
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSendTimeout(t *testing.T) {
+	fc := NewFakeClock(fakeStart)
+	defer fc.Close()
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	for _, tc := range []struct {
+		desc string
+		ctx  context.Context
+		ch   chan int
+		d    time.Duration
+		want error
+	}{
+		{"ready", context.Background(), make(chan int, 1), time.Second, nil},
+		{"ready without wait", context.Background(), make(chan int, 1), 0, nil},
+		{"full without wait", context.Background(), make(chan int), 0, ErrSendTimeout},
+		{"cancelled", cancelled, make(chan int), time.Second, context.Canceled},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			if err := SendTimeout(tc.ctx, fc, tc.ch, 1, tc.d); err != tc.want {
+				t.Errorf("got error %v, want %v", err, tc.want)
+			}
+		})
+	}
+
+	errc := make(chan error)
+	go func() { errc <- SendTimeout(context.Background(), fc, make(chan int), 1, time.Second) }()
+	fc.BlockUntil(1)
+	fc.Advance(time.Second)
+	if err := <-errc; err != ErrSendTimeout {
+		t.Errorf("got error %v after the timeout, want %v", err, ErrSendTimeout)
+	}
+
+	ch := make(chan int)
+	go func() { errc <- SendTimeout(context.Background(), fc, ch, 1, time.Second) }()
+	if v := <-ch; v != 1 {
+		t.Errorf("got %d, want 1", v)
+	}
+	if err := <-errc; err != nil {
+		t.Errorf("got error %v after the receive, want nil", err)
+	}
+}
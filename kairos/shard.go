@@ -0,0 +1,204 @@
+package kairos
+
+import (
+	"context"
+	"runtime"
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+// WithShards makes a clock created by [NewClock] or [NewClockFromFunc] spread its timers over n
+// independent timer heaps, each with its own lock and timer routine, so that goroutines that start,
+// reset, and stop different timers concurrently rarely contend for the same lock.  A non-positive
+// n means [runtime.GOMAXPROCS](0).  Timers are assigned to the shards in turn when they are created
+// and stay in their shard.  Timers with equal deadlines fire in the order they were started only if
+// they are in the same shard, and [FireOnClose] fires the pending timers in deadline order shard by
+// shard.  The option has no effect together with [WithSleeper], since a sleeper cannot be shared
+// between timer routines, or with [WithRuntimeTimers], whose runtime timers are already sharded by
+// the Go runtime.
+func WithShards(n int) ClockOption {
+	return func(cfg *clockConfig) { cfg.shards, cfg.sharded = n, true }
+}
+
+// A shardedClock is a Clock whose timers are spread over several clocks with the same time source.
+type shardedClock struct {
+	shards []*clock
+	next   atomic.Uint64 // Number of timers created, to assign them to shards in turn.
+	health *healthCheck  // State of the checks of Healthy.
+}
+
+func newShardedClock(now func() time.Time, cfg clockConfig) *shardedClock {
+	n := cfg.shards
+	if n <= 0 {
+		n = runtime.GOMAXPROCS(0)
+	}
+	sc := &shardedClock{shards: make([]*clock, n), health: newHealthCheck(cfg)}
+	if cfg.limit > 0 {
+		cfg.limit = (cfg.limit + n - 1) / n
+	}
+	// The shards sleep in real time, like a clock without WithSleeper.
+	if cfg.res == 0 {
+		cfg.res = defaultResolution
+	}
+	for i := range sc.shards {
+		cfg.sleeper = newRealSleeper()
+		sc.shards[i] = newClockWith(now, cfg)
+	}
+	return sc
+}
+
+// pick returns the shard of a new timer.
+func (sc *shardedClock) pick() *clock {
+	return sc.shards[(sc.next.Add(1)-1)%uint64(len(sc.shards))]
+}
+
+func (sc *shardedClock) Now() time.Time                  { return sc.shards[0].Now() }
+func (sc *shardedClock) Since(t time.Time) time.Duration { return sc.shards[0].Since(t) }
+func (sc *shardedClock) Until(t time.Time) time.Duration { return sc.shards[0].Until(t) }
+func (sc *shardedClock) Sleep(d time.Duration)           { sc.pick().Sleep(d) }
+
+func (sc *shardedClock) After(d time.Duration) <-chan time.Time     { return sc.pick().After(d) }
+func (sc *shardedClock) AfterFunc(d time.Duration, f func()) *Timer { return sc.pick().AfterFunc(d, f) }
+func (sc *shardedClock) NewTimer(d time.Duration) *Timer            { return sc.pick().NewTimer(d) }
+func (sc *shardedClock) NewTimerAt(t time.Time) *Timer              { return sc.pick().NewTimerAt(t) }
+func (sc *shardedClock) NewStoppedTimer() *Timer                    { return sc.pick().NewStoppedTimer() }
+func (sc *shardedClock) AcquireTimer(d time.Duration) *Timer        { return sc.pick().AcquireTimer(d) }
+func (sc *shardedClock) InitTimer(t *Timer, f func())               { sc.pick().InitTimer(t, f) }
+
+func (sc *shardedClock) AfterFuncContext(d time.Duration, f func(ctx context.Context)) *FuncTimer {
+	return sc.pick().AfterFuncContext(d, f)
+}
+
+func (sc *shardedClock) ContextWithTimeout(parent context.Context, d time.Duration) (context.Context,
+	context.CancelFunc) {
+	return sc.pick().ContextWithTimeout(parent, d)
+}
+
+func (sc *shardedClock) NewTimerCtx(ctx context.Context, d time.Duration) *Timer {
+	return sc.pick().NewTimerCtx(ctx, d)
+}
+
+func (sc *shardedClock) NewTicker(d time.Duration, opts ...TickerOption) *Ticker {
+	return sc.pick().NewTicker(d, opts...)
+}
+
+func (sc *shardedClock) NewFuncTicker(next func(prev time.Time) time.Duration, opts ...TickerOption) *Ticker {
+	return sc.pick().NewFuncTicker(next, opts...)
+}
+
+func (sc *shardedClock) TickFunc(d time.Duration, f func(t time.Time), opts ...TickerOption) *Ticker {
+	return sc.pick().TickFunc(d, f, opts...)
+}
+
+// Pending returns the number of timers armed in all shards.
+func (sc *shardedClock) Pending() int {
+	n := 0
+	for _, shard := range sc.shards {
+		n += shard.Pending()
+	}
+	return n
+}
+
+// PopExpired pops the expired timers of each shard in turn and sorts them by deadline.  With a
+// positive max, the timers returned are not necessarily the earliest expired ones of all shards.
+func (sc *shardedClock) PopExpired(now time.Time, max int) []*Timer {
+	var expired []*Timer
+	for _, shard := range sc.shards {
+		n := 0
+		if max > 0 {
+			if n = max - len(expired); n == 0 {
+				break
+			}
+		}
+		expired = append(expired, shard.PopExpired(now, n)...)
+	}
+	sort.SliceStable(expired, func(i, j int) bool { return expired[i].when.Before(expired[j].when) })
+	return expired
+}
+
+// MemStats returns the sums of the statistics of all shards.
+func (sc *shardedClock) MemStats() MemStats {
+	var s MemStats
+	for _, shard := range sc.shards {
+		ss := shard.MemStats()
+		s.Timers += ss.Timers
+		s.Tickers += ss.Tickers
+		s.HeapCap += ss.HeapCap
+		s.ChannelBytes += ss.ChannelBytes
+		s.Bytes += ss.Bytes
+	}
+	return s
+}
+
+// Stats returns the sums of the counts of all shards, and the unions of their samples.
+func (sc *shardedClock) Stats() Stats {
+	var s Stats
+	for _, shard := range sc.shards {
+		ss := shard.Stats()
+		s.Created += ss.Created
+		s.Reset += ss.Reset
+		s.Stopped += ss.Stopped
+		s.Fired += ss.Fired
+		s.CallbackPanics += ss.CallbackPanics
+		s.PeakPending += ss.PeakPending
+		s.Wakeups += ss.Wakeups
+		s.RoutineFired += ss.RoutineFired
+		s.InFlight += ss.InFlight
+		s.Requested = mergeSamples(s.Requested, ss.Requested)
+		s.Lifetimes = mergeSamples(s.Lifetimes, ss.Lifetimes)
+	}
+	return s
+}
+
+// StartRunner restarts the timer routines of every shard.
+func (sc *shardedClock) StartRunner() error {
+	var err error
+	for _, shard := range sc.shards {
+		if serr := shard.StartRunner(); err == nil {
+			err = serr
+		}
+	}
+	return err
+}
+
+// StopRunner stops the timer routines of every shard.
+func (sc *shardedClock) StopRunner(ctx context.Context) error {
+	var err error
+	for _, shard := range sc.shards {
+		if serr := shard.StopRunner(ctx); err == nil {
+			err = serr
+		}
+	}
+	return err
+}
+
+// Health combines the health of the shards: the routine is reported running if any shard's is,
+// stopped if every shard's is, and the lag is the largest of the shards'.
+func (sc *shardedClock) Health() RunnerHealth {
+	h := RunnerHealth{Stopped: true}
+	for _, shard := range sc.shards {
+		sh := shard.Health()
+		h.Running = h.Running || sh.Running
+		h.Stopped = h.Stopped && sh.Stopped
+		h.Pending += sh.Pending
+		h.Lag = max(h.Lag, sh.Lag)
+		h.Stalled = max(h.Stalled, sh.Stalled)
+	}
+	return h
+}
+
+func (sc *shardedClock) Close() error {
+	return sc.Shutdown(context.Background())
+}
+
+// Shutdown shuts down every shard.  It returns [ErrClosed] if the clock was already closed.
+func (sc *shardedClock) Shutdown(ctx context.Context) error {
+	var err error
+	for _, shard := range sc.shards {
+		if serr := shard.Shutdown(ctx); err == nil {
+			err = serr
+		}
+	}
+	return err
+}
@@ -0,0 +1,81 @@
+package kairos
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestShardedClock(t *testing.T) {
+	c := NewClock(WithShards(4))
+	sc, ok := c.(*shardedClock)
+	if !ok || len(sc.shards) != 4 {
+		t.Fatalf("got %T, want a clock with 4 shards", c)
+	}
+	// Goroutines reset their own timers concurrently; every timer must fire.
+	const n = 16
+	var wg sync.WaitGroup
+	shards := make(map[*clock]bool)
+	for i := 0; i < n; i++ {
+		timer := c.NewTimer(time.Hour)
+		shards[timer.clk] = true
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				timer.Reset(time.Hour)
+			}
+			timer.Reset(time.Millisecond)
+			<-timer.C
+		}()
+	}
+	wg.Wait()
+	if len(shards) != 4 {
+		t.Errorf("timers were assigned to %d shards, want 4", len(shards))
+	}
+	for i := 0; i < 6; i++ {
+		c.NewTimer(time.Hour)
+	}
+	if got := diagnostics(c).Pending(); got != 6 {
+		t.Errorf("got %d pending timers, want 6", got)
+	}
+	if err := c.Close(); err != nil {
+		t.Errorf("Close: got error %v", err)
+	}
+	if got := diagnostics(c).Pending(); got != 0 {
+		t.Errorf("got %d pending timers after Close, want 0", got)
+	}
+	if err := c.Close(); err != ErrClosed {
+		t.Errorf("second Close: got %v, want %v", err, ErrClosed)
+	}
+}
+
+func TestShardsIgnored(t *testing.T) {
+	for _, opts := range [][]ClockOption{
+		{WithShards(4), WithRuntimeTimers()},
+		{WithShards(4), WithSleeper(newRealSleeper())},
+	} {
+		c := NewClock(opts...)
+		if _, ok := c.(*clock); !ok {
+			t.Errorf("got %T, want an unsharded clock", c)
+		}
+		c.Close()
+	}
+}
+
+func BenchmarkTimerResetParallel(b *testing.B) {
+	for _, shards := range []int{1, 0} {
+		b.Run(fmt.Sprintf("shards %d", shards), func(b *testing.B) {
+			c := NewClock(WithShards(shards))
+			defer c.Close()
+			b.RunParallel(func(pb *testing.PB) {
+				timer := c.NewTimer(time.Hour)
+				for pb.Next() {
+					timer.Reset(time.Hour)
+				}
+				timer.Stop()
+			})
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package kairos
+
+import (
+	"time"
+)
+
+// A SimClock is a [FakeClock] for discrete-event simulation: instead of advancing by arbitrary
+// durations, its time jumps from one timer deadline to the next, so days of simulated lease
+// renewals and timeouts run in milliseconds.  A SimClock always uses deterministic dispatch (see
+// [WithDeterministicDispatch]), so simulations whose logic runs in AfterFunc callbacks are fully
+// reproducible.
+type SimClock struct {
+	*FakeClock
+	settle time.Duration
+}
+
+// WithSettle makes each step of a [SimClock] wait until there has been no timer activity for the
+// real duration d before returning, so that goroutines woken by channel timers get a chance to arm
+// their next timers.  It is not needed if the simulation only uses AfterFunc callbacks.  This
+// option only affects clocks created by [NewSimClock].
+func WithSettle(d time.Duration) ClockOption {
+	return func(cfg *clockConfig) { cfg.settle = d }
+}
+
+// NewSimClock returns a [SimClock] whose current time is start.
+func NewSimClock(start time.Time, opts ...ClockOption) *SimClock {
+	opts = append(opts[:len(opts):len(opts)], WithDeterministicDispatch())
+	return &SimClock{FakeClock: NewFakeClock(start, opts...), settle: newClockConfig(opts).settle}
+}
+
+// Step jumps to the earliest pending deadline and fires every timer due at that time.  It returns
+// false, without changing the time, if no timer is pending.
+func (s *SimClock) Step() bool {
+	s.mutex.Lock()
+	t := s.timers.Peek()
+	if t == nil {
+		s.mutex.Unlock()
+		return false
+	}
+	s.advanceLocked(t.when)
+	s.mutex.Unlock()
+	s.waitSettled()
+	return true
+}
+
+// Run steps until no timer is pending, that is, until the simulation is quiescent, and returns the
+// number of steps taken.  Run does not return if timers keep re-arming themselves forever; use
+// [SimClock.RunUntil] to bound such simulations.
+func (s *SimClock) Run() int {
+	n := 0
+	for s.Step() {
+		n++
+	}
+	return n
+}
+
+// RunUntil steps through every deadline up to and including end, then sets the time to end (if it
+// is later than the current time), and returns the number of steps taken.
+func (s *SimClock) RunUntil(end time.Time) int {
+	n := 0
+	for {
+		s.mutex.Lock()
+		t := s.timers.Peek()
+		if t == nil || t.when.After(end) {
+			if end.After(s.current) {
+				s.current = end
+			}
+			s.mutex.Unlock()
+			return n
+		}
+		s.advanceLocked(t.when)
+		s.mutex.Unlock()
+		s.waitSettled()
+		n++
+	}
+}
+
+// waitSettled waits until there has been no timer activity for the settle duration.
+func (s *SimClock) waitSettled() {
+	if s.settle <= 0 {
+		return
+	}
+	for {
+		s.mutex.Lock()
+		activity := s.activity
+		s.mutex.Unlock()
+		time.Sleep(s.settle)
+		s.mutex.Lock()
+		quiet := s.activity == activity
+		s.mutex.Unlock()
+		if quiet {
+			return
+		}
+	}
+}
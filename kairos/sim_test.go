@@ -0,0 +1,74 @@
+package kairos
+
+import (
+	"testing"
+	"time"
+)
+
+// lease simulates a lease that is renewed every period until it has been held for total.
+type lease struct {
+	c        Clock
+	period   time.Duration
+	until    time.Time
+	renewals int
+}
+
+func (l *lease) renew() {
+	l.renewals++
+	if l.c.Now().Before(l.until) {
+		l.c.AfterFunc(l.period, l.renew)
+	}
+}
+
+func TestSimClock(t *testing.T) {
+	sc := NewSimClock(fakeStart)
+	const days = 30
+	l := &lease{c: sc, period: 10 * time.Second, until: fakeStart.Add(days * 24 * time.Hour)}
+	sc.AfterFunc(l.period, l.renew)
+	timeout := sc.NewTimer(24 * time.Hour)
+
+	if got, want := sc.RunUntil(fakeStart.Add(time.Minute)), 6; got != want {
+		t.Errorf("RunUntil: got %d steps, want %d", got, want)
+	}
+	if got, want := sc.Now(), fakeStart.Add(time.Minute); !got.Equal(want) {
+		t.Errorf("after RunUntil: got Now() %v, want %v", got, want)
+	}
+	steps := sc.Run()
+	if want := days * 24 * 360; l.renewals != want {
+		t.Errorf("got %d renewals, want %d", l.renewals, want)
+	}
+	if got, want := steps+6, l.renewals; got != want {
+		t.Errorf("got %d total steps, want %d", got, want)
+	}
+	if got, want := sc.Now(), l.until; !got.Equal(want) {
+		t.Errorf("after Run: got Now() %v, want %v", got, want)
+	}
+	if _, ok := recv(timeout.C); !ok {
+		t.Errorf("timeout timer did not fire")
+	}
+	if sc.Step() {
+		t.Errorf("Step returned true with no pending timers")
+	}
+}
+
+func TestSimClockSettle(t *testing.T) {
+	sc := NewSimClock(fakeStart, WithSettle(time.Millisecond))
+	done := make(chan int)
+	go func() {
+		n := 0
+		for timer := sc.NewTimer(time.Hour); n < 48; n++ {
+			<-timer.C
+			timer.Reset(time.Hour)
+		}
+		done <- n
+	}()
+	sc.BlockUntil(1)
+	for i := 0; i < 48; i++ {
+		if !sc.Step() {
+			t.Fatalf("step %d: no pending timer", i)
+		}
+	}
+	if got := <-done; got != 48 {
+		t.Errorf("got %d wakeups, want 48", got)
+	}
+}
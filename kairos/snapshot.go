@@ -0,0 +1,80 @@
+package kairos
+
+import (
+	"time"
+)
+
+// A Snapshot is the state of a [FakeClock] at some instant: its time and its armed timers.
+type Snapshot struct {
+	clock  *FakeClock
+	now    time.Time
+	timers []snapshotTimer
+}
+
+type snapshotTimer struct {
+	t      *Timer
+	when   time.Time
+	seq    uint64
+	period time.Duration
+	anchor time.Time // Ticker schedule, if t backs a Ticker.
+	n      int64
+	tick   int64 // Sequence number of the next tick.
+}
+
+// Time returns the clock's time when the snapshot was taken.
+func (s *Snapshot) Time() time.Time { return s.now }
+
+// Snapshot captures the clock's time and the set of armed timers, with their deadlines, so that
+// [FakeClock.Restore] can return to this state later.  Table-driven tests can prepare a common
+// timing state once and restore it at the start of each case.
+func (fc *FakeClock) Snapshot() *Snapshot {
+	fc.mutex.Lock()
+	defer fc.mutex.Unlock()
+	s := &Snapshot{clock: fc, now: fc.current, timers: make([]snapshotTimer, 0, fc.timers.Len())}
+	for _, t := range fc.timers.AppendTo(nil) {
+		st := snapshotTimer{t: t, when: t.when, seq: t.seq, period: t.period}
+		if t.tk != nil {
+			st.anchor, st.n, st.tick = t.tk.anchor, t.tk.n, t.tk.tickSeq
+		}
+		s.timers = append(s.timers, st)
+	}
+	return s
+}
+
+// Restore returns the clock to the state captured by s: the clock's time is set back (or forward)
+// to the snapshot's time without firing anything, every timer that was armed in the snapshot is
+// re-armed with its original deadline, and every other timer is stopped.  The channels of the
+// re-armed timers are emptied and their undelivered ticks discarded, but values waiting in the
+// channels of other timers and the side effects of callbacks that ran since the snapshot are left
+// alone.  Like [Timer.Stop], stopping a timer lets a goroutine blocked by [BlockAtLimit] arm its
+// own.  A closed clock keeps its timers stopped.  Restore panics if s was taken from another clock.
+func (fc *FakeClock) Restore(s *Snapshot) {
+	if s.clock != fc {
+		panic("kairos: FakeClock.Restore called with a snapshot of another clock")
+	}
+	fc.mutex.Lock()
+	for t := fc.timers.Peek(); t != nil; t = fc.timers.Peek() {
+		fc.timers.Remove(t)
+		fc.disarmedLocked()
+	}
+	for _, st := range s.timers {
+		if fc.closed {
+			break
+		}
+		if st.t.tk != nil {
+			st.t.tk.discardLocked()
+			st.t.tk.anchor, st.t.tk.n, st.t.tk.tickSeq = st.anchor, st.n, st.tick
+			st.t.tk.paused = false
+			st.t.tk.drainLocked()
+		}
+		select {
+		case <-st.t.C:
+		default:
+		}
+		st.t.when, st.t.seq, st.t.period = st.when, st.seq, st.period
+		fc.timers.Insert(st.t)
+	}
+	fc.current = s.now
+	fc.mutex.Unlock()
+	fc.onArmed()
+}
@@ -0,0 +1,82 @@
+package kairos
+
+import (
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestFakeClockSnapshot(t *testing.T) {
+	fc := NewFakeClock(fakeStart)
+	lease := fc.NewTimer(10 * time.Second)
+	renew := fc.NewTicker(3 * time.Second)
+	fc.Advance(4 * time.Second)
+	<-renew.C
+	snap := fc.Snapshot()
+	if got, want := snap.Time(), fakeStart.Add(4*time.Second); !got.Equal(want) {
+		t.Errorf("got snapshot time %v, want %v", got, want)
+	}
+
+	for _, tc := range []struct {
+		desc    string
+		prepare func()
+		advance time.Duration
+		expired bool
+		ticked  bool
+	}{
+		{desc: "untouched", advance: 2 * time.Second, ticked: true},
+		{desc: "lease expires", advance: 6 * time.Second, expired: true, ticked: true},
+		{desc: "lease stopped", prepare: func() { lease.Stop() }, advance: time.Minute, ticked: true},
+		{desc: "renewal stopped", prepare: func() { renew.Stop() }, advance: time.Minute, expired: true},
+		{desc: "before next tick", advance: time.Second},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			fc.Restore(snap)
+			if got, want := fc.Now(), snap.Time(); !got.Equal(want) {
+				t.Errorf("after Restore: got Now() %v, want %v", got, want)
+			}
+			if got, want := fc.Pending(), 2; got != want {
+				t.Errorf("after Restore: got Pending() %d, want %d", got, want)
+			}
+			if tc.prepare != nil {
+				tc.prepare()
+			}
+			fc.Advance(tc.advance)
+			if got, ok := recv(lease.C); ok != tc.expired {
+				t.Errorf("lease expired = %v, want %v", ok, tc.expired)
+			} else if ok && !got.Equal(fakeStart.Add(10*time.Second)) {
+				t.Errorf("lease: got %v, want %v", got, fakeStart.Add(10*time.Second))
+			}
+			if _, ok := recv(renew.C); ok != tc.ticked {
+				t.Errorf("renewal ticked = %v, want %v", ok, tc.ticked)
+			}
+		})
+	}
+}
+
+func TestFakeClockRestoreWakesBlockedTimer(t *testing.T) {
+	fc := NewFakeClock(fakeStart, WithTimerLimit(1, BlockAtLimit))
+	snap := fc.Snapshot()
+	fc.NewTimer(time.Hour)
+	started := make(chan *Timer)
+	go func() { started <- fc.NewTimer(time.Hour) }()
+	// Wait until NewTimer is blocked at the limit.
+	for {
+		fc.mutex.Lock()
+		n := fc.limit.waiters
+		fc.mutex.Unlock()
+		if n > 0 {
+			break
+		}
+		runtime.Gosched()
+	}
+	fc.Restore(snap)
+	select {
+	case b := <-started:
+		if !b.Stop() {
+			t.Errorf("timer started after Restore: Stop: was active is false")
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("NewTimer still blocked after Restore stopped the armed timer")
+	}
+}
@@ -0,0 +1,100 @@
+package kairos
+
+import (
+	"sort"
+	"time"
+)
+
+// WithStaleTimers makes the clock watch for forgotten timers: timers that stay armed for threshold
+// or longer without firing, being reset, or being stopped, such as the timeouts of requests that
+// never complete, which accumulate until they exhaust memory.  The clock calls report once for
+// each such timer and arming, with the description of the timer, which includes its creation and
+// reset sites with [WithCreationSites], and how long it has been armed.  A ticker that keeps
+// ticking is never stale.
+//
+// The clock looks for stale timers every quarter of threshold, from a goroutine that runs until the
+// clock is shut down, or, for a [FakeClock], whenever its time moves forward.  report runs in its
+// own goroutine, called for the stale timers found by a scan in deadline order; [Clock.Shutdown]
+// waits for it.
+func WithStaleTimers(threshold time.Duration,
+	report func(info TimerInfo, armedFor time.Duration)) ClockOption {
+	if threshold <= 0 {
+		panic("kairos: non-positive threshold for WithStaleTimers")
+	}
+	return func(cfg *clockConfig) {
+		cfg.stale = &staleTimers{threshold: threshold, report: report}
+	}
+}
+
+// A staleTimers is the state of the detection of stale timers of a clock.
+type staleTimers struct {
+	threshold time.Duration
+	report    func(info TimerInfo, armedFor time.Duration)
+	quitC     chan struct{} // Closed to stop the scans, if the clock scans from a goroutine.
+}
+
+// setStale applies the detection of stale timers of cfg to clk.  If scan is true, the clock scans
+// its timers from a goroutine; otherwise, as for a FakeClock, it must call checkStaleLocked itself.
+func (clk *clock) setStale(cfg clockConfig, scan bool) {
+	if cfg.stale == nil {
+		return
+	}
+	clk.stale = &staleTimers{threshold: cfg.stale.threshold, report: cfg.stale.report}
+	if scan {
+		clk.stale.quitC = make(chan struct{})
+		go clk.scanStale()
+	}
+}
+
+// scanStale looks for stale timers until the clock is shut down.
+func (clk *clock) scanStale() {
+	ticker := time.NewTicker(max(clk.stale.threshold/4, time.Millisecond))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-clk.stale.quitC:
+			return
+		case <-ticker.C:
+		}
+		now := clk.now()
+		clk.mutex.Lock()
+		clk.checkStaleLocked(now)
+		clk.mutex.Unlock()
+	}
+}
+
+// checkStaleLocked reports the armed timers that have become stale by time now.  The mutex must be
+// held.
+func (clk *clock) checkStaleLocked(now time.Time) {
+	s := clk.stale
+	type staleTimer struct {
+		desc     timerDesc
+		armedFor time.Duration
+	}
+	var stale []staleTimer
+	check := func(t *Timer) {
+		if d := now.Sub(t.armedAt); !t.staleReported && d >= s.threshold {
+			t.staleReported = true
+			stale = append(stale, staleTimer{describeLocked(t, now), d})
+		}
+	}
+	for _, t := range clk.timers.AppendTo(nil) {
+		check(t)
+	}
+	for t := range clk.rtimers {
+		check(t)
+	}
+	if len(stale) == 0 {
+		return
+	}
+	clk.funcs.Add(1)
+	go func() {
+		defer clk.funcs.Done()
+		sort.SliceStable(stale, func(i, j int) bool {
+			return stale[i].desc.info.When.Before(stale[j].desc.info.When)
+		})
+		for _, st := range stale {
+			s.report(st.desc.resolve(), st.armedFor)
+		}
+	}()
+}
@@ -0,0 +1,66 @@
+package kairos
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStaleTimers(t *testing.T) {
+	type report struct {
+		label    string
+		armedFor time.Duration
+	}
+	reports := make(chan report, 10)
+	fc := NewFakeClock(fakeStart,
+		WithStaleTimers(time.Minute, func(info TimerInfo, armedFor time.Duration) {
+			reports <- report{info.Label, armedFor}
+		}))
+	newTimer := func(d time.Duration, label string) *Timer {
+		timer := fc.NewTimer(d)
+		timer.SetLabel(label)
+		return timer
+	}
+	newTimer(time.Hour, "forgotten")
+	newTimer(2*time.Hour, "forgotten later")
+	newTimer(30*time.Second, "fired")
+	reset := newTimer(time.Hour, "reset")
+	stopped := newTimer(time.Hour, "stopped")
+	tk := fc.NewTicker(10 * time.Second)
+	tk.SetLabel("ticker")
+
+	fc.Advance(40 * time.Second)
+	reset.Reset(time.Hour)
+	stopped.Stop()
+	fc.Advance(30 * time.Second)
+	fc.Advance(20 * time.Second) // Reports nothing new.
+	tk.Stop()
+	if err := fc.Close(); err != nil {
+		t.Fatal(err)
+	}
+	close(reports)
+
+	var got []report
+	for r := range reports {
+		got = append(got, r)
+	}
+	want := []report{{"forgotten", 70 * time.Second}, {"forgotten later", 70 * time.Second}}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("got reports %v, want %v", got, want)
+	}
+}
+
+func TestStaleTimersReal(t *testing.T) {
+	reported := make(chan TimerInfo, 1)
+	c := NewClock(WithStaleTimers(10*time.Millisecond, func(info TimerInfo, armedFor time.Duration) {
+		if armedFor < 10*time.Millisecond {
+			t.Errorf("got a timer armed for %v reported, want at least 10ms", armedFor)
+		}
+		reported <- info
+	}))
+	defer c.Close()
+	timer := c.NewTimer(time.Hour)
+	timer.SetLabel("forgotten")
+	if got := (<-reported).Label; got != "forgotten" {
+		t.Errorf("got timer %q reported, want %q", got, "forgotten")
+	}
+}
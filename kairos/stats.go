@@ -0,0 +1,139 @@
+package kairos
+
+import (
+	"sync/atomic"
+	"time"
+	"unsafe"
+)
+
+// MemStats reports the resources held by the timers armed on a clock, for capacity planning.  The
+// byte counts are estimates computed from the sizes of the data structures involved.  They do not
+// include the functions of AfterFunc timers and [TickFunc] tickers or what those reference, nor the
+// timers that are stopped or have fired, which are held only by the code that created them.
+type MemStats struct {
+	Timers  int // Number of armed timers, including those backing tickers.
+	Tickers int // Number of armed timers backing tickers.
+	// HeapCap is the number of timers the clock's timer heap, or timing wheel with [WheelBackend],
+	// can hold before it must grow.  It is zero for clocks using [WithRuntimeTimers].
+	HeapCap int
+	// ChannelBytes is the size of the channels of the armed timers and tickers, including their
+	// buffers.
+	ChannelBytes int64
+	// Bytes is the estimated memory held by the armed timers, their channels and tickers, and the
+	// timer heap or runtime timers.
+	Bytes int64
+}
+
+// Estimated sizes of the data structures counted by MemStats.
+const (
+	// chanBytes is the size of a timer channel: the runtime's channel header on 64-bit platforms,
+	// and a buffer of one time.Time.
+	chanBytes = 96 + int64(unsafe.Sizeof(time.Time{}))
+	// runtimeTimerBytes is the approximate size of a runtime timer, its callback closure, and its
+	// entry in the map of armed timers.
+	runtimeTimerBytes = 160
+)
+
+// MemStats returns statistics about the timers armed on the clock.  It takes constant time.
+func (clk *clock) MemStats() MemStats {
+	clk.mutex.Lock()
+	defer clk.mutex.Unlock()
+	counts := clk.timers.Counts()
+	capacity, queueBytes := clk.timers.Size()
+	s := MemStats{
+		Timers:  clk.armedLocked(),
+		HeapCap: capacity,
+	}
+	if clk.rtimers != nil {
+		counts = clk.rcounts
+		s.Bytes = int64(s.Timers) * runtimeTimerBytes
+	}
+	s.Tickers = counts.tickers
+	s.ChannelBytes = int64(counts.chans) * chanBytes
+	s.Bytes += queueBytes +
+		int64(s.Timers)*int64(unsafe.Sizeof(Timer{})) +
+		int64(s.Tickers)*int64(unsafe.Sizeof(Ticker{})) +
+		s.ChannelBytes
+	return s
+}
+
+// Stats counts the operations on the timers of a clock since its creation, for health endpoints
+// and dashboards without a metrics library.  The counts only increase, except InFlight.  With
+// [WithDurationSampling], it also samples the durations of the timers.
+type Stats struct {
+	Created uint64 // Timers and tickers created, including the timers behind After and Sleep.
+	Reset   uint64 // Starts and restarts of timers, including by NewTimer and AfterFunc.
+	Stopped uint64 // Timers stopped while armed.
+	Fired   uint64 // Timer expirations and ticker ticks.
+	// CallbackPanics is the number of panics of [TickFunc] functions, which the tickers recover
+	// from.  The panic of an AfterFunc callback is not recovered from and crashes the program.
+	CallbackPanics uint64
+	// PeakPending is the largest number of timers armed at once, including those backing tickers.
+	// For a clock with [WithShards], it is the sum of the peaks of the shards.
+	PeakPending uint64
+	// Wakeups is the number of times the timer routine woke up to fire the expired timers, and
+	// RoutineFired the number of timers it fired; see [Stats.FiresPerWakeup].  Clocks without a
+	// timer routine report neither; see [RunnerHealth].
+	Wakeups      uint64
+	RoutineFired uint64
+	// InFlight is the number of AfterFunc and TickFunc callbacks started or queued that have not
+	// returned yet, with the goroutines that deliver the clock's reports and the missed ticks of
+	// its tickers: those that [Clock.Shutdown] waits for.  Unlike the other fields, it is a
+	// current value, not a count.
+	InFlight uint64
+	// Requested is a sample of the durations requested of the timers when they were started or
+	// reset, or nil without [WithDurationSampling].
+	Requested *DurationSample
+	// Lifetimes is a sample of the times for which the timers stayed armed before they fired,
+	// were stopped, or were reset, or nil without [WithDurationSampling].  For a ticker, each
+	// interval between ticks is a lifetime.
+	Lifetimes *DurationSample
+}
+
+// opCounters holds the counts of a clock's timer operations reported by Stats.
+type opCounters struct {
+	created, reset, stopped, fired, panics atomic.Uint64
+	wakeups, routineFired                  atomic.Uint64
+
+	peak atomic.Uint64 // Stored with the clock's mutex held.
+}
+
+// FiresPerWakeup returns the average number of timers fired by the timer routine per wakeup, or 0
+// if it never woke up.  A wakeup that fires no timer, because the earliest timer was stopped or
+// postponed meanwhile, counts too.
+func (s Stats) FiresPerWakeup() float64 {
+	if s.Wakeups == 0 {
+		return 0
+	}
+	return float64(s.RoutineFired) / float64(s.Wakeups)
+}
+
+// armedLocked updates the peak number of armed timers after one was armed.  The mutex must be
+// held.
+func (o *opCounters) armedLocked(n int) {
+	if uint64(n) > o.peak.Load() {
+		o.peak.Store(uint64(n))
+	}
+}
+
+// Stats returns the numbers of operations on the clock's timers.  The counts are read separately,
+// so they may not be consistent with each other while timers are in use.
+func (clk *clock) Stats() Stats {
+	s := Stats{
+		Created:        clk.ops.created.Load(),
+		Reset:          clk.ops.reset.Load(),
+		Stopped:        clk.ops.stopped.Load(),
+		Fired:          clk.ops.fired.Load(),
+		CallbackPanics: clk.ops.panics.Load(),
+		PeakPending:    clk.ops.peak.Load(),
+		Wakeups:        clk.ops.wakeups.Load(),
+		RoutineFired:   clk.ops.routineFired.Load(),
+		InFlight:       uint64(max(clk.funcs.n.Load(), 0)),
+	}
+	if clk.samples != nil {
+		clk.mutex.Lock()
+		s.Requested, s.Lifetimes = clk.samples.requested.sample(), clk.samples.lifetimes.sample()
+		clk.mutex.Unlock()
+	}
+	return s
+}
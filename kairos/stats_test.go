@@ -0,0 +1,158 @@
+package kairos
+
+import (
+	"slices"
+	"testing"
+	"time"
+	"unsafe"
+)
+
+func TestMemStats(t *testing.T) {
+	for _, tc := range []struct {
+		desc string
+		c    Clock
+	}{
+		{"fake", NewFakeClock(fakeStart)},
+		{"sharded", NewClock(WithShards(3))},
+		{"runtime timers", NewClock(WithRuntimeTimers())},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			defer tc.c.Close()
+			timers := []*Timer{
+				tc.c.NewTimer(time.Hour),
+				tc.c.NewTimer(time.Hour),
+				tc.c.NewTimer(time.Hour),
+				tc.c.AfterFunc(time.Hour, func() {}),
+			}
+			tickers := []*Ticker{
+				tc.c.NewTicker(time.Hour),
+				tc.c.TickFunc(time.Hour, func(time.Time) {}),
+			}
+			s := diagnostics(tc.c).MemStats()
+			if s.Timers != 6 || s.Tickers != 2 || s.ChannelBytes != 4*chanBytes {
+				t.Errorf("got %d timers, %d tickers, %d channel bytes, want 6, 2, %d",
+					s.Timers, s.Tickers, s.ChannelBytes, 4*chanBytes)
+			}
+			if s.Bytes <= s.ChannelBytes {
+				t.Errorf("got %d bytes, want more than the %d channel bytes", s.Bytes, s.ChannelBytes)
+			}
+			for _, timer := range timers {
+				timer.Stop()
+			}
+			for _, tk := range tickers {
+				tk.Stop()
+			}
+			s = diagnostics(tc.c).MemStats()
+			if s.Timers != 0 || s.Tickers != 0 || s.ChannelBytes != 0 {
+				t.Errorf("after Stop: got %d timers, %d tickers, %d channel bytes, want none",
+					s.Timers, s.Tickers, s.ChannelBytes)
+			}
+			// Only the empty slots of the heap are left.
+			if want := int64(s.HeapCap) * int64(unsafe.Sizeof(&Timer{})); s.Bytes != want {
+				t.Errorf("after Stop: got %d bytes, want %d for a heap capacity of %d", s.Bytes, want, s.HeapCap)
+			}
+		})
+	}
+}
+
+func TestStats(t *testing.T) {
+	fc := NewFakeClock(fakeStart, WithDeterministicDispatch())
+	defer fc.Close()
+	timer := fc.NewTimer(time.Second)
+	timer.Reset(2 * time.Second)
+	timer.Stop()
+	timer.Stop() // Not counted: the timer is not armed.
+	fc.AfterFunc(time.Second, func() {})
+	tk := fc.TickFunc(time.Second, func(time.Time) { panic("boom") })
+	fc.Advance(2 * time.Second)
+	tk.Stop()
+	want := Stats{Created: 3, Reset: 4, Stopped: 2, Fired: 3, CallbackPanics: 2, PeakPending: 2}
+	if got := fc.Stats(); got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	c := NewClock(WithShards(2))
+	defer c.Close()
+	for i := 0; i < 4; i++ {
+		c.NewTimer(time.Hour).Stop()
+	}
+	// The shards take turns, so each had one timer armed at most.
+	want = Stats{Created: 4, Reset: 4, Stopped: 4, PeakPending: 2}
+	if got := diagnostics(c).Stats(); got != want {
+		t.Errorf("sharded clock: got %+v, want %+v", got, want)
+	}
+}
+
+func TestDurationSampling(t *testing.T) {
+	fc := NewFakeClock(fakeStart, WithDurationSampling(3))
+	defer fc.Close()
+	timer := fc.NewTimer(time.Second)
+	fc.Advance(time.Second) // Fires: lifetime 1s.
+	timer.Reset(time.Hour)  // Not armed: no lifetime.
+	stopped := fc.NewTimer(time.Minute)
+	fc.Advance(2 * time.Second)
+	stopped.Stop()         // Lifetime 2s.
+	stopped.Stop()         // Not armed: no lifetime.
+	timer.Reset(time.Hour) // Lifetime 2s.
+
+	s := fc.Stats()
+	if s.Requested == nil || s.Lifetimes == nil {
+		t.Fatalf("got samples %v, %v, want both", s.Requested, s.Lifetimes)
+	}
+	// The reservoir of requested durations holds 3 of the 4 durations requested.
+	if s.Requested.Count != 4 || len(s.Requested.Values) != 3 {
+		t.Errorf("got %d requested durations sampled of %d, want 3 of 4", len(s.Requested.Values),
+			s.Requested.Count)
+	}
+	for _, d := range s.Requested.Values {
+		if d != time.Second && d != time.Minute && d != time.Hour {
+			t.Errorf("got requested duration %v, want 1s, 1m, or 1h", d)
+		}
+	}
+	want := DurationSample{Values: []time.Duration{time.Second, 2 * time.Second, 2 * time.Second}, Count: 3}
+	if !slices.Equal(s.Lifetimes.Values, want.Values) || s.Lifetimes.Count != want.Count {
+		t.Errorf("got lifetimes %+v, want %+v", *s.Lifetimes, want)
+	}
+	for _, tc := range []struct {
+		q    float64
+		want time.Duration
+	}{
+		{0, time.Second},
+		{0.5, 2 * time.Second},
+		{1, 2 * time.Second},
+	} {
+		if got := s.Lifetimes.Quantile(tc.q); got != tc.want {
+			t.Errorf("Quantile(%v): got %v, want %v", tc.q, got, tc.want)
+		}
+	}
+	if s := NewFakeClock(fakeStart).Stats(); s.Requested != nil || s.Lifetimes != nil {
+		t.Errorf("without WithDurationSampling: got samples %v, %v, want none", s.Requested, s.Lifetimes)
+	}
+}
+
+func TestWakeupStats(t *testing.T) {
+	fc := NewFakeClock(fakeStart)
+	c := NewClockFromFunc(fc.Now, WithSleeper(NewClockSleeper(fc)))
+	defer c.Close()
+	var timers []*Timer
+	for i := 0; i < 3; i++ {
+		timers = append(timers, c.NewTimer(time.Second))
+	}
+	c.NewTimer(time.Hour).Stop()
+	fc.BlockUntil(1)
+	fc.Advance(time.Second)
+	for _, timer := range timers {
+		waitFired(t, timer)
+	}
+	s := diagnostics(c).Stats()
+	if s.PeakPending != 4 || s.RoutineFired != 3 || s.Wakeups == 0 {
+		t.Errorf("got peak %d, %d fired in %d wakeups, want peak 4, 3 fired in some", s.PeakPending,
+			s.RoutineFired, s.Wakeups)
+	}
+	if got, want := s.FiresPerWakeup(), 3/float64(s.Wakeups); got != want {
+		t.Errorf("got %v fires per wakeup, want %v", got, want)
+	}
+	if got := (Stats{}).FiresPerWakeup(); got != 0 {
+		t.Errorf("got %v fires per wakeup without wakeups, want 0", got)
+	}
+}
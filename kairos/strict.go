@@ -0,0 +1,80 @@
+package kairos
+
+import (
+	"bytes"
+	"fmt"
+	"runtime"
+	"time"
+)
+
+// WithStrict makes a [FakeClock] watch for code that waits on real time instead of the fake
+// clock.  If timers are pending or goroutines are blocked in [FakeClock.BlockUntil], but nothing
+// has used the clock (read the time, armed or fired a timer) for the real duration threshold, the
+// test is probably stuck in [time.Sleep] or on a channel from [time.After].  The clock then calls
+// report with a message listing the goroutines blocked in time.Sleep and the stacks of all
+// goroutines.  If report is nil, the clock panics with the message instead.  Pass t.Error as
+// report to fail a test.  The watchdog goroutine exits when the clock is closed.  This option only
+// affects clocks created by [NewFakeClock].
+func WithStrict(threshold time.Duration, report func(msg string)) ClockOption {
+	return func(cfg *clockConfig) {
+		cfg.strict = threshold
+		cfg.strictReport = report
+	}
+}
+
+// watchdog reports stalls as described for WithStrict.
+func (fc *FakeClock) watchdog(threshold time.Duration, report func(msg string)) {
+	defer close(fc.doneC)
+	ticker := time.NewTicker(threshold / 4)
+	defer ticker.Stop()
+	var last uint64
+	quietSince := time.Now()
+	reported := false
+	for {
+		select {
+		case <-fc.quitC:
+			return
+		case <-ticker.C:
+		}
+		fc.mutex.Lock()
+		uses := fc.activity + fc.reads
+		waiting := fc.timers.Len() > 0 || len(fc.waiters) > 0
+		fc.mutex.Unlock()
+		if uses != last || !waiting {
+			last = uses
+			quietSince = time.Now()
+			reported = false
+			continue
+		}
+		if quiet := time.Since(quietSince); quiet >= threshold && !reported {
+			reported = true
+			msg := stallMessage(quiet)
+			if report == nil {
+				panic(msg)
+			}
+			report(msg)
+		}
+	}
+}
+
+// stallMessage describes a stall of a strict FakeClock.
+func stallMessage(quiet time.Duration) string {
+	buf := make([]byte, 1<<16)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			buf = buf[:n]
+			break
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+	var sleepers []string
+	for _, g := range bytes.Split(buf, []byte("\n\n")) {
+		if bytes.Contains(g, []byte("\ntime.Sleep(")) {
+			sleepers = append(sleepers, string(bytes.SplitN(g, []byte("\n"), 2)[0]))
+		}
+	}
+	return fmt.Sprintf("kairos: fake clock unused for %v of real time while timers are pending; "+
+		"is the code under test waiting on real time?\ngoroutines in time.Sleep: %q\n\n%s",
+		quiet.Round(time.Millisecond), sleepers, buf)
+}
@@ -0,0 +1,36 @@
+package kairos
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFakeClockStrict(t *testing.T) {
+	reports := make(chan string, 1)
+	fc := NewFakeClock(fakeStart, WithStrict(200*time.Millisecond, func(msg string) { reports <- msg }))
+	t.Cleanup(func() { fc.Close() })
+	fc.NewTimer(time.Second)
+
+	// Code that keeps using the fake clock is fine, even if it takes a while.
+	for i := 0; i < 10; i++ {
+		time.Sleep(10 * time.Millisecond)
+		fc.Advance(time.Millisecond)
+	}
+	select {
+	case msg := <-reports:
+		t.Fatalf("unexpected report: %s", msg)
+	default:
+	}
+
+	// Code that sleeps in real time is reported.
+	time.Sleep(500 * time.Millisecond)
+	select {
+	case msg := <-reports:
+		if !strings.Contains(msg, "goroutines in time.Sleep: [\"goroutine ") || !strings.Contains(msg, "TestFakeClockStrict") {
+			t.Errorf("report does not identify the sleeping goroutine:\n%s", msg)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("stall was not reported")
+	}
+}
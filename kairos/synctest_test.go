@@ -0,0 +1,30 @@
+//go:build go1.25
+
+package kairos
+
+import (
+	"testing"
+	"testing/synctest"
+	"time"
+)
+
+func TestRuntimeTimersSynctest(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		c := NewClock(WithRuntimeTimers())
+		restore := SetClock(c)
+		defer restore()
+		start := time.Now()
+		timer := NewTimer(time.Hour)
+		called := false
+		AfterFunc(2*time.Hour, func() { called = true })
+		got := <-timer.C
+		if want := start.Add(time.Hour); !got.Equal(want) {
+			t.Errorf("got fire time %v, want %v", got, want)
+		}
+		time.Sleep(time.Hour)
+		synctest.Wait()
+		if !called {
+			t.Errorf("AfterFunc callback did not run")
+		}
+	})
+}
@@ -0,0 +1,820 @@
+package kairos
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// A Ticker holds a channel that delivers ticks of a clock at intervals, or, if created by TickFunc,
+// calls a function on each tick.  A Ticker must be created with NewTicker, NewFuncTicker, or
+// TickFunc.
+type Ticker struct {
+	C <-chan time.Time
+	c chan<- time.Time // Same channel as C.
+
+	t         *Timer // Periodic timer that sends on c.
+	missed    MissedTickPolicy
+	maxLag    int       // Maximum length of the backlog, or 0 if unlimited.
+	immediate bool      // Tick when started, not only after the first period.
+	aligned   bool      // Tick on multiples of the period in wall-clock time.
+	epoch     time.Time // Origin of the boundaries of an aligned ticker, or the zero time.
+	driftFree bool      // Schedule the n-th tick at anchor + n*period.
+	jitter    jitter
+	backoff   backoff
+	realign   bool // Restart the schedule on Resume.
+	maxTicks  int  // Number of ticks after which the ticker stops, or 0 if unlimited.
+
+	// Interval function of a ticker created by NewFuncTicker, or nil.
+	next func(prev time.Time) time.Duration
+	// Function called on each tick by a ticker created by TickFunc, or nil.
+	fn      func(t time.Time)
+	onPanic func(v any) // Called with the value of a panic of fn, or nil.
+
+	// The following fields are protected by the mutex of the ticker's clock.
+	backlog    []Tick        // Ticks waiting to be delivered, for DeliverMissedTicks.
+	forwarding bool          // True while a goroutine delivers the backlog.
+	stopC      chan struct{} // Closed to make the forwarding goroutine give up.
+	anchor     time.Time     // Time the ticker was last started.
+	n          int64         // Number of the next tick since anchor.
+	paused     bool
+	count      int           // Number of ticks since started, if maxTicks > 0.
+	doneC      chan struct{} // Closed after maxTicks ticks.
+	calling    bool          // True while fn is being called, for TickFunc.
+	tc         chan Tick     // Channel returned by Ticks, which replaces c, or nil.
+	tickSeq    int64         // Sequence number of the next tick.
+	sentSeq    int64         // Sequence number of the last tick sent on c.
+	sentSched  time.Time     // Scheduled time of the last tick sent on c.
+	nextGen    uint64        // Incremented to cancel a pending restart; see rearmFuncLocked.
+
+	// Ticker run on behalf of this one, if this one is a handle returned by a clock with
+	// WithLeakDetection.  The handle holds no other state.
+	inner *Ticker
+}
+
+// A Tick is a tick delivered by [Ticker.Ticks].
+type Tick struct {
+	Time time.Time
+	// Scheduled is the time at which the tick was due, before which Time never is.  The difference
+	// is how late the clock delivered the tick.
+	Scheduled time.Time
+	// Seq is the number of the tick since the ticker was started or last reset, starting at 1.  It
+	// also counts the ticks dropped by [DropMissedTicks] or [WithCatchUpLimit], so a gap between
+	// successive values reveals how many ticks were missed, but not the intervals skipped while
+	// the ticker was paused.
+	Seq int64
+}
+
+// A MissedTickPolicy determines what a [Ticker] does with ticks that cannot be delivered because
+// the receiver has not yet taken the previous tick from the channel, or because the clock jumped
+// over several intervals at once, as [FakeClock.Advance] can.
+type MissedTickPolicy int
+
+const (
+	// DropMissedTicks drops ticks for slow receivers, like [time.Ticker].  The receiver sees at
+	// most one tick per receive, however many intervals have passed.
+	DropMissedTicks MissedTickPolicy = iota
+	// DeliverMissedTicks queues the ticks that cannot be delivered right away and delivers them
+	// back to back, in order, each with the time of its own interval, as soon as the receiver is
+	// ready, so that the receiver catches up.  If the clock itself falls behind, for example
+	// because the process was suspended, the ticks of every missed interval are queued.  Use
+	// [WithCatchUpLimit] to bound the queue.
+	DeliverMissedTicks
+)
+
+// A TickerOption configures a [Ticker].
+type TickerOption func(*tickerConfig)
+
+type tickerConfig struct {
+	missed    MissedTickPolicy
+	maxLag    int
+	immediate bool
+	aligned   bool
+	epoch     time.Time
+	driftFree bool
+	jitter    jitter
+	backoff   backoff
+	realign   bool
+	maxTicks  int
+	onPanic   func(v any)
+	prio      Priority
+}
+
+// backoff describes the growth of a backoff ticker's intervals.
+type backoff struct {
+	factor float64       // Growth factor of the intervals, or 0 if they do not grow.
+	max    time.Duration // Maximum interval, or 0 if unlimited.
+}
+
+// grow returns the interval that follows an interval of length d.
+func (b backoff) grow(d time.Duration) time.Duration {
+	d = scaleDuration(d, b.factor)
+	if b.max > 0 && d > b.max {
+		d = b.max
+	}
+	return d
+}
+
+// jitter describes the randomization of a ticker's intervals.
+type jitter struct {
+	max  time.Duration  // Maximum deviation, if frac is 0.
+	frac float64        // Maximum deviation as a fraction of the period.
+	rnd  func() float64 // Source of random numbers in [0, 1), or nil for rand.Float64.
+}
+
+// offset returns a random deviation for an interval of nominal length d.
+func (j jitter) offset(d time.Duration) time.Duration {
+	max := j.max
+	if j.frac > 0 {
+		max = time.Duration(float64(d) * j.frac)
+	}
+	if max <= 0 {
+		return 0
+	}
+	rnd := j.rnd
+	if rnd == nil {
+		rnd = rand.Float64
+	}
+	return time.Duration((2*rnd() - 1) * float64(max))
+}
+
+// interval returns the randomized length of an interval of nominal length d.
+func (j jitter) interval(d time.Duration) time.Duration {
+	d += j.offset(d)
+	if d <= 0 {
+		d = 1
+	}
+	return d
+}
+
+// WithMissedTicks sets what the ticker does with ticks that cannot be delivered right away.  The
+// default is [DropMissedTicks].
+func WithMissedTicks(p MissedTickPolicy) TickerOption {
+	return func(cfg *tickerConfig) { cfg.missed = p }
+}
+
+// WithCatchUpLimit bounds the queue of a ticker with the [DeliverMissedTicks] policy to n ticks
+// (not counting the tick waiting in the channel).  Once the queue is full, further missed ticks are
+// dropped, so a receiver that falls far behind catches up on at most n ticks.  A non-positive n
+// means no limit, which is the default.
+func WithCatchUpLimit(n int) TickerOption {
+	return func(cfg *tickerConfig) { cfg.maxLag = n }
+}
+
+// WithImmediateFirstTick makes the ticker deliver its first tick as soon as it is started, by
+// NewTicker or [Ticker.Reset], and the following ticks every period after that.  This suits
+// polling loops that should run once right away and then periodically.
+func WithImmediateFirstTick() TickerOption {
+	return func(cfg *tickerConfig) { cfg.immediate = true }
+}
+
+// WithWallClockAlignment makes the ticker tick on round wall-clock boundaries: at the instants that
+// are multiples of the period since the zero time (see [time.Time.Truncate]), such as every minute
+// at :00 or every hour on the hour.  Such boundaries are computed in UTC, so they match the round
+// boundaries of local time only for zones whose offset is a multiple of the period.  Unlike other
+// timers, whose deadlines are measured with the monotonic clock, an aligned ticker follows the wall
+// clock: if the system clock steps backward, no tick arrives until the wall clock reaches the next
+// boundary again, and if it steps forward, the ticker realigns onto the boundaries after the step.
+func WithWallClockAlignment() TickerOption {
+	return func(cfg *tickerConfig) { cfg.aligned, cfg.epoch = true, time.Time{} }
+}
+
+// WithAlignmentEpoch is like [WithWallClockAlignment], except that the ticker ticks at the instants
+// that are multiples of the period since epoch instead of the zero time.  For example, a ticker
+// with a period of 15 minutes and an epoch of 00:07 on any day ticks at 00:07, 00:22, 00:37, and
+// so on, and its first tick lands on the first of these boundaries after it is started.  The
+// epoch may be in the future.  WithAlignmentEpoch and WithWallClockAlignment replace the effect of
+// each other.
+func WithAlignmentEpoch(epoch time.Time) TickerOption {
+	return func(cfg *tickerConfig) { cfg.aligned, cfg.epoch = true, epoch }
+}
+
+// WithJitter makes the ticker randomize the length of each interval by up to max in either
+// direction, uniformly, so that processes started together with the same period do not stay
+// synchronized and hit a shared backend at the same instants.  On average, the ticker still ticks
+// once per period.  WithJitter replaces the effect of [WithJitterFraction].  It has no effect on a
+// ticker aligned with [WithWallClockAlignment].
+func WithJitter(max time.Duration) TickerOption {
+	return func(cfg *tickerConfig) { cfg.jitter.max, cfg.jitter.frac = max, 0 }
+}
+
+// WithJitterFraction is like [WithJitter] with a maximum deviation of the fraction f of the period,
+// for example 0.1 for 10%.
+func WithJitterFraction(f float64) TickerOption {
+	return func(cfg *tickerConfig) { cfg.jitter.max, cfg.jitter.frac = 0, f }
+}
+
+// WithJitterSource makes the ticker draw the random numbers for [WithJitter] from r instead of
+// [rand.Float64], for example to make tests reproducible.  r must return values in the half-open
+// interval [0, 1).  It is called by the goroutines that start and fire the ticker, so it must be
+// safe for concurrent use unless the ticker is driven by a [FakeClock] from a single goroutine.
+func WithJitterSource(r func() float64) TickerOption {
+	return func(cfg *tickerConfig) { cfg.jitter.rnd = r }
+}
+
+// WithBackoff makes the ticker back off exponentially: its first interval is the duration passed to
+// NewTicker or [Ticker.Reset], and each following interval is factor times the previous one, up to
+// max (unless max is zero).  Combined with [WithJitterFraction], this is the usual schedule of
+// reconnection attempts.  Call Reset with the base interval to start over, for example after a
+// successful attempt.  [WithWallClockAlignment] and [WithDriftFree] have no effect on a backoff
+// ticker.  WithBackoff panics if factor is less than 1 or max is negative.
+func WithBackoff(factor float64, max time.Duration) TickerOption {
+	if !(factor >= 1) || max < 0 {
+		panic("kairos: invalid WithBackoff parameters")
+	}
+	return func(cfg *tickerConfig) { cfg.backoff = backoff{factor: factor, max: max} }
+}
+
+// WithRealignOnResume makes [Ticker.Resume] restart the ticker's schedule from the time of the
+// call, so that the first tick after a pause arrives one period later, instead of resuming on the
+// original cadence.
+func WithRealignOnResume() TickerOption {
+	return func(cfg *tickerConfig) { cfg.realign = true }
+}
+
+// WithMaxTicks makes the ticker stop by itself after n ticks, for finite polling jobs such as "try
+// 10 times, once a second".  [Ticker.Done] returns a channel that is closed once the n-th tick has
+// been sent.  Ticks dropped for a slow receiver count toward n; use [DeliverMissedTicks] to make
+// sure the receiver gets all n.  Reset starts counting again from zero.
+func WithMaxTicks(n int) TickerOption {
+	return func(cfg *tickerConfig) { cfg.maxTicks = n }
+}
+
+// WithDriftFree makes the ticker schedule its n-th tick at start + n*d, where start is the time the
+// ticker was started and d its period, so that its ticks stay on the same grid however long it
+// runs.  Every ticker measures each interval from the previous deadline rather than from the time
+// the previous tick was actually delivered, so late deliveries never add up, but jitter from
+// [WithJitter] does: by default the randomized intervals accumulate like a random walk.  With
+// WithDriftFree, the jitter is instead a random offset of each tick from its grid point, and a
+// ticker that drops missed ticks resumes on the grid.
+func WithDriftFree() TickerOption {
+	return func(cfg *tickerConfig) { cfg.driftFree = true }
+}
+
+// WithPanicHandler makes a ticker created by [TickFunc] call h with the value of each panic of
+// its function, which it recovers from.  By default, such panics are recovered from and ignored.
+// The option has no effect on other tickers.
+func WithPanicHandler(h func(v any)) TickerOption {
+	return func(cfg *tickerConfig) { cfg.onPanic = h }
+}
+
+// nextBoundary returns the first multiple of d since epoch, or since the zero time if epoch is
+// zero, that is after t in wall-clock time.  The result has no monotonic clock reading.
+func nextBoundary(t, epoch time.Time, d time.Duration) time.Time {
+	if epoch.IsZero() {
+		return t.Truncate(d).Add(d)
+	}
+	t = t.Round(0)
+	r := t.Sub(epoch) % d
+	if r < 0 {
+		r += d
+	}
+	return t.Add(d - r)
+}
+
+// NewTicker returns a new [Ticker] containing a channel that will send the current time on the
+// channel after each tick.  The period of the ticks is specified by the duration argument.  The
+// ticker will adjust the time interval or drop ticks to make up for slow receivers, according to
+// its [MissedTickPolicy].  The duration d must be greater than zero; if not, NewTicker will panic.
+func NewTicker(d time.Duration, opts ...TickerOption) *Ticker {
+	return defaultClock().NewTicker(d, opts...)
+}
+
+// NewTicker returns a new [Ticker] driven by the clock.  See the package-level [NewTicker].
+func (clk *clock) NewTicker(d time.Duration, opts ...TickerOption) *Ticker {
+	if d <= 0 {
+		panic("kairos: non-positive interval for NewTicker")
+	}
+	tk := clk.newTicker(d, nil, opts)
+	tk.start(d)
+	return clk.trackTicker(tk)
+}
+
+// NewFuncTicker returns a new [Ticker] whose intervals are computed by next, for example to poll
+// less often while idle and more often while busy.  The ticker calls next with the time it was
+// started to get the first interval, then with the deadline of each tick to get the interval until
+// the following one.  If next returns a non-positive duration, the ticker stops.  next is called
+// once each tick is delivered, without any lock of the clock held, so it may use the clock, for
+// example to read its time.  [WithWallClockAlignment] and [WithDriftFree] have no effect on such a
+// ticker.
+func NewFuncTicker(next func(prev time.Time) time.Duration, opts ...TickerOption) *Ticker {
+	return defaultClock().NewFuncTicker(next, opts...)
+}
+
+// NewFuncTicker returns a new function [Ticker] driven by the clock.  See the package-level
+// [NewFuncTicker].
+func (clk *clock) NewFuncTicker(next func(prev time.Time) time.Duration, opts ...TickerOption) *Ticker {
+	d := next(clk.now())
+	tk := clk.newTicker(d, next, opts)
+	if d > 0 {
+		tk.start(d)
+	}
+	return clk.trackTicker(tk)
+}
+
+// TickFunc returns a new [Ticker] that calls f with the time of each tick, every d, instead of
+// sending it on a channel; the ticker's C field is nil.  Each call is made in its own goroutine, as
+// for [AfterFunc], but calls never overlap: a tick that falls while f is still running is dropped
+// or, with [DeliverMissedTicks], queued until f returns.  If f panics, the ticker recovers and
+// keeps ticking; see [WithPanicHandler].  Stop discards the queued ticks but does not wait for a
+// call in progress.  The duration d must be greater than zero; if not, TickFunc will panic.
+func TickFunc(d time.Duration, f func(t time.Time), opts ...TickerOption) *Ticker {
+	return defaultClock().TickFunc(d, f, opts...)
+}
+
+// TickFunc returns a new function-calling [Ticker] driven by the clock.  See the package-level
+// [TickFunc].
+func (clk *clock) TickFunc(d time.Duration, f func(t time.Time), opts ...TickerOption) *Ticker {
+	if d <= 0 {
+		panic("kairos: non-positive interval for TickFunc")
+	}
+	tk := clk.newTicker(d, nil, opts)
+	tk.C, tk.c, tk.t.C, tk.t.c = nil, nil, nil, nil
+	tk.fn = f
+	tk.start(d)
+	return tk
+}
+
+// newTicker returns a new stopped ticker.
+func (clk *clock) newTicker(d time.Duration, next func(time.Time) time.Duration, opts []TickerOption) *Ticker {
+	var cfg tickerConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	c := make(chan time.Time, 1)
+	tk := &Ticker{
+		C:         c,
+		c:         c,
+		missed:    cfg.missed,
+		maxLag:    cfg.maxLag,
+		immediate: cfg.immediate,
+		aligned:   cfg.aligned,
+		epoch:     cfg.epoch,
+		driftFree: cfg.driftFree,
+		jitter:    cfg.jitter,
+		backoff:   cfg.backoff,
+		next:      next,
+		realign:   cfg.realign,
+		maxTicks:  cfg.maxTicks,
+		onPanic:   cfg.onPanic,
+	}
+	if tk.maxTicks > 0 {
+		tk.doneC = make(chan struct{})
+	}
+	if next != nil || tk.backoff.factor > 0 {
+		tk.aligned, tk.driftFree = false, false
+	}
+	tk.t = &Timer{C: c, c: c, clk: clk, tk: tk, period: d, prio: cfg.prio}
+	clk.created(tk.t)
+	if cfg.prio != NormalPriority {
+		clk.mutex.Lock()
+		clk.prioritizeLocked()
+		clk.mutex.Unlock()
+	}
+	return tk
+}
+
+// Reset stops the ticker and resets its period to the specified duration.  The next tick will
+// arrive after the new period elapses, or at the next boundary for a ticker aligned with
+// [WithWallClockAlignment].  For a ticker created by [NewFuncTicker], d is the first interval and
+// the function computes the following ones.  Ticks not yet delivered are discarded.  The duration d
+// must be greater than zero; if not, Reset will panic.
+func (tk *Ticker) Reset(d time.Duration) {
+	if d <= 0 {
+		panic("kairos: non-positive interval for Ticker.Reset")
+	}
+	tk = tk.impl()
+	if tk.t == nil {
+		panic("kairos: Reset called on uninitialized Ticker")
+	}
+	tk.t.clk.mutex.Lock()
+	tk.t.period = d
+	tk.paused = false
+	tk.discardLocked()
+	if tk.maxTicks > 0 && tk.count >= tk.maxTicks {
+		tk.doneC = make(chan struct{})
+	}
+	tk.count = 0
+	tk.t.clk.mutex.Unlock()
+	tk.start(d)
+}
+
+// Ticks returns a channel that delivers the ticks with their sequence numbers, so that the
+// receiver can tell how many ticks it missed.  Once Ticks has been called, the ticker delivers its
+// ticks on that channel instead of C; a tick waiting in C is moved to it.  Ticks returns nil for
+// a ticker created by [TickFunc].
+func (tk *Ticker) Ticks() <-chan Tick {
+	tk = tk.impl()
+	if tk.t == nil {
+		panic("kairos: Ticks called on uninitialized Ticker")
+	}
+	tk.t.clk.mutex.Lock()
+	defer tk.t.clk.mutex.Unlock()
+	if tk.fn != nil {
+		return nil
+	}
+	if tk.tc == nil {
+		tk.tc = make(chan Tick, 1)
+		select {
+		case now := <-tk.C:
+			tk.tc <- Tick{Time: now, Scheduled: tk.sentSched, Seq: tk.sentSeq}
+		default:
+		}
+	}
+	return tk.tc
+}
+
+// drainLocked empties the channel returned by Ticks, if any.  The clock's mutex must be held.
+func (tk *Ticker) drainLocked() {
+	if tk.tc != nil {
+		select {
+		case <-tk.tc:
+		default:
+		}
+	}
+}
+
+// Done returns a channel that is closed when a ticker created with [WithMaxTicks] has sent its
+// last tick and stopped.  It returns nil for other tickers.  After Reset, Done returns a new
+// channel.
+func (tk *Ticker) Done() <-chan struct{} {
+	tk = tk.impl()
+	if tk.t == nil {
+		return nil
+	}
+	tk.t.clk.mutex.Lock()
+	defer tk.t.clk.mutex.Unlock()
+	return tk.doneC
+}
+
+// start arms the ticker's timer and delivers the immediate first tick, if requested.
+func (tk *Ticker) start(d time.Duration) {
+	clk := tk.t.clk
+	clk.resetTimer(tk.t, d)
+	if !tk.immediate {
+		return
+	}
+	now := clk.now()
+	clk.mutex.Lock()
+	done := !clk.closed && tk.tickLocked(now, now)
+	clk.runCallsLocked()
+	clk.mutex.Unlock()
+	if done {
+		clk.delTimer(tk.t)
+	}
+}
+
+// Stop turns off the ticker.  After Stop, no more ticks will be sent, and ticks not yet delivered
+// are discarded.  Stop does not close the channel, to prevent a concurrent goroutine reading from
+// the channel from seeing an erroneous "tick".
+func (tk *Ticker) Stop() {
+	tk = tk.impl()
+	if tk.t == nil {
+		panic("kairos: Stop called on uninitialized Ticker")
+	}
+	tk.t.clk.delTimer(tk.t)
+	tk.t.clk.mutex.Lock()
+	tk.paused = false
+	tk.discardLocked()
+	tk.t.clk.mutex.Unlock()
+}
+
+// Pause suppresses the ticks of a running ticker, for example during a maintenance window, until
+// [Ticker.Resume] is called.  Ticks not yet delivered are discarded.  Pause does nothing if the
+// ticker is stopped or already paused.
+func (tk *Ticker) Pause() {
+	tk = tk.impl()
+	if tk.t == nil {
+		panic("kairos: Pause called on uninitialized Ticker")
+	}
+	clk := tk.t.clk
+	if !clk.delTimer(tk.t) {
+		return
+	}
+	clk.mutex.Lock()
+	defer clk.mutex.Unlock()
+	tk.paused = true
+	tk.discardLocked()
+}
+
+// Resume restarts a ticker paused by [Ticker.Pause].  By default the ticker resumes on its
+// original cadence: the first tick after the pause arrives when it would have arrived had the
+// ticker never been paused, and the ticks that were due during the pause are skipped.  A function
+// or backoff ticker whose next tick was due during the pause, and any ticker created with
+// [WithRealignOnResume], restarts its schedule instead, as if reset to its current period.  Resume
+// does nothing if the ticker is not paused.  The channel is emptied.
+func (tk *Ticker) Resume() {
+	tk = tk.impl()
+	if tk.t == nil {
+		panic("kairos: Resume called on uninitialized Ticker")
+	}
+	clk := tk.t.clk
+	now := clk.now()
+	clk.mutex.Lock()
+	if !tk.paused {
+		clk.mutex.Unlock()
+		return
+	}
+	tk.paused = false
+	d := tk.t.period
+	var when time.Time
+	if !tk.realign {
+		when = tk.resumeDeadlineLocked(now)
+	}
+	clk.mutex.Unlock()
+	clk.startTimer(tk.t, d, when)
+}
+
+// resumeDeadlineLocked returns the deadline of the first tick after now on the ticker's original
+// schedule, or the zero time if the schedule must restart.  The clock's mutex must be held.
+func (tk *Ticker) resumeDeadlineLocked(now time.Time) time.Time {
+	t := tk.t
+	switch {
+	case tk.aligned:
+		return nextBoundary(now, tk.epoch, t.period)
+	case tk.driftFree:
+		if n := int64(now.Sub(tk.anchor)/t.period) + 1; n > tk.n {
+			tk.n = n
+		}
+		return tk.anchor.Add(time.Duration(tk.n)*t.period + tk.jitter.offset(t.period))
+	case t.when.After(now):
+		return t.when
+	case tk.next != nil || tk.backoff.factor > 0:
+		return time.Time{}
+	}
+	return t.when.Add(t.period * (now.Sub(t.when)/t.period + 1))
+}
+
+// SetLabel attaches a descriptive label to the ticker.  See [Timer.SetLabel].
+func (tk *Ticker) SetLabel(label string) {
+	tk = tk.impl()
+	if tk.t == nil {
+		panic("kairos: SetLabel called on uninitialized Ticker")
+	}
+	tk.t.SetLabel(label)
+}
+
+// ID returns the number of the timer backing the ticker, which identifies the ticker in the
+// diagnostics of the package.  See [Timer.ID].
+func (tk *Ticker) ID() uint64 {
+	tk = tk.impl()
+	if tk.t == nil {
+		return 0
+	}
+	return tk.t.id
+}
+
+// Label returns the label set by [Ticker.SetLabel], or the empty string if none.
+func (tk *Ticker) Label() string {
+	tk = tk.impl()
+	if tk.t == nil {
+		return ""
+	}
+	return tk.t.Label()
+}
+
+// discardLocked empties the backlog, makes the forwarding goroutine, if any, give up, and cancels
+// the pending restart of a ticker created by NewFuncTicker.  The clock's mutex must be held.
+func (tk *Ticker) discardLocked() {
+	tk.backlog = nil
+	tk.nextGen++
+	if tk.stopC != nil {
+		close(tk.stopC)
+		tk.stopC = nil
+	}
+}
+
+// tickLocked delivers a tick due at time scheduled at time now and counts it.  It reports whether
+// that was the last tick allowed by WithMaxTicks.  The clock's mutex must be held.
+func (tk *Ticker) tickLocked(now, scheduled time.Time) (last bool) {
+	tk.deliverLocked(Tick{Time: now, Scheduled: scheduled, Seq: tk.tickSeq})
+	tk.tickSeq++
+	if tk.maxTicks <= 0 {
+		return false
+	}
+	tk.count++
+	if tk.count == tk.maxTicks {
+		close(tk.doneC)
+	}
+	return tk.count >= tk.maxTicks
+}
+
+// deliverLocked sends a tick or queues it, according to the ticker's missed-tick policy.  The
+// clock's mutex must be held.
+func (tk *Ticker) deliverLocked(tick Tick) {
+	if tk.fn != nil {
+		tk.callLocked(tick)
+		return
+	}
+	if !tk.forwarding {
+		if tk.tc != nil {
+			select {
+			case tk.tc <- tick:
+				return
+			default:
+			}
+		} else {
+			select {
+			case tk.c <- tick.Time:
+				tk.sentSeq, tk.sentSched = tick.Seq, tick.Scheduled
+				return
+			default:
+			}
+		}
+	}
+	clk := tk.t.clk
+	if tk.missed == DropMissedTicks || clk.closed {
+		return
+	}
+	if tk.maxLag > 0 && len(tk.backlog) >= tk.maxLag {
+		return
+	}
+	tk.backlog = append(tk.backlog, tick)
+	if tk.stopC == nil {
+		tk.stopC = make(chan struct{})
+	}
+	if !tk.forwarding {
+		tk.forwarding = true
+		if clk.forwarders == nil {
+			clk.forwarders = make(map[*Ticker]struct{})
+		}
+		clk.forwarders[tk] = struct{}{}
+		clk.funcs.Add(1)
+		go func() {
+			defer clk.funcs.Done()
+			tk.forward()
+		}()
+	}
+}
+
+// forward delivers the backlog, blocking until the receiver takes each tick.  Shutdown makes it
+// give up, and waits for it to return.
+func (tk *Ticker) forward() {
+	mu := &tk.t.clk.mutex
+	mu.Lock()
+	defer mu.Unlock()
+	for len(tk.backlog) > 0 {
+		tick, stopC, tc := tk.backlog[0], tk.stopC, tk.tc
+		tk.backlog = tk.backlog[1:]
+		mu.Unlock()
+		sent := false
+		if tc != nil {
+			select {
+			case tc <- tick:
+			case <-stopC:
+			}
+		} else {
+			select {
+			case tk.c <- tick.Time:
+				sent = true
+			case <-stopC:
+			}
+		}
+		mu.Lock()
+		if sent {
+			tk.sentSeq, tk.sentSched = tick.Seq, tick.Scheduled
+		}
+	}
+	tk.forwarding = false
+	delete(tk.t.clk.forwarders, tk)
+}
+
+// callLocked calls the function of a ticker created by TickFunc with the time of tick, or, if a
+// call is still running, queues or drops the tick according to the missed-tick policy.  The
+// clock's mutex must be held.
+func (tk *Ticker) callLocked(tick Tick) {
+	now := tick.Time
+	if tk.calling {
+		if tk.missed == DeliverMissedTicks && (tk.maxLag <= 0 || len(tk.backlog) < tk.maxLag) {
+			tk.backlog = append(tk.backlog, tick)
+		}
+		return
+	}
+	tk.calling = true
+	clk := tk.t.clk
+	f := clk.callbackLocked(tk.t, tk.fn, func() { tk.call(now) })
+	if clk.serial {
+		clk.calls = append(clk.calls, f)
+		return
+	}
+	clk.goLocked(f)
+}
+
+// call calls the function with the tick at time now, then with each queued tick, in order.
+func (tk *Ticker) call(now time.Time) {
+	mu := &tk.t.clk.mutex
+	for {
+		tk.callOne(now)
+		mu.Lock()
+		if len(tk.backlog) == 0 {
+			tk.calling = false
+			mu.Unlock()
+			return
+		}
+		now, tk.backlog = tk.backlog[0].Time, tk.backlog[1:]
+		mu.Unlock()
+	}
+}
+
+// callOne calls the function with the tick at time now, recovering from a panic.
+func (tk *Ticker) callOne(now time.Time) {
+	defer func() {
+		v := recover()
+		if v != nil {
+			tk.t.clk.ops.panics.Add(1)
+		}
+		if v != nil && tk.t.clk.log != nil {
+			tk.t.clk.logPanic(tk.t, v)
+		}
+		if v != nil && tk.onPanic != nil {
+			tk.onPanic(v)
+		}
+	}()
+	tk.fn(now)
+}
+
+// rearmLocked restarts the periodic timer t after it fired at time now.  If the clock fell behind
+// by more than a period, a ticker that drops missed ticks skips the missed intervals.  The mutex
+// must be held.
+func (clk *clock) rearmLocked(t *Timer, now time.Time) {
+	if clk.closed {
+		return
+	}
+	tk := t.tk
+	switch {
+	case tk.next != nil:
+		clk.rearmFuncLocked(t)
+		return
+	case tk.backoff.factor > 0:
+		t.period = tk.backoff.grow(t.period)
+		t.when = t.when.Add(tk.jitter.interval(t.period))
+	case tk.aligned:
+		t.when = nextBoundary(t.when, tk.epoch, t.period)
+	case tk.driftFree:
+		tk.n++
+		if n := int64(now.Sub(tk.anchor)/t.period) + 1; tk.missed == DropMissedTicks && n > tk.n {
+			tk.tickSeq += n - tk.n
+			tk.n = n
+		}
+		t.when = tk.anchor.Add(time.Duration(tk.n)*t.period + tk.jitter.offset(t.period))
+	default:
+		t.when = t.when.Add(tk.jitter.interval(t.period))
+	}
+	clk.insertTickerLocked(t, now)
+}
+
+// insertTickerLocked arms the periodic timer t again with its new deadline, skipping the intervals
+// already missed at time now if its ticker drops missed ticks.  The mutex must be held.
+func (clk *clock) insertTickerLocked(t *Timer, now time.Time) {
+	tk := t.tk
+	if tk.missed == DropMissedTicks && !tk.driftFree && !t.when.After(now) {
+		skipped := now.Sub(t.when)/t.period + 1
+		tk.tickSeq += int64(skipped)
+		t.when = t.when.Add(t.period * skipped)
+	}
+	t.seq = clk.nextSeqLocked()
+	if clk.rtimers != nil {
+		clk.armRuntimeLocked(t)
+		t.rt.Reset(t.when.Sub(now))
+		return
+	}
+	clk.timers.Insert(t)
+}
+
+// rearmFuncLocked restarts the periodic timer t of a ticker created by NewFuncTicker after it
+// fired, with the interval that the ticker's next function returns for the tick's deadline.  next
+// is called without the mutex held, once the tick is delivered, so that it may use the clock; a
+// Stop or Reset of the ticker in the meantime cancels the restart.  On clocks driven by
+// FakeClock.Advance or PopExpired, next is called before they return; otherwise it is called in
+// its own goroutine.  The mutex must be held.
+func (clk *clock) rearmFuncLocked(t *Timer) {
+	tk := t.tk
+	prev, gen := t.when, tk.nextGen
+	queued := clk.sleeper == nil && clk.rtimers == nil
+	f := func() {
+		d := tk.next(prev)
+		now := clk.now()
+		clk.mutex.Lock()
+		if d <= 0 || clk.closed || tk.nextGen != gen {
+			clk.mutex.Unlock()
+			return
+		}
+		t.period = d
+		t.when = prev.Add(tk.jitter.interval(d))
+		clk.insertTickerLocked(t, now)
+		first := clk.rtimers == nil && clk.timers.Peek() == t
+		clk.mutex.Unlock()
+		// The caller that queued f goes on firing the timers, but the timer routine must be told.
+		if first && !queued {
+			clk.kick()
+		}
+	}
+	if queued {
+		clk.calls = append(clk.calls, f)
+		return
+	}
+	clk.funcs.Add(1)
+	go func() {
+		defer clk.funcs.Done()
+		f()
+	}()
+}
@@ -0,0 +1,665 @@
+package kairos
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recvTicks returns the ticks that can be received from c within a short real time.
+func recvTicks(c <-chan time.Time) []time.Time {
+	var ticks []time.Time
+	for {
+		select {
+		case v := <-c:
+			ticks = append(ticks, v)
+		case <-time.After(50 * time.Millisecond):
+			return ticks
+		}
+	}
+}
+
+func TestFakeClockTicker(t *testing.T) {
+	at := func(secs ...int) []time.Time {
+		var ts []time.Time
+		for _, s := range secs {
+			ts = append(ts, fakeStart.Add(time.Duration(s)*time.Second))
+		}
+		return ts
+	}
+	for _, tc := range []struct {
+		desc  string
+		opts  []TickerOption
+		steps [][]time.Time // Ticks expected after each advance by 1s, 3s, 1s.
+	}{
+		{"drop", nil, [][]time.Time{at(1), at(2), at(5)}},
+		{"deliver", []TickerOption{WithMissedTicks(DeliverMissedTicks)}, [][]time.Time{at(1), at(2, 3, 4), at(5)}},
+		{
+			"deliver with limit",
+			[]TickerOption{WithMissedTicks(DeliverMissedTicks), WithCatchUpLimit(1)},
+			[][]time.Time{at(1), at(2, 3), at(5)},
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			fc := NewFakeClock(fakeStart)
+			tk := fc.NewTicker(time.Second, tc.opts...)
+			defer tk.Stop()
+			for i, d := range []time.Duration{time.Second, 3 * time.Second, time.Second} {
+				fc.Advance(d)
+				got := recvTicks(tk.C)
+				if len(got) != len(tc.steps[i]) {
+					t.Fatalf("advance %d: got ticks %v, want %v", i, got, tc.steps[i])
+				}
+				for j := range got {
+					if !got[j].Equal(tc.steps[i][j]) {
+						t.Errorf("advance %d: got ticks %v, want %v", i, got, tc.steps[i])
+						break
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestFakeClockTickerCloseStopsForwarder(t *testing.T) {
+	fc := NewFakeClock(fakeStart)
+	tk := fc.NewTicker(time.Second, WithMissedTicks(DeliverMissedTicks))
+	defer tk.Stop()
+	// The tick at 1s fills the channel, and a goroutine waits to deliver the next ones.
+	fc.Advance(3 * time.Second)
+	if got := fc.Stats().InFlight; got != 1 {
+		t.Errorf("got %d goroutines in flight with a backlog, want 1", got)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := fc.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown: got error %v, want nil", err)
+	}
+	if got := fc.Stats().InFlight; got != 0 {
+		t.Errorf("got %d goroutines in flight after Shutdown, want 0", got)
+	}
+}
+
+func TestFakeClockTickerWithTimers(t *testing.T) {
+	fc := NewFakeClock(fakeStart, WithDeterministicDispatch())
+	var got []string
+	tk := fc.NewTicker(2 * time.Second)
+	fc.AfterFunc(3*time.Second, func() {
+		got = append(got, "timer")
+		tk.Reset(time.Second)
+	})
+	for i := 0; i < 5; i++ {
+		fc.Advance(time.Second)
+		if _, ok := recv(tk.C); ok {
+			got = append(got, fc.Now().Sub(fakeStart).String())
+		}
+	}
+	if want := "[2s timer 4s 5s]"; fmt.Sprint(got) != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if got, want := fc.Pending(), 1; got != want {
+		t.Errorf("got Pending() %d, want %d", got, want)
+	}
+	tk.Stop()
+	if got, want := fc.Pending(), 0; got != want {
+		t.Errorf("after Stop: got Pending() %d, want %d", got, want)
+	}
+	fc.Advance(time.Minute)
+	if _, ok := recv(tk.C); ok {
+		t.Errorf("stopped ticker ticked")
+	}
+}
+
+func TestTicker(t *testing.T) {
+	for _, tc := range []struct {
+		desc  string
+		clock Clock
+	}{
+		{"real", NewClock()},
+		{"runtime", NewClock(WithRuntimeTimers())},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Cleanup(func() { tc.clock.Close() })
+			const period = 10 * time.Millisecond
+			start := time.Now()
+			tk := tc.clock.NewTicker(period)
+			for i := 0; i < 3; i++ {
+				<-tk.C
+			}
+			if got, want := time.Since(start), 3*period; got < want {
+				t.Errorf("got 3 ticks after %v, want at least %v", got, want)
+			}
+			tk.Stop()
+			time.Sleep(2 * period)
+			recv(tk.C) // A tick might have been sent before Stop.
+			time.Sleep(2 * period)
+			if _, ok := recv(tk.C); ok {
+				t.Errorf("stopped ticker ticked")
+			}
+		})
+	}
+}
+
+func TestNewTickerPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("NewTicker(0) did not panic")
+		}
+	}()
+	NewTicker(0)
+}
+
+func TestTickerImmediateFirstTick(t *testing.T) {
+	fc := NewFakeClock(fakeStart)
+	tk := fc.NewTicker(time.Minute, WithImmediateFirstTick())
+	defer tk.Stop()
+	for _, step := range []struct {
+		advance time.Duration
+		reset   bool
+		want    time.Duration // Time of the expected tick, relative to fakeStart, or -1 if none.
+	}{
+		{advance: 0, want: 0},
+		{advance: 30 * time.Second, want: -1},
+		{advance: 30 * time.Second, want: time.Minute},
+		{advance: 30 * time.Second, reset: true, want: 90 * time.Second},
+		{advance: time.Minute, want: 150 * time.Second},
+	} {
+		fc.Advance(step.advance)
+		if step.reset {
+			tk.Reset(time.Minute)
+		}
+		got, ok := recv(tk.C)
+		if ok != (step.want >= 0) || (ok && !got.Equal(fakeStart.Add(step.want))) {
+			t.Errorf("at %v: got (%v, %v), want tick at %v", fc.Now().Sub(fakeStart), got, ok, step.want)
+		}
+	}
+}
+
+func TestTickerWallClockAlignment(t *testing.T) {
+	start := time.Date(2023, 5, 17, 12, 0, 17, 0, time.UTC)
+	fc := NewFakeClock(start)
+	tk := fc.NewTicker(time.Minute, WithWallClockAlignment())
+	defer tk.Stop()
+	for _, step := range []struct {
+		desc string
+		set  time.Time // New time of the clock.
+		want string    // Expected tick, or "" if none.
+	}{
+		{"before first boundary", time.Date(2023, 5, 17, 12, 0, 59, 0, time.UTC), ""},
+		{"first boundary", time.Date(2023, 5, 17, 12, 1, 0, 0, time.UTC), "12:01:00"},
+		{"between boundaries", time.Date(2023, 5, 17, 12, 1, 30, 0, time.UTC), ""},
+		{"clock stepped back", time.Date(2023, 5, 17, 11, 0, 30, 0, time.UTC), ""},
+		{"old boundary after step back", time.Date(2023, 5, 17, 11, 2, 0, 0, time.UTC), ""},
+		{"boundary after step back", time.Date(2023, 5, 17, 12, 2, 0, 0, time.UTC), "12:02:00"},
+		{"clock stepped forward", time.Date(2023, 5, 17, 14, 7, 42, 0, time.UTC), "12:03:00"},
+		{"boundary after step forward", time.Date(2023, 5, 17, 14, 8, 0, 0, time.UTC), "14:08:00"},
+	} {
+		fc.Set(step.set)
+		got := ""
+		if v, ok := recv(tk.C); ok {
+			got = v.Format("15:04:05")
+		}
+		if got != step.want {
+			t.Errorf("%s: got tick %q, want %q", step.desc, got, step.want)
+		}
+	}
+
+	tk.Reset(time.Hour)
+	if got, want := fc.PendingTimers()[0].When, time.Date(2023, 5, 17, 15, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("after Reset: got next tick at %v, want %v", got, want)
+	}
+}
+
+func TestTickerAlignmentEpoch(t *testing.T) {
+	start := time.Date(2023, 5, 17, 12, 0, 17, 0, time.UTC)
+	epoch := time.Date(2023, 5, 17, 0, 7, 0, 0, time.UTC)
+	fc := NewFakeClock(start)
+	tk := fc.NewTicker(15*time.Minute, WithAlignmentEpoch(epoch))
+	defer tk.Stop()
+	var got []string
+	for i := 0; i < 3; i++ {
+		when, _ := fc.NextDeadline()
+		fc.AdvanceTo(when)
+		got = append(got, (<-tk.C).Format("15:04:05"))
+	}
+	if want := "[12:07:00 12:22:00 12:37:00]"; fmt.Sprint(got) != want {
+		t.Errorf("got ticks %v, want %v", got, want)
+	}
+
+	// WithWallClockAlignment replaces the epoch.
+	tk2 := fc.NewTicker(15*time.Minute, WithAlignmentEpoch(epoch), WithWallClockAlignment())
+	defer tk2.Stop()
+	if got, want := fc.PendingTimers()[0].When, time.Date(2023, 5, 17, 12, 45, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("got next tick at %v, want %v", got, want)
+	}
+}
+
+func TestTickerJitter(t *testing.T) {
+	for _, tc := range []struct {
+		desc string
+		opt  TickerOption
+		max  time.Duration
+	}{
+		{"absolute", WithJitter(2 * time.Second), 2 * time.Second},
+		{"fraction", WithJitterFraction(0.5), 5 * time.Second},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			// A fixed sequence of random numbers makes the intervals predictable.
+			rnds := []float64{0, 0.5, 0.999999}
+			i := 0
+			src := func() float64 {
+				r := rnds[i%len(rnds)]
+				i++
+				return r
+			}
+			fc := NewFakeClock(fakeStart)
+			tk := fc.NewTicker(10*time.Second, tc.opt, WithJitterSource(src))
+			defer tk.Stop()
+			var got []time.Duration
+			last := fakeStart
+			for len(got) < 6 {
+				when, _ := fc.NextDeadline()
+				fc.AdvanceTo(when)
+				now := <-tk.C
+				got = append(got, now.Sub(last).Round(time.Millisecond))
+				last = now
+			}
+			lo, mid, hi := 10*time.Second-tc.max, 10*time.Second, 10*time.Second+tc.max
+			want := []time.Duration{lo, mid, hi, lo, mid, hi}
+			if fmt.Sprint(got) != fmt.Sprint(want) {
+				t.Errorf("got intervals %v, want %v", got, want)
+			}
+		})
+	}
+
+	// The default source spreads the ticks of tickers started together.
+	fc := NewFakeClock(fakeStart)
+	for i := 0; i < 10; i++ {
+		defer fc.NewTicker(time.Minute, WithJitterFraction(0.1)).Stop()
+	}
+	seen := map[time.Time]bool{}
+	for _, p := range fc.PendingTimers() {
+		seen[p.When] = true
+	}
+	if len(seen) < 2 {
+		t.Errorf("10 jittered tickers all tick at the same time")
+	}
+}
+
+func TestTickerDriftFree(t *testing.T) {
+	offsets := []float64{0, 0.5, 0.999999} // Random numbers for offsets of -2s, 0, and +2s.
+	for _, tc := range []struct {
+		desc  string
+		opts  []TickerOption
+		ticks []time.Duration // Expected ticks, relative to fakeStart.
+	}{
+		{"default", nil, []time.Duration{8 * time.Second, 18 * time.Second, 30 * time.Second, 38 * time.Second}},
+		{"drift-free", []TickerOption{WithDriftFree()}, []time.Duration{8 * time.Second, 20 * time.Second, 32 * time.Second, 38 * time.Second}},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			i := 0
+			src := func() float64 {
+				r := offsets[i%len(offsets)]
+				i++
+				return r
+			}
+			fc := NewFakeClock(fakeStart)
+			opts := append([]TickerOption{WithJitter(2 * time.Second), WithJitterSource(src)}, tc.opts...)
+			tk := fc.NewTicker(10*time.Second, opts...)
+			defer tk.Stop()
+			var got []time.Duration
+			for len(got) < len(tc.ticks) {
+				when, _ := fc.NextDeadline()
+				fc.AdvanceTo(when)
+				got = append(got, (<-tk.C).Sub(fakeStart).Round(time.Millisecond))
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tc.ticks) {
+				t.Errorf("got ticks %v, want %v", got, tc.ticks)
+			}
+		})
+	}
+}
+
+func TestFuncTicker(t *testing.T) {
+	fc := NewFakeClock(fakeStart)
+	// Back off from one second, doubling the interval up to four seconds, then stop after a minute.
+	var prevs []time.Duration
+	tk := fc.NewFuncTicker(func(prev time.Time) time.Duration {
+		elapsed := prev.Sub(fakeStart)
+		prevs = append(prevs, elapsed)
+		switch {
+		case elapsed >= time.Minute:
+			return 0
+		case elapsed == 0:
+			return time.Second
+		}
+		return min(elapsed, 4*time.Second)
+	})
+	defer tk.Stop()
+	var got []time.Duration
+	for fc.Pending() > 0 {
+		when, _ := fc.NextDeadline()
+		fc.AdvanceTo(when)
+		got = append(got, (<-tk.C).Sub(fakeStart))
+	}
+	if len(got) < 4 || fmt.Sprint(got[:4]) != "[1s 2s 4s 8s]" {
+		t.Errorf("got ticks %v, want them to start with [1s 2s 4s 8s]", got)
+	}
+	if last := got[len(got)-1]; last != time.Minute {
+		t.Errorf("got last tick %v, want %v", last, time.Minute)
+	}
+	if fmt.Sprint(prevs[:3]) != "[0s 1s 2s]" {
+		t.Errorf("got prev arguments %v, want them to start with [0s 1s 2s]", prevs)
+	}
+
+	stopped := fc.NewFuncTicker(func(time.Time) time.Duration { return 0 })
+	if got := fc.Pending(); got != 0 {
+		t.Errorf("got Pending() %d for a function ticker stopped from the start, want 0", got)
+	}
+	stopped.Reset(time.Second)
+	fc.Advance(time.Second)
+	if _, ok := recv(stopped.C); !ok {
+		t.Errorf("function ticker restarted by Reset did not tick")
+	}
+}
+
+func TestFuncTickerUsesClock(t *testing.T) {
+	for _, tc := range []struct {
+		desc string
+		c    Clock
+	}{
+		{"fake", NewFakeClock(fakeStart)},
+		{"real", NewClock()},
+		{"runtime timers", NewClock(WithRuntimeTimers())},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			defer tc.c.Close()
+			// The interval depends on the clock's time, which next may now read.
+			tk := tc.c.NewFuncTicker(func(time.Time) time.Duration {
+				tc.c.Now()
+				return time.Millisecond
+			})
+			defer tk.Stop()
+			for i := 0; i < 3; i++ {
+				if fc, ok := tc.c.(*FakeClock); ok {
+					fc.Advance(time.Millisecond)
+				}
+				select {
+				case <-tk.C:
+				case <-time.After(time.Second):
+					t.Fatalf("got %d ticks, want 3", i)
+				}
+			}
+			tk.Stop()
+			if diagnostics(tc.c).Pending() != 0 {
+				t.Errorf("got %d armed timers after Stop, want 0", diagnostics(tc.c).Pending())
+			}
+		})
+	}
+}
+
+func TestTickerBackoff(t *testing.T) {
+	fc := NewFakeClock(fakeStart)
+	tk := fc.NewTicker(time.Second, WithBackoff(2, 10*time.Second))
+	defer tk.Stop()
+	intervals := func(n int) []time.Duration {
+		var got []time.Duration
+		last := fc.Now()
+		for len(got) < n {
+			when, _ := fc.NextDeadline()
+			fc.AdvanceTo(when)
+			now := <-tk.C
+			got = append(got, now.Sub(last))
+			last = now
+		}
+		return got
+	}
+	if got, want := fmt.Sprint(intervals(6)), "[1s 2s 4s 8s 10s 10s]"; got != want {
+		t.Errorf("got intervals %v, want %v", got, want)
+	}
+	// Reset starts over from the base interval.
+	tk.Reset(time.Second)
+	if got, want := fmt.Sprint(intervals(3)), "[1s 2s 4s]"; got != want {
+		t.Errorf("after Reset: got intervals %v, want %v", got, want)
+	}
+}
+
+func TestTickerPauseResume(t *testing.T) {
+	for _, tc := range []struct {
+		desc  string
+		opts  []TickerOption
+		ticks string // Ticks received, in seconds since fakeStart.
+	}{
+		// Paused at 12s, resumed at 27s.
+		{"original cadence", nil, "[5s 10s 30s 35s 40s]"},
+		{"realigned", []TickerOption{WithRealignOnResume()}, "[5s 10s 32s 37s]"},
+		{"drift-free", []TickerOption{WithDriftFree()}, "[5s 10s 30s 35s 40s]"},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			fc := NewFakeClock(fakeStart)
+			tk := fc.NewTicker(5*time.Second, tc.opts...)
+			defer tk.Stop()
+			var got []time.Duration
+			for i := 0; i < 40; i++ {
+				switch i {
+				case 12:
+					tk.Pause()
+					tk.Pause() // No effect.
+				case 27:
+					tk.Resume()
+					tk.Resume() // No effect.
+				}
+				fc.Advance(time.Second)
+				if now, ok := recv(tk.C); ok {
+					got = append(got, now.Sub(fakeStart))
+				}
+			}
+			if fmt.Sprint(got) != tc.ticks {
+				t.Errorf("got ticks %v, want %v", got, tc.ticks)
+			}
+		})
+	}
+
+	fc := NewFakeClock(fakeStart)
+	tk := fc.NewTicker(time.Second)
+	tk.Stop()
+	tk.Pause()
+	tk.Resume()
+	if got := fc.Pending(); got != 0 {
+		t.Errorf("Resume restarted a stopped ticker: got Pending() %d, want 0", got)
+	}
+}
+
+func TestTickerMaxTicks(t *testing.T) {
+	for _, tc := range []struct {
+		desc  string
+		opts  []TickerOption
+		ticks string
+	}{
+		{"plain", nil, "[1s 2s 3s]"},
+		{"immediate first tick", []TickerOption{WithImmediateFirstTick()}, "[0s 1s 2s]"},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			fc := NewFakeClock(fakeStart)
+			tk := fc.NewTicker(time.Second, append(tc.opts, WithMaxTicks(3))...)
+			done := tk.Done()
+			var got []time.Duration
+			for i := 0; i < 5; i++ {
+				if now, ok := recv(tk.C); ok {
+					got = append(got, now.Sub(fakeStart))
+				}
+				fc.Advance(time.Second)
+			}
+			if fmt.Sprint(got) != tc.ticks {
+				t.Errorf("got ticks %v, want %v", got, tc.ticks)
+			}
+			select {
+			case <-done:
+			default:
+				t.Errorf("Done channel not closed after the last tick")
+			}
+			if got := fc.Pending(); got != 0 {
+				t.Errorf("got Pending() %d after the last tick, want 0", got)
+			}
+
+			tk.Reset(time.Second)
+			if tk.Done() == done {
+				t.Errorf("Done returned the same channel after Reset")
+			}
+			for i := 0; i < 3; i++ {
+				fc.Advance(time.Second)
+			}
+			<-tk.Done()
+		})
+	}
+	if NewFakeClock(fakeStart).NewTicker(time.Second).Done() != nil {
+		t.Errorf("Done of an unlimited ticker is not nil")
+	}
+}
+
+func TestTickFunc(t *testing.T) {
+	fc := NewFakeClock(fakeStart, WithDeterministicDispatch())
+	defer fc.Close()
+	var calls []time.Duration
+	var panics []any
+	tk := fc.TickFunc(time.Second, func(now time.Time) {
+		calls = append(calls, now.Sub(fakeStart))
+		if len(calls) == 2 {
+			panic("boom")
+		}
+	}, WithImmediateFirstTick(), WithPanicHandler(func(v any) { panics = append(panics, v) }))
+	if tk.C != nil {
+		t.Errorf("got non-nil C for a TickFunc ticker")
+	}
+	for i := 0; i < 3; i++ {
+		fc.Advance(time.Second)
+	}
+	if want := "[0s 1s 2s 3s]"; fmt.Sprint(calls) != want {
+		t.Errorf("got calls %v, want %v", calls, want)
+	}
+	if want := "[boom]"; fmt.Sprint(panics) != want {
+		t.Errorf("got panics %v, want %v", panics, want)
+	}
+	tk.Stop()
+	fc.Advance(time.Hour)
+	if len(calls) != 4 {
+		t.Errorf("got calls %v after Stop", calls[4:])
+	}
+}
+
+func TestTickFuncSlow(t *testing.T) {
+	for _, tc := range []struct {
+		policy MissedTickPolicy
+		calls  string
+	}{
+		{DropMissedTicks, "[1s 4s]"},
+		{DeliverMissedTicks, "[1s 2s 3s 4s]"},
+	} {
+		fc := NewFakeClock(fakeStart)
+		release := make(chan struct{})
+		var mu sync.Mutex
+		var calls []time.Duration
+		running := 0
+		tk := fc.TickFunc(time.Second, func(now time.Time) {
+			mu.Lock()
+			calls = append(calls, now.Sub(fakeStart))
+			running++
+			if running > 1 {
+				t.Errorf("calls overlap")
+			}
+			first := len(calls) == 1
+			mu.Unlock()
+			if first {
+				<-release
+			}
+			mu.Lock()
+			running--
+			mu.Unlock()
+		}, WithMissedTicks(tc.policy))
+		// The ticks at 2s and 3s fall while the first call is blocked.
+		for i := 0; i < 3; i++ {
+			fc.Advance(time.Second)
+		}
+		close(release)
+		for calling := true; calling; {
+			time.Sleep(time.Millisecond)
+			fc.mutex.Lock()
+			calling = tk.calling
+			fc.mutex.Unlock()
+		}
+		fc.Advance(time.Second)
+		fc.Close() // Waits for the calls to return.
+		if fmt.Sprint(calls) != tc.calls {
+			t.Errorf("%v: got calls %v, want %v", tc.policy, calls, tc.calls)
+		}
+	}
+}
+
+func TestTickerTicks(t *testing.T) {
+	for _, tc := range []struct {
+		desc string
+		opts []TickerOption
+		seqs string
+	}{
+		// Advancing by 3s delivers the tick at 3s, and the ticks at 4s and 5s are dropped because
+		// the channel is full.
+		{"drop", nil, "[1 2 3 6]"},
+		{"deliver", []TickerOption{WithMissedTicks(DeliverMissedTicks)}, "[1 2 3 4 5 6]"},
+		{"drift-free", []TickerOption{WithDriftFree()}, "[1 2 3 6]"},
+		{"immediate", []TickerOption{WithImmediateFirstTick()}, "[1 2 3 4 7]"},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			fc := NewFakeClock(fakeStart)
+			defer fc.Close()
+			tk := fc.NewTicker(time.Second, tc.opts...)
+			defer tk.Stop()
+			var seqs []int64
+			recv := func() {
+				for {
+					select {
+					case tick := <-tk.Ticks():
+						if want := tk.t.period; tick.Time.Sub(fakeStart)%want != 0 {
+							t.Errorf("got tick at %v, want a multiple of %v", tick.Time, want)
+						}
+						seqs = append(seqs, tick.Seq)
+					case <-time.After(50 * time.Millisecond):
+						return
+					}
+				}
+			}
+			recv()
+			for _, d := range []time.Duration{time.Second, time.Second, 3 * time.Second, time.Second} {
+				fc.Advance(d)
+				recv()
+			}
+			if fmt.Sprint(seqs) != tc.seqs {
+				t.Errorf("got sequence numbers %v, want %v", seqs, tc.seqs)
+			}
+			// Reset restarts the sequence.
+			tk.Reset(time.Second)
+			fc.Advance(time.Second)
+			if tick := <-tk.Ticks(); tick.Seq != 1 {
+				t.Errorf("after Reset: got sequence number %d, want 1", tick.Seq)
+			}
+		})
+	}
+}
+
+func TestTickerTicksMovesPendingTick(t *testing.T) {
+	fc := NewFakeClock(fakeStart)
+	defer fc.Close()
+	tk := fc.NewTicker(time.Second)
+	defer tk.Stop()
+	fc.Advance(2 * time.Second)
+	if got := <-tk.C; !got.Equal(fakeStart.Add(time.Second)) {
+		t.Fatalf("got tick at %v, want %v", got, fakeStart.Add(time.Second))
+	}
+	fc.Advance(time.Second)
+	// The tick at 3s is waiting in C when Ticks is first called.
+	want := Tick{Time: fakeStart.Add(3 * time.Second), Seq: 3}
+	if got := <-tk.Ticks(); !got.Time.Equal(want.Time) || got.Seq != want.Seq {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
@@ -2,29 +2,187 @@
 package kairos
 
 import (
+	"context"
+	"sync/atomic"
 	"time"
 )
 
 // The Timer type represents a single event. When the Timer expires,
 // the current time will be sent on C, unless the Timer was created by AfterFunc.
-// A Timer must be created with NewTimer. NewStoppedTimer or AfterFunc.
+// A Timer must be created with NewTimer. NewStoppedTimer or AfterFunc,
+// or initialized in place with InitTimer.  A Timer must not be copied
+// after it is created.
 type Timer struct {
+	_ noCopy
+
 	C <-chan time.Time
 	c chan<- time.Time // Same channel as C.
 
-	i    int       // heap index.
-	when time.Time // Timer wakes up at when.
+	clk  *clock      // Clock that owns the timer.
+	id   uint64      // Number of the timer, unique in the process; see timerIDs.
+	f    func()      // Function to call instead of sending on c, for timers created by AfterFunc.
+	i    int         // heap index.
+	when time.Time   // Timer wakes up at when.
+	seq  uint64      // Start order, to break ties between equal values of when; see WithTieOrder.
+	rt   *time.Timer // Runtime timer, if the clock delegates to runtime timers.
+
+	// Deadline and start order by which the timer is ordered in the heap.  They are those of an
+	// earlier start if the timer was postponed in place; see clock.startTimer.
+	key    time.Time
+	keySeq uint64
+
+	period time.Duration // Interval between ticks, for timers backing a Ticker.
+	tk     *Ticker       // Ticker backed by this timer, if any.
+
+	label string   // protected by clk.mutex
+	prio  Priority // protected by clk.mutex
+
+	// Timer armed on behalf of this one, if this one is a handle returned by a clock with
+	// WithLeakDetection.  The handle holds no other state.
+	node  *Timer
+	stack []uintptr // Creation stack of the timer, if recorded.
+	// Stack of the last start or reset of the timer, with WithCreationSites.  Protected by
+	// clk.mutex.
+	resetStack []uintptr
+	// Time of the last start, reset, or expiration of the timer, with WithStaleTimers or
+	// WithDurationSampling, and whether it was reported as stale since.  Protected by clk.mutex.
+	armedAt       time.Time
+	staleReported bool
+
+	firing Firing      // protected by clk.mutex; last expiration, with WithFireLatency.
+	fc     chan Firing // protected by clk.mutex; channel returned by Firings, which replaces c.
+
+	// Cancels the contexts returned by Context, on the next expiration.  Protected by clk.mutex.
+	ctxs []context.CancelCauseFunc
+}
+
+// noCopy makes go vet report copies of the structures that contain it, such as a Timer embedded
+// in a copied struct.
+type noCopy struct{}
+
+func (*noCopy) Lock()   {}
+func (*noCopy) Unlock() {}
+
+// installed is the clock installed by SetClock, or nil if the real clock is in use.
+var installed atomic.Pointer[installedClock]
+
+// timerIDs is the last number given to a timer.
+var timerIDs atomic.Uint64
+
+type installedClock struct{ Clock }
+
+// defaultClock returns the clock used by the package-level functions.
+func defaultClock() Clock {
+	if ic := installed.Load(); ic != nil {
+		return ic.Clock
+	}
+	return realClock
+}
+
+// SetClock redirects the package-level functions such as [NewTimer] to c until restore is called,
+// typically to substitute a [FakeClock] in tests of code that uses the package-level functions.
+// Timers already created are unaffected.  Only one clock can be installed at a time: SetClock
+// panics if another clock is already installed, which catches tests that swap the clock
+// concurrently, and restore panics if called more than once.
+func SetClock(c Clock) (restore func()) {
+	ic := &installedClock{c}
+	if !installed.CompareAndSwap(nil, ic) {
+		panic("kairos: SetClock called while another clock is installed")
+	}
+	return func() {
+		if !installed.CompareAndSwap(ic, nil) {
+			panic("kairos: SetClock restore function called more than once")
+		}
+	}
 }
 
 // NewTimer creates a new Timer that will send the current time on its
 // channel after at least duration d.
 func NewTimer(d time.Duration) *Timer {
-	return realClock.NewTimer(d)
+	return defaultClock().NewTimer(d)
+}
+
+// NewTimerAt creates a new Timer that will send the current time on its channel when the time
+// reaches t.
+func NewTimerAt(t time.Time) *Timer {
+	return defaultClock().NewTimerAt(t)
 }
 
 // NewStoppedTimer creates a new stopped Timer.
 func NewStoppedTimer() *Timer {
-	return realClock.NewStoppedTimer()
+	return defaultClock().NewStoppedTimer()
+}
+
+// InitTimer initializes t, a zero Timer typically embedded in a larger structure, as a stopped
+// timer that calls f in its own goroutine when it fires, like a timer created by AfterFunc.  It lets
+// code that manages a deadline per connection or request keep the timer inside the structure it
+// times out, so that neither creating nor arming the timer allocates, and f can find the structure
+// without a map from timers.  Arm the timer with [Timer.Reset] or [Timer.ResetAt] and cancel it
+// with [Timer.Stop].  See [Clock.InitTimer].
+func InitTimer(t *Timer, f func()) {
+	defaultClock().InitTimer(t, f)
+}
+
+// AcquireTimer returns a Timer started with duration d, like NewTimer, but reuses a timer returned
+// by [ReleaseTimer] if one is available, so that workloads creating a timer per request do not
+// allocate one each time.
+func AcquireTimer(d time.Duration) *Timer {
+	return defaultClock().AcquireTimer(d)
+}
+
+// ReleaseTimer stops t and returns it to its clock for reuse by AcquireTimer.  Neither t nor its
+// channel may be used after ReleaseTimer is called, since they may be handed to another caller.
+// The contexts derived from t by [Timer.Context] are cancelled with cause [ErrTimerReleased].
+// ReleaseTimer panics if t was created by AfterFunc or InitTimer.
+func ReleaseTimer(t *Timer) {
+	n := t.impl()
+	if n.clk == nil {
+		panic("kairos: ReleaseTimer called on uninitialized Timer")
+	}
+	if n.f != nil {
+		panic("kairos: ReleaseTimer called on an AfterFunc timer")
+	}
+	n.Stop()
+	n.clk.mutex.Lock()
+	n.label = ""
+	n.prio = NormalPriority
+	n.firing = Firing{}
+	n.fc = nil
+	n.releaseContextsLocked()
+	n.clk.mutex.Unlock()
+	n.clk.pool.Put(t)
+}
+
+// AfterFunc waits for the duration to elapse and then calls f in its own goroutine.  It returns a
+// Timer that can be used to cancel the call using its Stop method.
+func AfterFunc(d time.Duration, f func()) *Timer {
+	return defaultClock().AfterFunc(d, f)
+}
+
+// After waits for the duration to elapse and then sends the current time on the returned channel.
+// It is equivalent to NewTimer(d).C.
+func After(d time.Duration) <-chan time.Time {
+	return defaultClock().After(d)
+}
+
+// Sleep pauses the current goroutine for at least the duration d.
+func Sleep(d time.Duration) {
+	defaultClock().Sleep(d)
+}
+
+// Now returns the current time.
+func Now() time.Time {
+	return defaultClock().Now()
+}
+
+// Since returns the time elapsed since t.
+func Since(t time.Time) time.Duration {
+	return defaultClock().Since(t)
+}
+
+// Until returns the duration until t.
+func Until(t time.Time) time.Duration {
+	return defaultClock().Until(t)
 }
 
 // Stop prevents the Timer from firing.
@@ -33,20 +191,93 @@ func NewStoppedTimer() *Timer {
 // Stop does not close the channel, to prevent a read from
 // the channel succeeding incorrectly.
 func (t *Timer) Stop() (wasActive bool) {
-	if t.c == nil {
+	t = t.impl()
+	if t.clk == nil {
 		panic("timer: Stop called on uninitialized Timer")
 	}
-	return realClock.delTimer(t)
+	return t.clk.delTimer(t)
 }
 
 // Reset changes the timer to expire after duration d.
 // It returns true if the timer had been active,
 // false if the timer had expired or been stopped.
 // The channel t.C is cleared and calling t.Reset() behaves as creating a
-// new Timer.
+// new Timer.  If the timer's clock has been closed, the timer stays stopped.
+// Neither Reset nor Stop allocates memory, unless the clock records to a
+// [Recorder].
 func (t *Timer) Reset(d time.Duration) bool {
-	if t.c == nil {
+	t = t.impl()
+	if t.clk == nil {
 		panic("timer: Reset called on uninitialized Timer")
 	}
-	return realClock.resetTimer(t, d)
+	active, _ := t.clk.resetTimer(t, d)
+	return active
+}
+
+// ResetAt changes the timer to expire when its clock reaches when, or right away if when is not
+// after the current time.  It is like Reset(clock.Until(when)), except that the deadline is
+// exactly when, however long the call takes, which suits deadlines given as times such as those of
+// [net.Conn.SetDeadline].  Like Reset, it does not allocate memory.
+func (t *Timer) ResetAt(when time.Time) bool {
+	t = t.impl()
+	if t.clk == nil {
+		panic("timer: ResetAt called on uninitialized Timer")
+	}
+	active, _ := t.clk.startTimerAt(t, when)
+	return active
+}
+
+// TryReset is like Reset, but also returns an error if the timer could not be started and stays
+// stopped: [ErrClosed] if its clock is closed, or [ErrTimerLimit] if the clock's limit of armed
+// timers is reached and its [LimitPolicy] is [RejectAtLimit].
+func (t *Timer) TryReset(d time.Duration) (bool, error) {
+	t = t.impl()
+	if t.clk == nil {
+		panic("timer: TryReset called on uninitialized Timer")
+	}
+	return t.clk.resetTimer(t, d)
+}
+
+// deadline returns the deadline of t when started at time now with duration d.
+func (t *Timer) deadline(now time.Time, d time.Duration) time.Time {
+	switch {
+	case t.tk == nil:
+		return now.Add(d)
+	case t.tk.aligned:
+		return nextBoundary(now, t.tk.epoch, t.period)
+	}
+	return now.Add(t.tk.jitter.interval(d))
+}
+
+// ID returns the number of the timer, unique among the timers of the process, which identifies it
+// in the diagnostics of the package, such as [TimerInfo], [AuditEntry], and the reports of
+// [WithLeakDetection], [WithRaceDiagnostics], and [WithLogger], so that they can be correlated.
+// Timers are numbered from 1 in the order they are created; a timer reused by [AcquireTimer] keeps
+// its number.  ID returns 0 for an uninitialized Timer.  Unlike the other methods of a timer, ID
+// may be called by an [Observer] or a [ClockListener].
+func (t *Timer) ID() uint64 {
+	return t.impl().id
+}
+
+// SetLabel attaches a descriptive label to the timer, such as the name of the operation it times
+// out.  Labels appear in introspection results such as [FakeClock.PendingTimers].
+func (t *Timer) SetLabel(label string) {
+	t = t.impl()
+	if t.clk == nil {
+		panic("timer: SetLabel called on uninitialized Timer")
+	}
+	t.clk.mutex.Lock()
+	defer t.clk.mutex.Unlock()
+	t.label = label
+}
+
+// Label returns the label set by [Timer.SetLabel], or the empty string if none.
+func (t *Timer) Label() string {
+	t = t.impl()
+	if t.clk == nil {
+		return ""
+	}
+	t.clk.mutex.Lock()
+	defer t.clk.mutex.Unlock()
+	return t.label
 }
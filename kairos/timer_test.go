@@ -19,8 +19,9 @@ import (
 // To accommodate these potential delays, this margin is added to the timer duration d to allow
 // times in the half-open range [d, d+margin).  (Timers should never fire early, and elapsed time
 // should never be negative, so the margin is not subtracted from the early side of the range, only
-// added to the late side.)
-const margin = 100 * time.Millisecond
+// added to the late side.)  The margin is scaled up on platforms whose hosts can stall the
+// program for longer; see marginScale.
+const margin = 100 * time.Millisecond * marginScale
 
 func TestNewTimer(t *testing.T) {
 	repeat := func(n, mod, offset int, d time.Duration) []time.Duration {
@@ -155,6 +156,56 @@ func TestStopPanic(t *testing.T) {
 	timer.Stop()
 }
 
+func TestAcquireTimer(t *testing.T) {
+	fc := NewFakeClock(fakeStart)
+	timer := fc.AcquireTimer(time.Second)
+	timer.SetLabel("request")
+	fc.Advance(time.Second)
+	// The timer is released with a value waiting in its channel.
+	ReleaseTimer(timer)
+	if n := fc.Pending(); n != 0 {
+		t.Errorf("got %d pending timers after ReleaseTimer, want 0", n)
+	}
+	for i := 0; i < 3; i++ {
+		timer = fc.AcquireTimer(time.Second)
+		if _, ok := recv(timer.C); ok {
+			t.Fatalf("acquired timer has a stale value in its channel")
+		}
+		if got := fc.PendingTimers()[0].Label; got != "" {
+			t.Errorf("acquired timer has label %q, want none", got)
+		}
+		fc.Advance(time.Second)
+		if got, ok := recv(timer.C); !ok || !got.Equal(fc.Now()) {
+			t.Errorf("acquired timer: got %v, %v, want %v", got, ok, fc.Now())
+		}
+		ReleaseTimer(timer)
+	}
+
+	defer func() {
+		if r := recover(); r != "kairos: ReleaseTimer called on an AfterFunc timer" {
+			t.Errorf("got panic %v", r)
+		}
+	}()
+	ReleaseTimer(fc.AfterFunc(time.Second, func() {}))
+}
+
+func TestReleaseTimerPanic(t *testing.T) {
+	defer func() {
+		if r := recover(); r != "kairos: ReleaseTimer called on uninitialized Timer" {
+			t.Errorf("got panic %v", r)
+		}
+	}()
+	ReleaseTimer(&Timer{})
+}
+
+func BenchmarkAcquireTimer(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		timer := AcquireTimer(time.Hour)
+		ReleaseTimer(timer)
+	}
+}
+
 func TestMultipleStop(t *testing.T) {
 	var timers []*Timer
 
@@ -267,6 +318,53 @@ func TestMultipleResets(t *testing.T) {
 	}
 }
 
+func TestResetPostpone(t *testing.T) {
+	// The time source only advances when told to, so drive the timer routine with a fake sleeper.
+	fc := NewFakeClock(fakeStart)
+	rec := NewRecorder()
+	c := NewClockFromFunc(fc.Now, WithSleeper(NewClockSleeper(fc)), WithRecorder(rec), WithClosePolicy(FireOnClose))
+	a := c.NewTimer(10 * time.Second)
+	b := c.NewTimer(20 * time.Second)
+	// a stays in the heap at 10s.
+	for i := 1; i <= 3; i++ {
+		a.Reset(time.Duration(i) * 10 * time.Second)
+	}
+	fc.Advance(10 * time.Second)
+	time.Sleep(10 * time.Millisecond)
+	if _, ok := recv(a.C); ok {
+		t.Fatalf("postponed timer fired at its old deadline")
+	}
+	fc.Advance(10 * time.Second)
+	if got, want := waitFired(t, b), fakeStart.Add(20*time.Second); !got.Equal(want) {
+		t.Errorf("other timer: got fire time %v, want %v", got, want)
+	}
+	if _, ok := recv(a.C); ok {
+		t.Fatalf("postponed timer fired before its new deadline")
+	}
+	fc.Advance(10 * time.Second)
+	if got, want := waitFired(t, a), fakeStart.Add(30*time.Second); !got.Equal(want) {
+		t.Errorf("postponed timer: got fire time %v, want %v", got, want)
+	}
+	if a.Stop() {
+		t.Errorf("Stop after firing: got true, want false")
+	}
+
+	// A postponed timer fires on close after the timers with earlier deadlines.
+	x := c.NewTimer(10 * time.Second)
+	c.NewTimer(20 * time.Second)
+	x.Reset(30 * time.Second)
+	c.Close()
+	var fired []uint64
+	for _, e := range rec.Trace() {
+		if e.Op == OpFire {
+			fired = append(fired, e.Timer)
+		}
+	}
+	if want := "[2 1 4 3]"; fmt.Sprint(fired) != want {
+		t.Errorf("got timers fired in order %v, want %v", fired, want)
+	}
+}
+
 func TestResetChannelClear(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	t.Cleanup(cancel)
@@ -349,6 +447,7 @@ func BenchmarkTimerResetRapidFire(b *testing.B) {
 		b.Run(fmt.Sprintf("pre-filled %v", n), func(b *testing.B) {
 			prefillTimers(b, n)
 			timer := NewStoppedTimer()
+			b.ReportAllocs()
 			b.ResetTimer()
 			for i := 0; i < b.N; i++ {
 				timer.Reset(0)
@@ -357,3 +456,185 @@ func BenchmarkTimerResetRapidFire(b *testing.B) {
 		})
 	}
 }
+
+func BenchmarkTimerResetLater(b *testing.B) {
+	for _, n := range []int{0, 1e4} {
+		b.Run(fmt.Sprintf("pre-filled %v", n), func(b *testing.B) {
+			prefillTimers(b, n)
+			timer := NewTimer(time.Hour)
+			defer timer.Stop()
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				timer.Reset(time.Hour)
+			}
+		})
+	}
+}
+
+func BenchmarkTimerStopReset(b *testing.B) {
+	b.ReportAllocs()
+	timer := NewTimer(time.Hour)
+	defer timer.Stop()
+	for i := 0; i < b.N; i++ {
+		timer.Stop()
+		timer.Reset(time.Hour)
+	}
+}
+
+func TestResetStopAllocs(t *testing.T) {
+	clocks := []struct {
+		name string
+		c    Clock
+	}{
+		{"real", NewClock()},
+		{"runtime timers", NewClock(WithRuntimeTimers())},
+		{"sharded", NewClock(WithShards(2))},
+		{"fake", NewFakeClock(fakeStart)},
+		{"deterministic fake", NewFakeClock(fakeStart, WithDeterministicDispatch())},
+	}
+	for _, tc := range clocks {
+		t.Run(tc.name, func(t *testing.T) {
+			defer tc.c.Close()
+			timer := tc.c.NewTimer(time.Hour)
+			f := tc.c.AfterFunc(time.Hour, func() {})
+			tk := tc.c.NewTicker(time.Hour)
+			var conn struct {
+				id       int
+				deadline Timer
+			}
+			tc.c.InitTimer(&conn.deadline, func() { conn.id++ })
+			var later time.Duration
+			ops := []struct {
+				name string
+				op   func()
+			}{
+				{"Reset", func() { timer.Reset(time.Hour) }},
+				{"Reset later", func() { later++; timer.Reset(time.Hour + later) }},
+				{"Stop and Reset", func() { timer.Stop(); timer.Reset(time.Hour) }},
+				{"Reset expired", func() { timer.Reset(0); <-timer.C }},
+				{"AfterFunc Stop and Reset", func() { f.Stop(); f.Reset(time.Hour) }},
+				{"Ticker Stop and Reset", func() { tk.Stop(); tk.Reset(time.Hour) }},
+				{"ResetAt", func() { timer.ResetAt(tc.c.Now().Add(time.Hour)) }},
+				{"embedded Stop and ResetAt", func() { conn.deadline.Stop(); conn.deadline.ResetAt(tc.c.Now().Add(time.Hour)) }},
+			}
+			for _, op := range ops {
+				if got := testing.AllocsPerRun(100, op.op); got != 0 {
+					t.Errorf("%s: got %v allocations, want 0", op.name, got)
+				}
+			}
+		})
+	}
+}
+
+func TestInitTimer(t *testing.T) {
+	fc := NewFakeClock(fakeStart, WithDeterministicDispatch())
+	defer fc.Close()
+	type conn struct {
+		timedOut bool
+		deadline Timer
+	}
+	conns := make([]conn, 3)
+	for i := range conns {
+		c := &conns[i]
+		fc.InitTimer(&c.deadline, func() { c.timedOut = true })
+		if c.deadline.Stop() {
+			t.Errorf("conn %d: Stop of a new timer: got true, want false", i)
+		}
+		c.deadline.ResetAt(fakeStart.Add(time.Duration(i+1) * time.Second))
+	}
+	if !conns[1].deadline.Stop() {
+		t.Errorf("Stop of an armed timer: got false, want true")
+	}
+	fc.Advance(time.Hour)
+	for i, want := range []bool{true, false, true} {
+		if got := conns[i].timedOut; got != want {
+			t.Errorf("conn %d: got timed out %v, want %v", i, got, want)
+		}
+	}
+
+	for _, tc := range []struct {
+		desc string
+		f    func()
+	}{
+		{"initialized", func() { fc.InitTimer(&conns[0].deadline, func() {}) }},
+		{"nil function", func() { fc.InitTimer(new(Timer), nil) }},
+		{"released", func() { ReleaseTimer(&conns[0].deadline) }},
+		{"ResetAt uninitialized", func() { new(Timer).ResetAt(fakeStart) }},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("got no panic, want one")
+				}
+			}()
+			tc.f()
+		})
+	}
+}
+
+func TestAfterFunc(t *testing.T) {
+	const want = 100 * time.Millisecond
+	start := time.Now()
+	called := make(chan time.Duration, 1)
+	timer := AfterFunc(want, func() { called <- time.Since(start) })
+	if timer.C != nil {
+		t.Errorf("AfterFunc timer has a channel")
+	}
+	select {
+	case got := <-called:
+		if got < want || got >= want+margin {
+			t.Errorf("callback ran at wrong time; got duration %v, want %v", got, want)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("callback did not run")
+	}
+	if timer.Stop() {
+		t.Errorf("stop fired AfterFunc timer: was active is true")
+	}
+	if timer.Reset(time.Hour) {
+		t.Errorf("reset fired AfterFunc timer: was active is true")
+	}
+	if !timer.Stop() {
+		t.Errorf("stop reset AfterFunc timer: was active is false")
+	}
+}
+
+func TestShutdownWaitsForCallbacks(t *testing.T) {
+	c := NewClock()
+	release := make(chan struct{})
+	started := make(chan struct{})
+	c.AfterFunc(0, func() {
+		close(started)
+		<-release
+	})
+	<-started
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	t.Cleanup(cancel)
+	if err := c.Shutdown(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Shutdown with running callback: got error %v, want %v", err, context.DeadlineExceeded)
+	}
+	close(release)
+}
+
+func TestTimerID(t *testing.T) {
+	fc := NewFakeClock(fakeStart)
+	defer fc.Close()
+	var zero Timer
+	if got := zero.ID(); got != 0 {
+		t.Errorf("ID of an uninitialized timer: got %d, want 0", got)
+	}
+	a, b := fc.NewTimer(time.Second), fc.AfterFunc(time.Second, func() {})
+	tk := fc.NewTicker(time.Second)
+	defer tk.Stop()
+	if a.ID() == 0 || b.ID() != a.ID()+1 || tk.ID() != b.ID()+1 {
+		t.Errorf("got IDs %d, %d, %d, want consecutive numbers", a.ID(), b.ID(), tk.ID())
+	}
+	// A handle has the number of the timer behind it.
+	c := NewClock(WithLeakDetection(false, func(string) {}))
+	defer c.Close()
+	h := c.NewStoppedTimer()
+	if h.ID() == 0 || h.ID() != h.impl().id {
+		t.Errorf("got ID %d of a handle, want %d", h.ID(), h.impl().id)
+	}
+}
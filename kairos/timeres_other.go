@@ -0,0 +1,7 @@
+//go:build !windows
+
+package kairos
+
+// beginHighResolution does nothing: the system timers of other platforms have a high resolution
+// already.
+func beginHighResolution() (end func()) { return func() {} }
@@ -0,0 +1,22 @@
+package kairos
+
+import "syscall"
+
+var (
+	winmm           = syscall.NewLazyDLL("winmm.dll")
+	timeBeginPeriod = winmm.NewProc("timeBeginPeriod")
+	timeEndPeriod   = winmm.NewProc("timeEndPeriod")
+)
+
+// beginHighResolution raises the resolution of the system timer to one millisecond, and returns a
+// function that restores it.  Windows keeps the finest resolution requested by any process, so
+// the requests of several clocks nest.
+func beginHighResolution() (end func()) {
+	if timeBeginPeriod.Find() != nil {
+		return func() {} // No multimedia timer API, as on Nano Server.
+	}
+	if r, _, _ := timeBeginPeriod.Call(1); r != 0 {
+		return func() {} // TIMERR_NOCANDO: the resolution is left unchanged.
+	}
+	return func() { timeEndPeriod.Call(1) }
+}
@@ -0,0 +1,171 @@
+//go:build linux
+
+package kairos
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// Constants of the Linux timerfd API, which the syscall package does not define.
+const (
+	clockRealtime        = 0
+	clockMonotonic       = 1
+	tfdTimerAbstime      = 1 << 0
+	tfdTimerCancelOnSet  = 1 << 1
+	timerfdCreateFlags   = syscall.O_NONBLOCK | syscall.O_CLOEXEC
+	timerfdExpirationLen = 8 // A read returns the number of expirations as a uint64.
+)
+
+// A TimerfdSleeper is a [Sleeper] backed by a Linux timerfd, for use with [WithSleeper].  The
+// kernel wakes the timer routine directly through the runtime's network poller, without going
+// through the runtime's timers, so wakeups are as precise as the kernel's high-resolution timers
+// allow.
+//
+// A sleeper created by [NewRealtimeTimerfdSleeper] arms the timerfd with absolute CLOCK_REALTIME
+// deadlines and asks the kernel to cancel them when the system clock is set, so it also reports
+// the changes of the wall time on Changes as they happen, without the polling of a [ClockWatcher].
+//
+// A TimerfdSleeper holds a file descriptor and a goroutine until it is closed.  Close it after
+// closing the clock that uses it.
+type TimerfdSleeper struct {
+	f        *os.File
+	realtime bool
+	c        chan time.Time
+	changes  chan ClockChange
+	doneC    chan struct{}
+
+	mutex sync.Mutex
+	// armed is the time at which the timerfd was last armed, used to compute the step of a change.
+	// It is only used by realtime sleepers.
+	armed time.Time
+}
+
+// NewTimerfdSleeper returns a [TimerfdSleeper] that measures delays with CLOCK_MONOTONIC, like the
+// runtime's timers.
+func NewTimerfdSleeper() (*TimerfdSleeper, error) {
+	return newTimerfdSleeper(clockMonotonic)
+}
+
+// NewRealtimeTimerfdSleeper returns a [TimerfdSleeper] that arms its deadlines on CLOCK_REALTIME
+// and reports the changes of the system clock.  When the system clock is set, a pending wakeup is
+// delivered early, so that the timer routine re-reads the time, and the change is sent on Changes.
+func NewRealtimeTimerfdSleeper() (*TimerfdSleeper, error) {
+	return newTimerfdSleeper(clockRealtime)
+}
+
+func newTimerfdSleeper(clockID int) (*TimerfdSleeper, error) {
+	fd, _, errno := syscall.Syscall(syscall.SYS_TIMERFD_CREATE, uintptr(clockID),
+		timerfdCreateFlags, 0)
+	if errno != 0 {
+		return nil, fmt.Errorf("kairos: timerfd_create: %w", errno)
+	}
+	// The descriptor is non-blocking, so os.NewFile registers it with the runtime's poller and
+	// reads park the goroutine rather than a thread.
+	s := &TimerfdSleeper{
+		f:        os.NewFile(fd, "timerfd"),
+		realtime: clockID == clockRealtime,
+		c:        make(chan time.Time, 1),
+		changes:  make(chan ClockChange, 1),
+		doneC:    make(chan struct{}),
+	}
+	go s.read()
+	return s, nil
+}
+
+// C returns the channel on which wakeups are delivered.
+func (s *TimerfdSleeper) C() <-chan time.Time { return s.c }
+
+// Changes returns the channel on which the changes of the system clock are delivered.  Only a
+// sleeper created by [NewRealtimeTimerfdSleeper] that has a pending wakeup when the clock is set
+// reports changes, and a change is dropped if the receiver has not taken the previous one yet.
+func (s *TimerfdSleeper) Changes() <-chan ClockChange { return s.changes }
+
+// Reset discards any pending wakeup and arranges for a wakeup after d.
+func (s *TimerfdSleeper) Reset(d time.Duration) {
+	s.drain()
+	if d <= 0 {
+		d = 1 // A zero it_value disarms the timerfd.
+	}
+	if !s.realtime {
+		s.settime(0, syscall.NsecToTimespec(int64(d)))
+		return
+	}
+	now := time.Now()
+	s.mutex.Lock()
+	s.armed = now
+	s.mutex.Unlock()
+	// Round(0) strips the monotonic clock reading, so that the deadline is a wall time.
+	deadline := now.Round(0).Add(d)
+	s.settime(tfdTimerAbstime|tfdTimerCancelOnSet, syscall.NsecToTimespec(deadline.UnixNano()))
+}
+
+// Stop cancels any pending wakeup.
+func (s *TimerfdSleeper) Stop() {
+	s.settime(0, syscall.Timespec{})
+	s.drain()
+}
+
+// Close releases the timerfd and stops the goroutine reading it.
+func (s *TimerfdSleeper) Close() error {
+	err := s.f.Close()
+	<-s.doneC
+	return err
+}
+
+func (s *TimerfdSleeper) drain() {
+	select {
+	case <-s.c:
+	default:
+	}
+}
+
+// settime arms the timerfd with value, or disarms it if value is zero.
+func (s *TimerfdSleeper) settime(flags int, value syscall.Timespec) {
+	spec := struct{ interval, value syscall.Timespec }{value: value}
+	rc, err := s.f.SyscallConn()
+	if err != nil {
+		return // Closed.
+	}
+	rc.Control(func(fd uintptr) {
+		_, _, errno := syscall.Syscall6(syscall.SYS_TIMERFD_SETTIME, fd, uintptr(flags),
+			uintptr(unsafe.Pointer(&spec)), 0, 0, 0)
+		if errno != 0 {
+			// The arguments are always valid, so this is a bug.
+			panic(fmt.Sprintf("kairos: timerfd_settime: %v", errno))
+		}
+	})
+}
+
+// read delivers the expirations of the timerfd on C until the sleeper is closed.
+func (s *TimerfdSleeper) read() {
+	defer close(s.doneC)
+	var buf [timerfdExpirationLen]byte
+	for {
+		_, err := s.f.Read(buf[:])
+		switch {
+		case err == nil:
+		case errors.Is(err, syscall.ECANCELED):
+			// The system clock was set while the timerfd was armed.
+			now := time.Now()
+			s.mutex.Lock()
+			step := now.Round(0).Sub(s.armed.Round(0)) - now.Sub(s.armed)
+			s.mutex.Unlock()
+			select {
+			case s.changes <- ClockChange{Time: now, Step: step}:
+			default:
+			}
+		default:
+			return // Closed.
+		}
+		select {
+		case s.c <- time.Now():
+		default:
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package kairos
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimerfdSleeper(t *testing.T) {
+	for _, tc := range []struct {
+		desc string
+		new  func() (*TimerfdSleeper, error)
+	}{
+		{"monotonic", NewTimerfdSleeper},
+		{"realtime", NewRealtimeTimerfdSleeper},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			s, err := tc.new()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer s.Close()
+			start := time.Now()
+			s.Reset(20 * time.Millisecond)
+			if got := (<-s.C()).Sub(start); got < 20*time.Millisecond || got >= 20*time.Millisecond+margin {
+				t.Errorf("woke after %v, want 20ms", got)
+			}
+			s.Reset(10 * time.Millisecond)
+			s.Stop()
+			if _, ok := recv(s.C()); ok {
+				t.Errorf("stopped sleeper woke")
+			}
+
+			c := NewClock(WithSleeper(s))
+			defer c.Close()
+			start = time.Now()
+			timer := c.NewTimer(30 * time.Millisecond)
+			got := waitFired(t, timer).Sub(start)
+			if got < 30*time.Millisecond || got >= 30*time.Millisecond+margin {
+				t.Errorf("timer fired after %v, want 30ms", got)
+			}
+		})
+	}
+}
+
+func TestTimerfdSleeperClose(t *testing.T) {
+	s, err := NewTimerfdSleeper()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Reset(time.Hour)
+	if err := s.Close(); err != nil {
+		t.Errorf("Close: %v", err)
+	}
+	// Stopping a closed sleeper, as the timer routine of a closed clock may do, is harmless.
+	s.Stop()
+}
@@ -1,12 +1,47 @@
 package kairos
 
+import (
+	"time"
+	"unsafe"
+)
+
 // A timerHeap is a binary heap containing all running Timers, ordered by their expiration times.
-type timerHeap []*Timer
+// Timers with equal expiration times are ordered by their sequence numbers, which are assigned in
+// the order the timers were started, or its reverse; see clock.nextSeqLocked.  A timer is ordered
+// by the expiration time and sequence number it had when it was inserted, which the clock's timer
+// routine refreshes if the timer was postponed since.
+type timerHeap struct {
+	ts []*Timer
+	timerCounts
+}
+
+// timerCounts counts timers by kind.
+type timerCounts struct {
+	chans   int // Number of timers with a channel.
+	tickers int // Number of timers backing a Ticker.
+}
+
+// add adds n to the count of the kind of t.
+func (c *timerCounts) add(t *Timer, n int) {
+	if t.c != nil {
+		c.chans += n
+	}
+	if t.tk != nil {
+		c.tickers += n
+	}
+}
+
+// before reports whether timer a is ordered before timer b.
+func before(a, b *Timer) bool {
+	return a.key.Before(b.key) || (a.key.Equal(b.key) && a.keySeq < b.keySeq)
+}
 
-func (h timerHeap) Peek() *Timer { return h.idx(0) }
+func (h *timerHeap) Peek() *Timer { return h.idx(0) }
 func (h *timerHeap) Insert(t *Timer) {
+	t.key, t.keySeq = t.when, t.seq
 	t.i = h.Len()
-	*h = append(*h, t)
+	h.ts = append(h.ts, t)
+	h.add(t, 1)
 	h.siftUp(t.i)
 }
 
@@ -19,11 +54,12 @@ func (h *timerHeap) Remove(t *Timer) bool {
 	i := t.i
 	last := h.Len() - 1
 	if i != last {
-		(*h)[i] = (*h)[last]
-		(*h)[i].i = i
+		h.ts[i] = h.ts[last]
+		h.ts[i].i = i
 	}
-	(*h)[last] = nil
-	*h = (*h)[:last]
+	h.ts[last] = nil
+	h.ts = h.ts[:last]
+	h.add(t, -1)
 	if i != last {
 		h.siftUp(i)
 		h.siftDown(i)
@@ -32,69 +68,77 @@ func (h *timerHeap) Remove(t *Timer) bool {
 	return true
 }
 
-func (h timerHeap) idx(i int) *Timer {
+func (h *timerHeap) idx(i int) *Timer {
 	if i < 0 || i >= h.Len() {
 		return nil
 	}
-	return h[i]
+	return h.ts[i]
 }
 
-func (h timerHeap) Len() int { return len(h) }
+func (h *timerHeap) Len() int                       { return len(h.ts) }
+func (h *timerHeap) Has(t *Timer) bool              { return h.idx(t.i) == t }
+func (h *timerHeap) Due(t *Timer) time.Time         { return t.when }
+func (h *timerHeap) Counts() timerCounts            { return h.timerCounts }
+func (h *timerHeap) AppendTo(dst []*Timer) []*Timer { return append(dst, h.ts...) }
+
+func (h *timerHeap) Size() (capacity int, bytes int64) {
+	return cap(h.ts), int64(cap(h.ts)) * int64(unsafe.Sizeof((*Timer)(nil)))
+}
 
 // Heap maintenance algorithms.
 // Based on golang source /runtime/time.go
 
-func (h timerHeap) siftUp(i int) {
-	tmp := h[i]
-	when := tmp.when
+func (h *timerHeap) siftUp(i int) {
+	ts := h.ts
+	tmp := ts[i]
 
 	var p int
 	for i > 0 {
 		p = (i - 1) / 4 // parent
-		if !when.Before(h[p].when) {
+		if !before(tmp, ts[p]) {
 			break
 		}
-		h[i] = h[p]
-		h[i].i = i
-		h[p] = tmp
-		h[p].i = p
+		ts[i] = ts[p]
+		ts[i].i = i
+		ts[p] = tmp
+		ts[p].i = p
 		i = p
 	}
 }
 
-func (h timerHeap) siftDown(i int) {
-	n := h.Len()
-	when := h[i].when
-	tmp := h[i]
+func (h *timerHeap) siftDown(i int) {
+	ts := h.ts
+	n := len(ts)
+	tmp := ts[i]
 	for {
 		c := i*4 + 1 // left child
 		c3 := c + 2  // mid child
 		if c >= n {
 			break
 		}
-		w := h[c].when
-		if c+1 < n && h[c+1].when.Before(w) {
-			w = h[c+1].when
+		w := ts[c]
+		if c+1 < n && before(ts[c+1], w) {
+			w = ts[c+1]
 			c++
 		}
 		if c3 < n {
-			w3 := h[c3].when
-			if c3+1 < n && h[c3+1].when.Before(w3) {
-				w3 = h[c3+1].when
+			w3 := ts[c3]
+			if c3+1 < n && before(ts[c3+1], w3) {
+				w3 = ts[c3+1]
 				c3++
 			}
-			if w3.Before(w) {
+			if before(w3, w) {
 				w = w3
 				c = c3
 			}
 		}
-		if !w.Before(when) {
+		if !before(w, tmp) {
 			break
 		}
-		h[i] = h[c]
-		h[i].i = i
-		h[c] = tmp
-		h[c].i = c
+		ts[i] = ts[c]
+		ts[i].i = i
+		ts[c] = tmp
+		ts[c].i = c
 		i = c
 	}
 }
@@ -0,0 +1,134 @@
+package kairos
+
+import (
+	"slices"
+	"time"
+)
+
+// A NonexistentPolicy determines when a [WallTime] occurs on a day on which its local time does
+// not exist, because the clocks are set forward over it at the start of daylight saving time.
+type NonexistentPolicy int
+
+const (
+	// ShiftNonexistent moves the time forward by the length of the gap, like [time.Date]: 02:30
+	// becomes 03:30 when the clocks go from 02:00 to 03:00.
+	ShiftNonexistent NonexistentPolicy = iota
+	// TransitionNonexistent moves the time to the end of the gap, the first instant after the
+	// transition: 02:30 becomes 03:00 when the clocks go from 02:00 to 03:00.
+	TransitionNonexistent
+	// SkipNonexistent skips the day.
+	SkipNonexistent
+)
+
+// An AmbiguousPolicy determines when a [WallTime] occurs on a day on which its local time exists
+// twice, because the clocks are set back over it at the end of daylight saving time.
+type AmbiguousPolicy int
+
+const (
+	// FirstAmbiguous picks the first instant, before the transition.
+	FirstAmbiguous AmbiguousPolicy = iota
+	// SecondAmbiguous picks the second instant, after the transition.
+	SecondAmbiguous
+	// BothAmbiguous picks both instants, so the time occurs twice that day.
+	BothAmbiguous
+)
+
+// A WallTime is a local time of day in a time zone, such as 02:30 in Europe/Berlin.  Its Next
+// method computes the instant at which it next occurs, so a timer armed for that instant fires at
+// the intended local time even across daylight saving time transitions, unlike a timer armed for a
+// fixed 24 hours.  The Nonexistent and Ambiguous policies say what happens on the days on which
+// the local time does not exist or exists twice.  A WallTime implements the Schedule interface of
+// the cron package.
+type WallTime struct {
+	Hour, Minute, Second int
+	// Location is the time zone of the local time.  Nil means [time.Local].
+	Location    *time.Location
+	Nonexistent NonexistentPolicy
+	Ambiguous   AmbiguousPolicy
+}
+
+func (w WallTime) location() *time.Location {
+	if w.Location == nil {
+		return time.Local
+	}
+	return w.Location
+}
+
+// Next returns the first instant strictly after t at which w occurs.  It panics if the hour,
+// minute, or second of w is out of range.
+func (w WallTime) Next(t time.Time) time.Time {
+	if w.Hour < 0 || w.Hour > 23 || w.Minute < 0 || w.Minute > 59 || w.Second < 0 || w.Second > 59 {
+		panic("kairos: WallTime out of range")
+	}
+	lt := t.In(w.location())
+	// SkipNonexistent skips at most one day, so one of the next three days has an occurrence.
+	for i := 0; ; i++ {
+		for _, o := range w.on(lt.Year(), lt.Month(), lt.Day()+i) {
+			if o.After(t) {
+				return o
+			}
+		}
+	}
+}
+
+// on returns the instants, in order, at which w occurs on the given day, which is normalized like
+// [time.Date] does.
+func (w WallTime) on(year int, month time.Month, day int) []time.Time {
+	loc := w.location()
+	// The wall clock time read as UTC.  Subtracting the zone offset in effect gives the instant.
+	wall := time.Date(year, month, day, w.Hour, w.Minute, w.Second, 0, time.UTC)
+	_, before := wall.Add(-24 * time.Hour).In(loc).Zone()
+	_, after := wall.Add(24 * time.Hour).In(loc).Zone()
+	var ts []time.Time
+	for _, off := range []int{before, after} {
+		t := wall.Add(-time.Duration(off) * time.Second).In(loc)
+		if sameWall(t, wall) && !slices.ContainsFunc(ts, t.Equal) {
+			ts = append(ts, t)
+		}
+	}
+	slices.SortFunc(ts, func(a, b time.Time) int { return a.Compare(b) })
+	switch {
+	case len(ts) == 0:
+		// Reading the wall clock time with the offset before the gap puts it after the gap,
+		// shifted forward by the length of the gap.
+		t := wall.Add(-time.Duration(before) * time.Second).In(loc)
+		switch w.Nonexistent {
+		case TransitionNonexistent:
+			start, _ := t.ZoneBounds()
+			return []time.Time{start}
+		case SkipNonexistent:
+			return nil
+		}
+		return []time.Time{t}
+	case len(ts) == 2 && w.Ambiguous == FirstAmbiguous:
+		return ts[:1]
+	case len(ts) == 2 && w.Ambiguous == SecondAmbiguous:
+		return ts[1:]
+	}
+	return ts
+}
+
+// sameWall reports whether a and b read the same on a wall clock, each in its own time zone.
+func sameWall(a, b time.Time) bool {
+	ay, am, ad := a.Date()
+	by, bm, bd := b.Date()
+	return ay == by && am == bm && ad == bd && a.Hour() == b.Hour() && a.Minute() == b.Minute() &&
+		a.Second() == b.Second()
+}
+
+// NextClockTime returns the first instant strictly after now at which the local time in loc is
+// hour:minute, resolving daylight saving time transitions with the default policies of
+// [WallTime].  Use [NewTimerAt] to arm a timer for it.
+func NextClockTime(now time.Time, hour, minute int, loc *time.Location) time.Time {
+	return WallTime{Hour: hour, Minute: minute, Location: loc}.Next(now)
+}
+
+// NextWeekday returns the start of the first day strictly after the day of now, in loc, that is
+// a wd: midnight, or the first instant of the day if midnight does not exist because of a daylight
+// saving time transition.
+func NextWeekday(now time.Time, wd time.Weekday, loc *time.Location) time.Time {
+	lt := now.In(loc)
+	days := (int(wd)-int(lt.Weekday())+6)%7 + 1
+	midnight := WallTime{Location: loc, Nonexistent: TransitionNonexistent}
+	return midnight.on(lt.Year(), lt.Month(), lt.Day()+days)[0]
+}
@@ -0,0 +1,139 @@
+package kairos
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWallTimeNext(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("time zone database unavailable: %v", err)
+	}
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("time zone database unavailable: %v", err)
+	}
+	at0230 := WallTime{Hour: 2, Minute: 30, Location: berlin}
+	with := func(w WallTime, n NonexistentPolicy, a AmbiguousPolicy) WallTime {
+		w.Nonexistent, w.Ambiguous = n, a
+		return w
+	}
+	for _, tc := range []struct {
+		name string
+		w    WallTime
+		from string
+		want []string // Successive occurrences, in UTC.
+	}{
+		{"plain", WallTime{Hour: 9, Location: berlin}, "2023-05-17T07:00:00Z", []string{"2023-05-18T07:00:00Z", "2023-05-19T07:00:00Z"}},
+		{"same day", WallTime{Hour: 9, Minute: 15, Second: 30, Location: berlin}, "2023-05-17T06:00:00Z", []string{"2023-05-17T07:15:30Z"}},
+		{"utc", WallTime{Hour: 23, Location: time.UTC}, "2023-12-31T23:30:00Z", []string{"2024-01-01T23:00:00Z"}},
+		// The clocks go from 02:00 to 03:00 on 2023-03-26 and from 03:00 to 02:00 on 2023-10-29.
+		{"nonexistent shift", at0230, "2023-03-25T12:00:00Z", []string{"2023-03-26T01:30:00Z", "2023-03-27T00:30:00Z"}},
+		{"nonexistent transition", with(at0230, TransitionNonexistent, FirstAmbiguous), "2023-03-25T12:00:00Z", []string{"2023-03-26T01:00:00Z", "2023-03-27T00:30:00Z"}},
+		{"nonexistent skip", with(at0230, SkipNonexistent, FirstAmbiguous), "2023-03-25T12:00:00Z", []string{"2023-03-27T00:30:00Z"}},
+		{"ambiguous first", at0230, "2023-10-28T12:00:00Z", []string{"2023-10-29T00:30:00Z", "2023-10-30T01:30:00Z"}},
+		{"ambiguous second", with(at0230, ShiftNonexistent, SecondAmbiguous), "2023-10-28T12:00:00Z", []string{"2023-10-29T01:30:00Z", "2023-10-30T01:30:00Z"}},
+		{"ambiguous both", with(at0230, ShiftNonexistent, BothAmbiguous), "2023-10-28T12:00:00Z", []string{"2023-10-29T00:30:00Z", "2023-10-29T01:30:00Z", "2023-10-30T01:30:00Z"}},
+		// The clocks go from 02:00 to 03:00 on 2023-03-12 in New York.
+		{"west of utc", WallTime{Hour: 2, Minute: 30, Location: newYork}, "2023-03-11T12:00:00Z", []string{"2023-03-12T07:30:00Z", "2023-03-13T06:30:00Z"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			from, _ := time.Parse(time.RFC3339, tc.from)
+			var got []string
+			for next := tc.w.Next(from); len(got) < len(tc.want); next = tc.w.Next(next) {
+				got = append(got, next.UTC().Format(time.RFC3339))
+			}
+			if strings.Join(got, " ") != strings.Join(tc.want, " ") {
+				t.Errorf("got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestWallTimeTimer(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("time zone database unavailable: %v", err)
+	}
+	// 12:00 in Berlin on the day before daylight saving time starts.
+	start := time.Date(2023, 3, 25, 11, 0, 0, 0, time.UTC)
+	fc := NewFakeClock(start)
+	defer fc.Close()
+	w := WallTime{Hour: 12, Location: berlin}
+	// A day is 23 hours long here, so a timer for 24 hours would fire at 13:00.
+	timer := fc.NewTimerAt(w.Next(fc.Now()))
+	fc.Advance(23 * time.Hour)
+	select {
+	case got := <-timer.C:
+		if want := time.Date(2023, 3, 26, 12, 0, 0, 0, berlin); !got.Equal(want) {
+			t.Errorf("got tick at %v, want %v", got, want)
+		}
+	default:
+		t.Errorf("timer did not fire at 12:00 local time")
+	}
+}
+
+func TestNextClockTime(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("time zone database unavailable: %v", err)
+	}
+	for _, tc := range []struct {
+		now          string
+		hour, minute int
+		want         string
+	}{
+		{"2023-05-17T06:59:00Z", 9, 0, "2023-05-17T07:00:00Z"},
+		{"2023-05-17T07:00:00Z", 9, 0, "2023-05-18T07:00:00Z"},
+		// 00:30 on 2023-05-18 in Berlin.
+		{"2023-05-17T22:30:00Z", 0, 15, "2023-05-18T22:15:00Z"},
+		{"2023-03-25T12:00:00Z", 2, 30, "2023-03-26T01:30:00Z"},
+	} {
+		now, _ := time.Parse(time.RFC3339, tc.now)
+		got := NextClockTime(now, tc.hour, tc.minute, berlin).UTC().Format(time.RFC3339)
+		if got != tc.want {
+			t.Errorf("NextClockTime(%s, %d, %d): got %s, want %s", tc.now, tc.hour, tc.minute, got, tc.want)
+		}
+	}
+}
+
+func TestNextWeekday(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("time zone database unavailable: %v", err)
+	}
+	for _, tc := range []struct {
+		now  string
+		wd   time.Weekday
+		want string
+	}{
+		// 2023-05-17 is a Wednesday.
+		{"2023-05-17T12:00:00Z", time.Thursday, "2023-05-17T22:00:00Z"},
+		{"2023-05-17T12:00:00Z", time.Monday, "2023-05-21T22:00:00Z"},
+		{"2023-05-17T12:00:00Z", time.Wednesday, "2023-05-23T22:00:00Z"},
+		// Already Thursday in Berlin.
+		{"2023-05-17T22:30:00Z", time.Thursday, "2023-05-24T22:00:00Z"},
+		// Across the start of daylight saving time.
+		{"2023-03-24T12:00:00Z", time.Monday, "2023-03-26T22:00:00Z"},
+	} {
+		now, _ := time.Parse(time.RFC3339, tc.now)
+		if got := NextWeekday(now, tc.wd, berlin).UTC().Format(time.RFC3339); got != tc.want {
+			t.Errorf("NextWeekday(%s, %v): got %s, want %s", tc.now, tc.wd, got, tc.want)
+		}
+	}
+}
+
+func TestWallTimeOutOfRange(t *testing.T) {
+	for _, w := range []WallTime{{Hour: 24}, {Minute: -1}, {Second: 60}} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%+v.Next did not panic", w)
+				}
+			}()
+			w.Next(time.Now())
+		}()
+	}
+}
@@ -0,0 +1,97 @@
+package kairos
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// WithWatchdog makes a clock created by [NewClock] or [NewClockFromFunc] watch its timer routine:
+// if the routine spends threshold or longer of real time on one batch of expirations without going
+// back to sleep, for example because an [Observer], a [ClockListener], the handler of
+// [WithLogger], or the [Sleeper] blocks it, the clock calls report with how long the routine has
+// been stuck, once per stall, and [RunnerHealth.Stalled] reports the stall until the routine moves
+// on.  Without the watchdog, a stalled routine only shows as timers that never fire.
+//
+// The watchdog checks the routine every quarter of threshold, from a goroutine that runs until the
+// clock is shut down, and calls report from that goroutine.  The option has no effect on clocks
+// without a timer routine; see [RunnerHealth].  WithWatchdog panics if threshold is not positive.
+func WithWatchdog(threshold time.Duration, report func(stalledFor time.Duration)) ClockOption {
+	if threshold <= 0 {
+		panic("kairos: non-positive threshold for WithWatchdog")
+	}
+	return func(cfg *clockConfig) { cfg.watchdog = &watchdog{threshold: threshold, report: report} }
+}
+
+// A watchdog is the state of the watch over a clock's timer routine.
+type watchdog struct {
+	threshold time.Duration
+	report    func(stalledFor time.Duration)
+	base      time.Time // Origin of busySince, for a monotonic reading.
+	// busySince is one more than the time since base at which the routine woke up to fire the
+	// timers, or 0 while the routine sleeps.
+	busySince atomic.Int64
+}
+
+// setWatchdog applies the watchdog option of cfg to clk, which must have a timer routine, and
+// starts the watchdog.
+func (clk *clock) setWatchdog(cfg clockConfig) {
+	if cfg.watchdog == nil {
+		return
+	}
+	clk.watchdog = &watchdog{threshold: cfg.watchdog.threshold, report: cfg.watchdog.report,
+		base: time.Now()}
+	go clk.watch()
+}
+
+// busy records that the timer routine woke up.  It does nothing if w is nil.
+func (w *watchdog) busy() {
+	if w != nil {
+		w.busySince.Store(int64(time.Since(w.base)) + 1)
+	}
+}
+
+// idle records that the timer routine goes to sleep.  It does nothing if w is nil.
+func (w *watchdog) idle() {
+	if w != nil {
+		w.busySince.Store(0)
+	}
+}
+
+// stalled returns how long the timer routine has been busy, if threshold or longer, and 0
+// otherwise or if w is nil.
+func (w *watchdog) stalled() time.Duration {
+	if w == nil {
+		return 0
+	}
+	since := w.busySince.Load()
+	if since == 0 {
+		return 0
+	}
+	if d := time.Since(w.base) - time.Duration(since-1); d >= w.threshold {
+		return d
+	}
+	return 0
+}
+
+// watch reports the stalls of the timer routine until the clock is shut down.
+func (clk *clock) watch() {
+	w := clk.watchdog
+	ticker := time.NewTicker(max(w.threshold/4, time.Millisecond))
+	defer ticker.Stop()
+	var reported int64 // busySince of the last stall reported.
+	for {
+		select {
+		case <-clk.quitC:
+			return
+		case <-ticker.C:
+		}
+		since := w.busySince.Load()
+		if since == reported {
+			continue
+		}
+		if d := w.stalled(); d > 0 && w.busySince.Load() == since {
+			reported = since
+			w.report(d)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package kairos
+
+import (
+	"testing"
+	"time"
+)
+
+// blockingObserver blocks the timer routine on each expiration until release is closed.
+type blockingObserver struct {
+	release chan struct{}
+}
+
+func (o blockingObserver) TimerCreated()                 {}
+func (o blockingObserver) TimerReset(bool)               {}
+func (o blockingObserver) TimerStopped(bool)             {}
+func (o blockingObserver) TimerFired(late time.Duration) { <-o.release }
+
+func TestWatchdog(t *testing.T) {
+	obs := blockingObserver{make(chan struct{})}
+	stalls := make(chan time.Duration, 10)
+	c := NewClock(WithObserver(obs), WithWatchdog(10*time.Millisecond, func(d time.Duration) {
+		stalls <- d
+	}))
+	defer c.Close()
+	if h := diagnostics(c).Health(); h.Stalled != 0 {
+		t.Errorf("got stall %v before any expiration, want none", h.Stalled)
+	}
+	timer := c.NewTimer(time.Millisecond)
+	if d := <-stalls; d < 10*time.Millisecond {
+		t.Errorf("got stall of %v, want at least 10ms", d)
+	}
+	// The routine is stuck holding the lock, which Health must not wait for.
+	if h := diagnostics(c).Health(); h.Stalled < 10*time.Millisecond || !h.Running {
+		t.Errorf("got health %+v while stuck, want running and stalled for at least 10ms", h)
+	}
+	close(obs.release)
+	<-timer.C
+	for diagnostics(c).Health().Stalled != 0 {
+		time.Sleep(time.Millisecond)
+	}
+	select {
+	case d := <-stalls:
+		t.Errorf("got a second report of a stall of %v, want one", d)
+	default:
+	}
+}
+
+func TestWatchdogPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("WithWatchdog(0, f) did not panic")
+		}
+	}()
+	WithWatchdog(0, func(time.Duration) {})
+}
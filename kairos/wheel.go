@@ -0,0 +1,214 @@
+package kairos
+
+import (
+	"math"
+	"time"
+	"unsafe"
+)
+
+// A timerWheel is a hashed timing wheel.  Each armed timer is placed in the slot of its deadline,
+// rounded up to a multiple of tick, and the timers of a slot are due together at the end of the
+// slot, in no particular order.  Slots are created when a timer is first placed in them and kept
+// in a heap ordered by time, so that the wheel never steps through empty slots, and are deleted
+// when they become empty.
+type timerWheel struct {
+	tick     time.Duration
+	origin   time.Time            // Origin of the slot numbers, a multiple of tick.
+	slots    map[int64]*wheelSlot // Slots by number.
+	order    []*wheelSlot         // Heap of the slots ordered by number.
+	free     []*wheelSlot         // Emptied slots kept for reuse, to avoid allocations.
+	n        int                  // Number of timers.
+	capacity int                  // Sum of the capacities of the timer slices of the slots.
+	timerCounts
+}
+
+// A wheelSlot holds the timers whose deadlines fall in one tick of a timerWheel.
+type wheelSlot struct {
+	num    int64     // Number of ticks from the wheel's origin to the end of the slot.
+	i      int       // Index in the heap of slots.
+	due    time.Time // End of the slot.
+	timers []*Timer
+}
+
+// maxFreeSlots is the maximum number of emptied slots a timerWheel keeps for reuse.
+const maxFreeSlots = 64
+
+func newTimerWheel(tick time.Duration) *timerWheel {
+	return &timerWheel{tick: tick, slots: map[int64]*wheelSlot{}}
+}
+
+// num returns the number of the slot of deadline when: the number of ticks from the origin to when,
+// rounded up.  Deadlines too far from the origin to be represented share the extreme slots.
+func (w *timerWheel) num(when time.Time) int64 {
+	d := when.Sub(w.origin)
+	n := int64(d / w.tick)
+	if d%w.tick > 0 {
+		n++
+	}
+	return n
+}
+
+// slot returns the slot holding t, or nil if t is not in the wheel.
+func (w *timerWheel) slot(t *Timer) *wheelSlot {
+	if w.n == 0 || t.i < 0 {
+		return nil
+	}
+	s := w.slots[w.num(t.key)]
+	if s == nil || t.i >= len(s.timers) || s.timers[t.i] != t {
+		return nil
+	}
+	return s
+}
+
+func (w *timerWheel) Len() int               { return w.n }
+func (w *timerWheel) Has(t *Timer) bool      { return w.slot(t) != nil }
+func (w *timerWheel) Due(t *Timer) time.Time { return w.slot(t).due }
+func (w *timerWheel) Counts() timerCounts    { return w.timerCounts }
+
+func (w *timerWheel) AppendTo(dst []*Timer) []*Timer {
+	for _, s := range w.order {
+		dst = append(dst, s.timers...)
+	}
+	return dst
+}
+
+func (w *timerWheel) Insert(t *Timer) {
+	if w.origin.IsZero() {
+		// Align the slots with multiples of tick.  Truncate drops the monotonic clock reading, so
+		// subtract from the deadline instead.
+		w.origin = t.when.Add(-t.when.Sub(t.when.Truncate(w.tick)))
+	}
+	t.key, t.keySeq = t.when, t.seq
+	num := w.num(t.when)
+	s := w.slots[num]
+	if s == nil {
+		s = w.newSlot(num)
+	}
+	t.i = len(s.timers)
+	c := cap(s.timers)
+	s.timers = append(s.timers, t)
+	w.capacity += cap(s.timers) - c
+	w.n++
+	w.add(t, 1)
+}
+
+func (w *timerWheel) Remove(t *Timer) bool {
+	s := w.slot(t)
+	if s == nil {
+		return false
+	}
+	last := len(s.timers) - 1
+	if t.i != last {
+		s.timers[t.i] = s.timers[last]
+		s.timers[t.i].i = t.i
+	}
+	s.timers[last] = nil
+	s.timers = s.timers[:last]
+	t.i = -1
+	w.n--
+	w.add(t, -1)
+	if last == 0 {
+		w.deleteSlot(s)
+	}
+	return true
+}
+
+func (w *timerWheel) Peek() *Timer {
+	if len(w.order) == 0 {
+		return nil
+	}
+	return w.order[0].timers[0]
+}
+
+func (w *timerWheel) Size() (capacity int, bytes int64) {
+	const slotBytes = int64(unsafe.Sizeof(wheelSlot{})) + 16 // Slot and its map entry.
+	ptr := int64(unsafe.Sizeof((*Timer)(nil)))
+	bytes = int64(w.capacity)*ptr + int64(len(w.slots)+len(w.free))*slotBytes + int64(cap(w.order))*ptr
+	return w.capacity, bytes
+}
+
+// newSlot adds an empty slot with number num.
+func (w *timerWheel) newSlot(num int64) *wheelSlot {
+	var s *wheelSlot
+	if n := len(w.free); n > 0 {
+		s = w.free[n-1]
+		w.free[n-1] = nil
+		w.free = w.free[:n-1]
+	} else {
+		s = &wheelSlot{}
+	}
+	s.num = num
+	if num > int64(math.MaxInt64/w.tick) {
+		s.due = w.origin.Add(math.MaxInt64) // The slot of the deadlines beyond reach.
+	} else {
+		s.due = w.origin.Add(time.Duration(num) * w.tick)
+	}
+	w.slots[num] = s
+	w.push(s)
+	return s
+}
+
+// deleteSlot removes the empty slot s.
+func (w *timerWheel) deleteSlot(s *wheelSlot) {
+	last := len(w.order) - 1
+	if i := s.i; i != last {
+		w.order[i] = w.order[last]
+		w.order[i].i = i
+		w.order[last] = nil
+		w.order = w.order[:last]
+		w.up(i)
+		w.down(i)
+	} else {
+		w.order[last] = nil
+		w.order = w.order[:last]
+	}
+	delete(w.slots, s.num)
+	if len(w.free) < maxFreeSlots {
+		w.free = append(w.free, s)
+	} else {
+		w.capacity -= cap(s.timers)
+	}
+}
+
+// Heap maintenance of the slots.
+
+func (w *timerWheel) push(s *wheelSlot) {
+	s.i = len(w.order)
+	w.order = append(w.order, s)
+	w.up(s.i)
+}
+
+func (w *timerWheel) swap(i, j int) {
+	w.order[i], w.order[j] = w.order[j], w.order[i]
+	w.order[i].i = i
+	w.order[j].i = j
+}
+
+func (w *timerWheel) up(i int) {
+	for i > 0 {
+		p := (i - 1) / 2
+		if w.order[p].num <= w.order[i].num {
+			break
+		}
+		w.swap(i, p)
+		i = p
+	}
+}
+
+func (w *timerWheel) down(i int) {
+	n := len(w.order)
+	for {
+		c := 2*i + 1
+		if c >= n {
+			break
+		}
+		if c+1 < n && w.order[c+1].num < w.order[c].num {
+			c++
+		}
+		if w.order[i].num <= w.order[c].num {
+			break
+		}
+		w.swap(i, c)
+		i = c
+	}
+}